@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/sha256"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// abuseSignal scores one sign that content was written as a prompt for the
+// model rather than as text someone wants to understand
+type abuseSignal func(content string) int
+
+// Phrases that address a language model instead of other people
+var promptPhrasePattern = regexp.MustCompile(`(?i)\b(ignore (all |any )?(previous|prior|above) instructions|you are (now )?an? (ai|assistant|language model)|act as an?|pretend (to be|you are)|respond only with|write (me )?an? (essay|poem|story|script|program|function|code)|explain (to me )?(how|why|what)|generate an?|summari[sz]e (this|the following)|answer (the following|this question)|do not translate|instead of translating)\b`)
+
+// promptPhrases scores instructions aimed at the model
+func promptPhrases(content string) int {
+	return 3 * len(promptPhrasePattern.FindAllString(content, 3))
+}
+
+// Inputs longer than this look more like prompts than chat messages
+const longInputLength = 1500
+
+// longInput scores very long inputs, one more point per extra length
+func longInput(content string) int {
+	n := len([]rune(content))
+	if n < longInputLength {
+		return 0
+	}
+	return 1 + n/longInputLength
+}
+
+// Signals summed by abuseScore; add to the list to plug in another
+var abuseSignals = []abuseSignal{promptPhrases, longInput}
+
+// abuseScore rates how likely content is someone using the bot as a free
+// model rather than asking for a translation; 0 means no sign at all
+func abuseScore(content string) int {
+	score := 0
+	for _, signal := range abuseSignals {
+		score += signal(content)
+	}
+	return score
+}
+
+// Points added each time a user repeats a request they made recently
+const repeatScore = 2
+
+// abuseVerdict is what to do with a request given its score
+type abuseVerdict int
+
+const (
+	abuseAllow abuseVerdict = iota
+	abuseThrottle
+	abuseRefuse
+)
+
+// abuseGuard scores requests and remembers recent ones per user, to spot
+// the same crafted input sent over and over
+type abuseGuard struct {
+	mu       sync.Mutex
+	throttle int
+	refuse   int
+	window   time.Duration
+	// user ID to content hash to when it was last requested
+	recent map[string]map[[sha256.Size]byte]time.Time
+	// user ID to when a throttled request was last let through
+	throttled map[string]time.Time
+}
+
+func newAbuseGuard(throttle, refuse int, window time.Duration) *abuseGuard {
+	return &abuseGuard{
+		throttle:  throttle,
+		refuse:    refuse,
+		window:    window,
+		recent:    make(map[string]map[[sha256.Size]byte]time.Time),
+		throttled: make(map[string]time.Time),
+	}
+}
+
+// check scores a request from userID. Prompt-like English translated into
+// English counts twice, since it translates to nothing new. A throttled
+// user gets at most one suspicious request through per window.
+func (g *abuseGuard) check(userID, content, targetLang string, now time.Time) (int, abuseVerdict) {
+	score := abuseScore(content)
+	if strings.EqualFold(targetLang, "English") {
+		score += promptPhrases(content)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for user, requests := range g.recent {
+		for sum, at := range requests {
+			if now.Sub(at) >= g.window {
+				delete(requests, sum)
+			}
+		}
+		if len(requests) == 0 {
+			delete(g.recent, user)
+		}
+	}
+	for user, last := range g.throttled {
+		if now.Sub(last) >= g.window {
+			delete(g.throttled, user)
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(content)))
+	if _, ok := g.recent[userID][sum]; ok {
+		score += repeatScore
+	}
+	if g.recent[userID] == nil {
+		g.recent[userID] = make(map[[sha256.Size]byte]time.Time)
+	}
+	g.recent[userID][sum] = now
+
+	switch {
+	case g.refuse > 0 && score >= g.refuse:
+		return score, abuseRefuse
+	case g.throttle > 0 && score >= g.throttle:
+		if last, ok := g.throttled[userID]; ok && now.Sub(last) < g.window {
+			return score, abuseThrottle
+		}
+		g.throttled[userID] = now
+	}
+	return score, abuseAllow
+}
+
+// Sent to users whose request looks like a prompt rather than a message
+const abuseNotice = "That message looks like instructions for an AI rather than text to translate, so I'm not translating it."
+
+// allowContent reports whether a translation of content may go ahead, logging
+// requests that were held back
+func (h *DiscordHandler) allowContent(userID, content, targetLang string) bool {
+	if h.abuse == nil {
+		return true
+	}
+	score, verdict := h.abuse.check(userID, content, targetLang, time.Now())
+	if verdict == abuseAllow {
+		return true
+	}
+	log.Printf("Holding back translation for user %s with abuse score %d", userID, score)
+	return false
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Marks the start of each item in a batched prompt, e.g. [[1]]
+var batchMarkerPattern = regexp.MustCompile(`(?m)^\[\[(\d+)\]\]\s*`)
+
+// joinBatch numbers texts so their translations can be told apart
+func joinBatch(texts []string) string {
+	var b strings.Builder
+	for i, text := range texts {
+		fmt.Fprintf(&b, "[[%d]] %s\n", i+1, text)
+	}
+	return b.String()
+}
+
+// batchInstruction asks the model to keep the count numbered items of a
+// joinBatch prompt apart, so splitBatch can recover them
+func batchInstruction(count int) string {
+	return fmt.Sprintf("The text is %d separate items, each starting with a marker such as [[1]]. Translate each item on its own and start its translation on a new line with the same marker, keeping every marker exactly as written and in order.", count)
+}
+
+// splitBatch recovers count numbered items from a batched reply. It fails if
+// the model dropped, merged or renumbered any of them.
+func splitBatch(reply string, count int) ([]string, error) {
+	locs := batchMarkerPattern.FindAllStringSubmatchIndex(reply, -1)
+	if len(locs) != count {
+		return nil, fmt.Errorf("expected %d items, got %d", count, len(locs))
+	}
+
+	items := make([]string, count)
+	for i, loc := range locs {
+		n, err := strconv.Atoi(reply[loc[2]:loc[3]])
+		if err != nil || n != i+1 {
+			return nil, fmt.Errorf("item %d is out of order", i+1)
+		}
+
+		end := len(reply)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		items[i] = strings.TrimSpace(reply[loc[1]:end])
+		if items[i] == "" {
+			return nil, fmt.Errorf("item %d is empty", i+1)
+		}
+	}
+	return items, nil
+}
+
+// Shown in place of an item that couldn't be translated
+const failedMarker = "(failed)"
+
+// batchItem is the outcome of translating one text in a batch
+type batchItem struct {
+	Text string
+	Err  error
+}
+
+// batchSummary counts the successes and failures in a batch
+func batchSummary(items []batchItem) string {
+	failed := 0
+	for _, item := range items {
+		if item.Err != nil {
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d translated, %d failed", len(items)-failed, failed)
+}
+
+// display returns the translation, or a marker if it failed
+func (b batchItem) display() string {
+	if b.Err != nil {
+		return failedMarker
+	}
+	return b.Text
+}
+
+// batchable reports whether a masked text can share a batched call. Texts
+// with spans translated on their own, such as spoilers, several lines, or
+// nothing to translate go through the full pipeline one by one instead.
+func batchable(text, masked string, tokens []protectedToken) bool {
+	if strings.Contains(text, "\n") || onlyPlaceholders(masked) {
+		return false
+	}
+	for _, token := range tokens {
+		for _, part := range token.parts {
+			if part.translate {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// translateBatch translates several short texts with a single provider call,
+// falling back to one call per text if the reply can't be split back up.
+// Batched texts get the same protection, cleanup, cache and history as
+// single translations; those that can't be batched are translated one by
+// one. Items that fail on their own don't stop the rest.
+func (h *DiscordHandler) translateBatch(ctx context.Context, guildID string, texts []string, targetLang string) []batchItem {
+	if len(texts) == 0 {
+		return nil
+	}
+
+	items := make([]batchItem, len(texts))
+	if h.blocksLanguage(targetLang) {
+		for i := range items {
+			items[i].Err = errUnreliableLanguage
+		}
+		return items
+	}
+	ctx = h.guildContext(ctx, guildID)
+	tokens := h.tokenOptions()
+	tokens.terms = h.guildConfig(guildID).glossaryFor(targetLang)
+	formality := h.formalityFor(guildID, targetLang)
+	cacheLang := cacheLanguage(targetLang, formality, tokens.terms)
+
+	// Detecting the source, when it's needed, is done per text
+	sharedCall := !h.detectsSource() && !needsSourceFor(h.config.PairPrompts, targetLang)
+
+	var batched, alone []int
+	masked := make([]string, len(texts))
+	protected := make([][]protectedToken, len(texts))
+	for i, text := range texts {
+		if !sharedCall {
+			alone = append(alone, i)
+			continue
+		}
+		masked[i], protected[i] = protectTokens(text, tokens)
+		if !batchable(text, masked[i], protected[i]) {
+			alone = append(alone, i)
+			continue
+		}
+		if h.cache != nil {
+			if result, hit := h.cachedTranslation(guildID, "", text, targetLang, cacheLang); hit {
+				items[i].Text = result.Text
+				continue
+			}
+		}
+		batched = append(batched, i)
+	}
+
+	if len(batched) == 1 {
+		alone = append(alone, batched...)
+	} else if len(batched) > 1 {
+		alone = append(alone, h.translateShared(ctx, guildID, texts, masked, protected, batched, targetLang, formality, cacheLang, items)...)
+	}
+
+	// Keep the items in order in the logs
+	slices.Sort(alone)
+	for _, i := range alone {
+		items[i].Text, items[i].Err = h.translate(ctx, guildID, "", texts[i], targetLang)
+		if items[i].Err != nil {
+			log.Printf("Error translating batch item %d: %v", i+1, items[i].Err)
+		}
+	}
+	return items
+}
+
+// translateShared translates the batched texts with one provider call,
+// filling in their items. It returns the texts still to be translated one
+// by one: all of them if the call fails or its reply can't be split up,
+// and any that come back in the wrong script when that's checked.
+func (h *DiscordHandler) translateShared(ctx context.Context, guildID string, texts, masked []string, protected [][]protectedToken, batched []int, targetLang string, formality Formality, cacheLang string, items []batchItem) []int {
+	joined := make([]string, len(batched))
+	for n, i := range batched {
+		joined[n] = masked[i]
+	}
+	reply, err := h.routeFor(targetLang).Translate(ctx, TranslateRequest{
+		Text:         joinBatch(joined),
+		TargetLang:   targetLang,
+		Formality:    formality,
+		Instructions: batchInstruction(len(batched)),
+	})
+	var translations []string
+	if err == nil {
+		translations, err = splitBatch(reply.Text, len(batched))
+	}
+	if err != nil {
+		log.Printf("Batch translation failed, translating items one by one: %v", err)
+		return batched
+	}
+
+	var retry []int
+	for n, i := range batched {
+		translation, err := tokenRestorer(protected[i]).Process(translations[n])
+		if err == nil {
+			translation, err = h.finishTranslation(texts[i], translation)
+		}
+		if err != nil || (h.config.VerifyScript && scriptMismatch(translation, targetLang)) {
+			retry = append(retry, i)
+			continue
+		}
+		items[i].Text = translation
+		h.metrics.recordTranslation(targetLang, nil)
+		h.recordFresh(guildID, "", texts[i], targetLang, cacheLang, translationResult{Text: translation}, h.cache != nil)
+	}
+	return retry
+}
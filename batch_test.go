@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestJoinBatch(t *testing.T) {
+	got := joinBatch([]string{"hello", "good night"})
+	want := "[[1]] hello\n[[2]] good night\n"
+	if got != want {
+		t.Errorf("joinBatch() = %q, want %q", got, want)
+	}
+}
+
+func TestBatchPrompt(t *testing.T) {
+	prompt := translationPrompt(TranslateRequest{
+		Text:         joinBatch([]string{"hello", "good night"}),
+		TargetLang:   "French",
+		Instructions: batchInstruction(2),
+	})
+	for _, want := range []string{
+		"Translate the following text to French.",
+		"2 separate items",
+		"marker such as [[1]]",
+		"keeping every marker exactly as written and in order",
+		"[[1]] hello\n[[2]] good night\n",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("batch prompt %q is missing %q", prompt, want)
+		}
+	}
+}
+
+func TestTranslateBatchSharesOneCall(t *testing.T) {
+	translator := &fakeTranslator{reply: func(req TranslateRequest) (string, error) {
+		return strings.ReplaceAll(req.Text, "]] ", "]] fr:"), nil
+	}}
+	h := newTestHandler(t, Config{}, translator)
+	items := h.translateBatch(context.Background(), "g1", []string{"hello", "good night"}, "French")
+
+	if translator.count() != 1 {
+		t.Fatalf("got %d translation calls, want 1", translator.count())
+	}
+	if got := translator.calls[0].Instructions; got != batchInstruction(2) {
+		t.Errorf("batch call instructions = %q, want %q", got, batchInstruction(2))
+	}
+	var got []string
+	for _, item := range items {
+		got = append(got, item.display())
+	}
+	if want := []string{"fr:hello", "fr:good night"}; !slices.Equal(got, want) {
+		t.Errorf("translateBatch() = %q, want %q", got, want)
+	}
+}
+
+func TestSplitBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		count   int
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "round trip",
+			reply: joinBatch([]string{"bonjour", "bonne nuit"}),
+			count: 2,
+			want:  []string{"bonjour", "bonne nuit"},
+		},
+		{
+			name:  "text before the first marker is dropped",
+			reply: "Here you go:\n[[1]] hola\n[[2]] adiós",
+			count: 2,
+			want:  []string{"hola", "adiós"},
+		},
+		{
+			name:  "items across lines",
+			reply: "[[1]] one\ncontinued\n[[2]] two",
+			count: 2,
+			want:  []string{"one\ncontinued", "two"},
+		},
+		{name: "dropped item", reply: "[[1]] one", count: 2, wantErr: true},
+		{name: "merged items", reply: "[[1]] one two [[2]]", count: 2, wantErr: true},
+		{name: "renumbered", reply: "[[2]] one\n[[1]] two", count: 2, wantErr: true},
+		{name: "empty item", reply: "[[1]] one\n[[2]]   ", count: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitBatch(tt.reply, tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitBatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchable(t *testing.T) {
+	opts := tokenOptions{numbers: true}
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"see you at 5", true},
+		{"two\nlines", false},
+		{"a ||spoiler|| here", false},
+		{"42", false},
+	}
+	for _, tt := range tests {
+		masked, tokens := protectTokens(tt.text, opts)
+		if got := batchable(tt.text, masked, tokens); got != tt.want {
+			t.Errorf("batchable(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateBatchPartialFailure(t *testing.T) {
+	errDown := errors.New("down")
+	translator := &fakeTranslator{reply: func(req TranslateRequest) (string, error) {
+		switch {
+		case strings.Contains(req.Text, "[[1]]"):
+			// The shared call comes back unusable, so each text is retried alone
+			return "garbled", nil
+		case req.Text == "cursed":
+			return "", errDown
+		}
+		return "fr:" + req.Text, nil
+	}}
+	h := newTestHandler(t, Config{}, translator)
+	items := h.translateBatch(context.Background(), "g1", []string{"hello", "cursed", "bye"}, "French")
+
+	var got []string
+	for _, item := range items {
+		got = append(got, item.display())
+	}
+	if want := []string{"fr:hello", failedMarker, "fr:bye"}; !slices.Equal(got, want) {
+		t.Errorf("translateBatch() = %q, want %q", got, want)
+	}
+	if !errors.Is(items[1].Err, errDown) {
+		t.Errorf("failed item error = %v, want %v", items[1].Err, errDown)
+	}
+	if got := batchSummary(items); got != "2 translated, 1 failed" {
+		t.Errorf("batchSummary() = %q", got)
+	}
+}
+
+func TestBatchSummary(t *testing.T) {
+	errDown := errors.New("down")
+	tests := []struct {
+		items []batchItem
+		want  string
+	}{
+		{nil, "0 translated, 0 failed"},
+		{[]batchItem{{Text: "a"}, {Text: "b"}}, "2 translated, 0 failed"},
+		{[]batchItem{{Err: errDown}, {Text: "b"}, {Err: errDown}}, "1 translated, 2 failed"},
+	}
+	for _, tt := range tests {
+		if got := batchSummary(tt.items); got != tt.want {
+			t.Errorf("batchSummary() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// budgetPeriod names the monthly period now falls in
+func budgetPeriod(now time.Time) string {
+	return now.UTC().Format("2006-01")
+}
+
+// spendTracker estimates what each guild spends on the shared OpenAI key
+// this month and warns once per month when a guild passes the warning
+// fraction of its budget
+type spendTracker struct {
+	mu        sync.Mutex
+	costPer1K float64
+	budget    float64
+	threshold float64
+	period    string
+	spent     map[string]float64
+	warned    map[string]bool
+
+	// warn is called, without the lock held, when a guild crosses the
+	// threshold
+	warn func(guildID string, spent, budget float64)
+}
+
+func newSpendTracker(costPer1K, budget, threshold float64, warn func(guildID string, spent, budget float64)) *spendTracker {
+	return &spendTracker{
+		costPer1K: costPer1K,
+		budget:    budget,
+		threshold: threshold,
+		spent:     make(map[string]float64),
+		warned:    make(map[string]bool),
+		warn:      warn,
+	}
+}
+
+// record adds the cost of usage to a guild's spend and reports whether this
+// pushed it over the warning threshold for the first time this period
+func (t *spendTracker) record(guildID string, usage Usage, now time.Time) bool {
+	t.mu.Lock()
+	if period := budgetPeriod(now); period != t.period {
+		t.period = period
+		t.spent = make(map[string]float64)
+		t.warned = make(map[string]bool)
+	}
+	tokens := usage.PromptTokens + usage.CompletionTokens
+	t.spent[guildID] += float64(tokens) / 1000 * t.costPer1K
+	spent := t.spent[guildID]
+	crossed := !t.warned[guildID] && spent >= t.budget*t.threshold
+	if crossed {
+		t.warned[guildID] = true
+	}
+	t.mu.Unlock()
+
+	if crossed && t.warn != nil {
+		t.warn(guildID, spent, t.budget)
+	}
+	return crossed
+}
+
+// recordUsage is an OpenAITranslator usage hook. Calls made with a guild's
+// own key don't count against the shared budget.
+func (t *spendTracker) recordUsage(ctx context.Context, usage Usage) {
+	guildID := contextGuild(ctx)
+	if guildID == "" || apiKey(ctx, "") != "" {
+		return
+	}
+	t.record(guildID, usage, time.Now())
+}
+
+// budgetWarning posts a budget warning to the guild's log channel, or only
+// logs it when the guild has none
+func budgetWarning(s *discordgo.Session, logChannels map[string]string) func(guildID string, spent, budget float64) {
+	return func(guildID string, spent, budget float64) {
+		message := fmt.Sprintf("Heads up: translations in this server have used about $%.2f of the $%.2f monthly budget (%.0f%%).",
+			spent, budget, spent/budget*100)
+		log.Printf("Guild %s passed its budget warning threshold: $%.2f of $%.2f", guildID, spent, budget)
+		channelID, ok := logChannels[guildID]
+		if !ok {
+			return
+		}
+		if _, err := s.ChannelMessageSend(channelID, message); err != nil {
+			log.Printf("Error sending budget warning: %v", err)
+		}
+	}
+}
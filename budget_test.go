@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpendTrackerWarnsOncePerPeriod(t *testing.T) {
+	january := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	february := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	type call struct {
+		guild  string
+		tokens int
+		at     time.Time
+		want   bool
+	}
+	// $1 per 1K tokens against a $10 budget warned at 80%, i.e. 8K tokens
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{"under the threshold", []call{{"g1", 7000, january, false}}},
+		{"crossing the threshold", []call{{"g1", 7000, january, false}, {"g1", 1000, january, true}}},
+		{"not again in the same period", []call{
+			{"g1", 9000, january, true},
+			{"g1", 1000, january, false},
+			{"g1", 5000, january.Add(time.Hour), false},
+		}},
+		{"again the next period", []call{{"g1", 9000, january, true}, {"g1", 9000, february, true}}},
+		{"spend starts over each period", []call{{"g1", 7000, january, false}, {"g1", 7000, february, false}}},
+		{"guilds apart", []call{{"g1", 7000, january, false}, {"g2", 7000, january, false}, {"g2", 9000, january, true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			tracker := newSpendTracker(1, 10, 0.8, func(guildID string, spent, budget float64) {
+				warnings = append(warnings, guildID)
+			})
+			wantWarnings := 0
+			for n, c := range tt.calls {
+				if got := tracker.record(c.guild, Usage{PromptTokens: c.tokens}, c.at); got != c.want {
+					t.Errorf("call %d: record() = %v, want %v", n, got, c.want)
+				}
+				if c.want {
+					wantWarnings++
+				}
+			}
+			if len(warnings) != wantWarnings {
+				t.Errorf("warned %d times, want %d", len(warnings), wantWarnings)
+			}
+		})
+	}
+}
+
+func TestSpendTrackerRecordUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want float64
+	}{
+		{name: "guild on the shared key", ctx: context.WithValue(context.Background(), guildIDContextKey{}, "g1"), want: 2},
+		{name: "guild with its own key", ctx: withAPIKey(context.WithValue(context.Background(), guildIDContextKey{}, "g1"), "sk-own")},
+		{name: "outside a guild", ctx: context.Background()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newSpendTracker(1, 10, 0.8, nil)
+			tracker.recordUsage(tt.ctx, Usage{PromptTokens: 1500, CompletionTokens: 500})
+			if got := tracker.spent["g1"]; got != tt.want {
+				t.Errorf("spent = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBudgetWarning(t *testing.T) {
+	s, fake := newTestSession(t)
+	warn := budgetWarning(s, map[string]string{"g1": "logs"})
+	warn("g1", 8.5, 10)
+	warn("g2", 9, 10)
+
+	messages := sentMessages(t, fake, "logs")
+	if len(messages) != 1 {
+		t.Fatalf("sent %d warnings, want 1 to the log channel", len(messages))
+	}
+	if !strings.Contains(messages[0].Content, "$8.50 of the $10.00 monthly budget (85%)") {
+		t.Errorf("warning = %q, want the spend and budget", messages[0].Content)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+	"golang.org/x/text/unicode/norm"
+)
+
+// How aggressively source text is normalized before computing cache keys
+const (
+	// Exact text only
+	normalizeNone = "none"
+	// Unicode NFC, trimmed, with runs of whitespace collapsed and invisible
+	// formatting characters such as zero-width spaces removed
+	normalizeBasic = "basic"
+	// Basic plus case folding and ignoring trailing punctuation
+	normalizeAggressive = "aggressive"
+)
+
+// normalizeForCache makes near-duplicate messages compare equal
+func normalizeForCache(content, level string) string {
+	if level == normalizeNone {
+		return content
+	}
+
+	content = norm.NFC.String(content)
+	// Zero-width and other invisible characters would otherwise make
+	// identical-looking messages miss the cache
+	content = strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, content)
+	// Line breaks shape the translation, so only spacing within a line
+	// is collapsed
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	content = strings.Trim(strings.Join(lines, "\n"), "\n")
+	if level == normalizeAggressive {
+		content = strings.ToLower(content)
+		content = strings.TrimRightFunc(content, unicode.IsPunct)
+	}
+	return content
+}
+
+// How homoglyphs are folded before computing cache keys
+const (
+	homoglyphsNone = "none"
+	// Only in words that mix Latin letters with lookalikes, as in spoofed
+	// text; genuine Cyrillic or Greek words are left alone
+	homoglyphsMixed = "mixed"
+	homoglyphsAll   = "all"
+)
+
+// Cyrillic and Greek letters that look like Latin ones
+var homoglyphs = map[rune]rune{
+	'а': 'a', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'һ': 'h', 'і': 'i', 'ј': 'j',
+	'о': 'o', 'р': 'p', 'ѕ': 's', 'х': 'x', 'у': 'y',
+	'А': 'A', 'В': 'B', 'С': 'C', 'Е': 'E', 'Н': 'H', 'І': 'I', 'Ј': 'J',
+	'К': 'K', 'М': 'M', 'О': 'O', 'Р': 'P', 'Ѕ': 'S', 'Т': 'T', 'Х': 'X',
+	'α': 'a', 'ο': 'o', 'ν': 'v', 'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Η': 'H',
+	'Ι': 'I', 'Κ': 'K', 'Μ': 'M', 'Ν': 'N', 'Ο': 'O', 'Ρ': 'P', 'Τ': 'T',
+	'Χ': 'X', 'Υ': 'Y', 'Ζ': 'Z',
+}
+
+// foldHomoglyphs replaces lookalike letters with their Latin counterparts
+func foldHomoglyphs(content, mode string) string {
+	if mode != homoglyphsMixed && mode != homoglyphsAll {
+		return content
+	}
+	fold := func(r rune) rune {
+		if latin, ok := homoglyphs[r]; ok {
+			return latin
+		}
+		return r
+	}
+	if mode == homoglyphsAll {
+		return strings.Map(fold, content)
+	}
+
+	// Walk word by word, keeping the spacing between words as it is so line
+	// breaks still tell keys apart
+	var b strings.Builder
+	notSpace := func(r rune) bool { return !unicode.IsSpace(r) }
+	for content != "" {
+		end := strings.IndexFunc(content, unicode.IsSpace)
+		if end < 0 {
+			end = len(content)
+		}
+		word := content[:end]
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.Is(unicode.Latin, r) }) >= 0 {
+			word = strings.Map(fold, word)
+		}
+		b.WriteString(word)
+		content = content[end:]
+
+		next := strings.IndexFunc(content, notSpace)
+		if next < 0 {
+			next = len(content)
+		}
+		b.WriteString(content[:next])
+		content = content[next:]
+	}
+	return b.String()
+}
+
+// cacheKey identifies a translation of content into lang. Only the key is
+// normalized; the source shown to users is untouched.
+func (c *translationCache) cacheKey(content, lang string) string {
+	content = foldHomoglyphs(normalizeForCache(content, c.normalization), c.homoglyphs)
+	sum := sha256.Sum256([]byte(strings.ToLower(lang) + "\x00" + content))
+	return hex.EncodeToString(sum[:])
+}
+
+type cacheEntry struct {
+	translation string
+	// source is the detected language of the original, if detected
+	source  string
+	expires time.Time
+}
+
+// translationCache remembers recent translations in memory
+type translationCache struct {
+	mu            sync.Mutex
+	entries       map[string]cacheEntry
+	ttl           time.Duration
+	size          int
+	normalization string
+	homoglyphs    string
+}
+
+func newTranslationCache(ttl time.Duration, size int, normalization, homoglyphs string) *translationCache {
+	return &translationCache{
+		entries:       make(map[string]cacheEntry),
+		ttl:           ttl,
+		size:          size,
+		normalization: normalization,
+		homoglyphs:    homoglyphs,
+	}
+}
+
+func (c *translationCache) get(content, lang string, now time.Time) (translation, source string, ok bool) {
+	key := c.cacheKey(content, lang)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", "", false
+	}
+	if now.After(entry.expires) {
+		delete(c.entries, key)
+		return "", "", false
+	}
+	return entry.translation, entry.source, true
+}
+
+func (c *translationCache) put(content, lang, translation, source string, now time.Time) {
+	key := c.cacheKey(content, lang)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= c.size {
+		c.evict(now)
+	}
+	c.entries[key] = cacheEntry{translation: translation, source: source, expires: now.Add(c.ttl)}
+}
+
+// evict drops expired entries, or the one closest to expiring if none have
+func (c *translationCache) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.expires.Before(oldest) {
+			oldestKey, oldest = key, entry.expires
+		}
+	}
+	if len(c.entries) >= c.size {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// messageCache remembers translations by message ID and language, so asking
+// for the same message again skips the provider and even the content key.
+// Entries are dropped when their message is edited; the content cache keeps
+// the old text's translation, which is still right for that text.
+type messageCache struct {
+	mu      sync.Mutex
+	entries map[string]map[string]cacheEntry
+	ttl     time.Duration
+	size    int
+}
+
+func newMessageCache(ttl time.Duration, size int) *messageCache {
+	return &messageCache{
+		entries: make(map[string]map[string]cacheEntry),
+		ttl:     ttl,
+		size:    size,
+	}
+}
+
+func (c *messageCache) get(messageID, lang string, now time.Time) (translationResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[messageID][strings.ToLower(lang)]
+	if !ok {
+		return translationResult{}, false
+	}
+	if now.After(entry.expires) {
+		delete(c.entries[messageID], strings.ToLower(lang))
+		return translationResult{}, false
+	}
+	return translationResult{Text: entry.translation, SourceLang: entry.source}, true
+}
+
+func (c *messageCache) put(messageID, lang string, result translationResult, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[messageID]; !ok {
+		if len(c.entries) >= c.size {
+			c.evict(now)
+		}
+		c.entries[messageID] = make(map[string]cacheEntry)
+	}
+	c.entries[messageID][strings.ToLower(lang)] = cacheEntry{translation: result.Text, source: result.SourceLang, expires: now.Add(c.ttl)}
+}
+
+// invalidate forgets every translation of a message
+func (c *messageCache) invalidate(messageID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, messageID)
+}
+
+// evict drops messages whose translations have all expired, or the message
+// with the translation closest to expiring if none have
+func (c *messageCache) evict(now time.Time) {
+	var oldestID string
+	var oldest time.Time
+	for id, langs := range c.entries {
+		live := false
+		for _, entry := range langs {
+			if !now.After(entry.expires) {
+				live = true
+			}
+			if oldestID == "" || entry.expires.Before(oldest) {
+				oldestID, oldest = id, entry.expires
+			}
+		}
+		if !live {
+			delete(c.entries, id)
+		}
+	}
+	if len(c.entries) >= c.size {
+		delete(c.entries, oldestID)
+	}
+}
+
+// messageUpdate forgets the translations of an edited message. Updates that
+// only add link previews carry no edit time and are ignored.
+func (h *DiscordHandler) messageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	if m.Message == nil || m.EditedTimestamp == nil {
+		return
+	}
+	h.messages.invalidate(m.ID)
+}
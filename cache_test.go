@@ -0,0 +1,226 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestNormalizeForCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		level   string
+		want    string
+	}{
+		{"none keeps everything", "  Hi  there!! ", normalizeNone, "  Hi  there!! "},
+		{"basic collapses spaces", "  Hi  \t there!! ", normalizeBasic, "Hi there!!"},
+		{"basic keeps line breaks", "  Hi \n there!! ", normalizeBasic, "Hi\nthere!!"},
+		{"basic keeps blank lines", "Hi\r\n\r\nthere\n", normalizeBasic, "Hi\n\nthere"},
+		{"basic trims blank edges", "\n\nHi\n \n", normalizeBasic, "Hi"},
+		{"basic drops invisibles", "Hi\u200b there\u2060", normalizeBasic, "Hi there"},
+		{"basic composes", "cafe\u0301", normalizeBasic, "café"},
+		{"basic keeps case", "Hello", normalizeBasic, "Hello"},
+		{"aggressive lowercases", "Hello There", normalizeAggressive, "hello there"},
+		{"aggressive trims punctuation", "Really?!", normalizeAggressive, "really"},
+		{"aggressive keeps inner punctuation", "wait, what", normalizeAggressive, "wait, what"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeForCache(tt.content, tt.level); got != tt.want {
+				t.Errorf("normalizeForCache(%q, %q) = %q, want %q", tt.content, tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFoldHomoglyphs(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		mode    string
+		want    string
+	}{
+		{"none", "pаypal", homoglyphsNone, "pаypal"},
+		{"mixed folds spoofed words", "pаypal lоgin", homoglyphsMixed, "paypal login"},
+		{"mixed keeps genuine Cyrillic", "привет pаypal", homoglyphsMixed, "привет paypal"},
+		{"mixed keeps spacing", "pаypal\n\nlоgin  now", homoglyphsMixed, "paypal\n\nlogin  now"},
+		{"all folds everything", "сор", homoglyphsAll, "cop"},
+		{"greek", "Αthens", homoglyphsMixed, "Athens"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := foldHomoglyphs(tt.content, tt.mode); got != tt.want {
+				t.Errorf("foldHomoglyphs(%q, %q) = %q, want %q", tt.content, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheKeyCollapsesInjectedVariants(t *testing.T) {
+	tests := []struct {
+		name          string
+		normalization string
+		homoglyphs    string
+		a, b          string
+		same          bool
+	}{
+		{"zero-width space", normalizeBasic, homoglyphsNone, "free nitro", "free\u200b nitro", true},
+		{"zero-width joiner and word joiner", normalizeBasic, homoglyphsNone, "free nitro", "fr\u200dee\u2060 nitro", true},
+		{"byte order mark", normalizeBasic, homoglyphsNone, "free nitro", "\ufefffree nitro", true},
+		{"left to right mark", normalizeBasic, homoglyphsNone, "free nitro", "free\u200e nitro", true},
+		{"not when off", normalizeNone, homoglyphsNone, "free nitro", "free\u200b nitro", false},
+		{"homoglyphs folded", normalizeBasic, homoglyphsMixed, "free nitro", "frее nitrо", true},
+		{"homoglyphs and zero-width", normalizeBasic, homoglyphsMixed, "free nitro", "frе\u200bе nitrо", true},
+		{"homoglyphs kept by default", normalizeBasic, homoglyphsNone, "free nitro", "frее nitrо", false},
+		{"line breaks still count", normalizeBasic, homoglyphsMixed, "free nitro", "free\nnitro", false},
+		{"different text", normalizeBasic, homoglyphsAll, "free nitro", "paid nitro", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTranslationCache(time.Hour, 10, tt.normalization, tt.homoglyphs)
+			if same := c.cacheKey(tt.a, "French") == c.cacheKey(tt.b, "French"); same != tt.same {
+				t.Errorf("cacheKey(%q) == cacheKey(%q) is %v, want %v", tt.a, tt.b, same, tt.same)
+			}
+		})
+	}
+}
+
+func TestCacheHitsInjectedVariant(t *testing.T) {
+	c := newTranslationCache(time.Hour, 10, normalizeBasic, homoglyphsMixed)
+	now := time.Now()
+	c.put("free nitro", "French", "nitro gratuit", "", now)
+	if got, _, ok := c.get("frее\u200b nitrо", "french", now); !ok || got != "nitro gratuit" {
+		t.Errorf("get() = %q, %v, want the cached translation", got, ok)
+	}
+}
+
+func TestMessageCache(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		edit   func(c *messageCache)
+		id     string
+		lang   string
+		at     time.Time
+		wantOK bool
+	}{
+		{name: "hit", id: "m1", lang: "French", at: now, wantOK: true},
+		{name: "any case", id: "m1", lang: "FRENCH", at: now, wantOK: true},
+		{name: "other language", id: "m1", lang: "German", at: now},
+		{name: "other message", id: "m2", lang: "French", at: now},
+		{name: "expired", id: "m1", lang: "French", at: now.Add(2 * time.Hour)},
+		{name: "invalidated", edit: func(c *messageCache) { c.invalidate("m1") }, id: "m1", lang: "French", at: now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newMessageCache(time.Hour, 10)
+			c.put("m1", "French", translationResult{Text: "bonjour", SourceLang: "English"}, now)
+			if tt.edit != nil {
+				tt.edit(c)
+			}
+			got, ok := c.get(tt.id, tt.lang, tt.at)
+			if ok != tt.wantOK {
+				t.Fatalf("get(%s, %s) ok = %v, want %v", tt.id, tt.lang, ok, tt.wantOK)
+			}
+			if ok && (got.Text != "bonjour" || got.SourceLang != "English") {
+				t.Errorf("get() = %+v, want the cached translation", got)
+			}
+		})
+	}
+}
+
+func TestMessageCacheEviction(t *testing.T) {
+	now := time.Now()
+	c := newMessageCache(time.Hour, 2)
+	c.put("m1", "French", translationResult{Text: "un"}, now)
+	c.put("m2", "French", translationResult{Text: "deux"}, now.Add(time.Minute))
+	// More languages for a message already cached take no extra room
+	c.put("m2", "German", translationResult{Text: "zwei"}, now.Add(time.Minute))
+	c.put("m3", "French", translationResult{Text: "trois"}, now.Add(2*time.Minute))
+
+	if _, ok := c.get("m1", "French", now); ok {
+		t.Error("m1 kept, want the oldest message evicted")
+	}
+	for _, id := range []string{"m2", "m3"} {
+		if _, ok := c.get(id, "French", now); !ok {
+			t.Errorf("%s evicted, want it kept", id)
+		}
+	}
+}
+
+func TestMessageCacheEdits(t *testing.T) {
+	edited := time.Now()
+	tests := []struct {
+		name            string
+		update          *discordgo.MessageUpdate
+		newContent      string
+		wantCalls       int
+		wantInvalidated bool
+	}{
+		{
+			name:      "repeat request",
+			wantCalls: 1,
+		},
+		{
+			name:      "link preview update",
+			update:    &discordgo.MessageUpdate{Message: &discordgo.Message{ID: "m1"}},
+			wantCalls: 1,
+		},
+		{
+			// The content cache still has the unchanged text
+			name:            "edit keeping the text",
+			update:          &discordgo.MessageUpdate{Message: &discordgo.Message{ID: "m1", EditedTimestamp: &edited}},
+			wantCalls:       1,
+			wantInvalidated: true,
+		},
+		{
+			name:            "edit changing the text",
+			update:          &discordgo.MessageUpdate{Message: &discordgo.Message{ID: "m1", EditedTimestamp: &edited}},
+			newContent:      "goodbye",
+			wantCalls:       2,
+			wantInvalidated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"author"}}`
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{}, translator)
+			h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+			h.cache = newTranslationCache(time.Hour, 10, normalizeBasic, homoglyphsNone)
+			h.messages = newMessageCache(time.Hour, 10)
+
+			h.reactionAdd(s, testReaction("user", "🇫🇷"))
+			if tt.update != nil {
+				h.messageUpdate(s, tt.update)
+			}
+			if _, ok := h.messages.get("m1", "French", time.Now()); ok == tt.wantInvalidated {
+				t.Errorf("message cache hit = %v, want %v", ok, !tt.wantInvalidated)
+			}
+			if tt.newContent != "" {
+				fake.mu.Lock()
+				fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"` + tt.newContent + `","author":{"id":"author"}}`
+				fake.mu.Unlock()
+			}
+			h.reactionAdd(s, testReaction("user2", "🇫🇷"))
+
+			if translator.count() != tt.wantCalls {
+				t.Errorf("translated %d times, want %d", translator.count(), tt.wantCalls)
+			}
+			messages := sentMessages(t, fake, "c1")
+			if len(messages) != 2 {
+				t.Fatalf("sent %d translations, want 2", len(messages))
+			}
+			want := "translated: hello"
+			if tt.newContent != "" {
+				want = "translated: " + tt.newContent
+			}
+			if got := messages[1].Embeds[0].Description; got != want {
+				t.Errorf("second translation = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// channelLanguages returns the target languages a channel allows, or nil
+// if it allows any
+func (h *DiscordHandler) channelLanguages(channelID string) []string {
+	list := h.config.ChannelAllowedLanguages[channelID]
+	if list == "" {
+		return nil
+	}
+	var langs []string
+	for _, lang := range strings.Split(list, "/") {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// channelAllows reports whether a channel takes translations into lang
+func (h *DiscordHandler) channelAllows(channelID, lang string) bool {
+	allowed := h.channelLanguages(channelID)
+	if allowed == nil {
+		return true
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(a, lang) {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictedNotice tells a user which languages a channel takes
+func restrictedNotice(allowed []string) string {
+	return fmt.Sprintf("This channel only takes translations to %s.", strings.Join(allowed, ", "))
+}
+
+// channelDefaultReaction translates a message into its channel's default
+// language, for the generic translate emoji
+func (h *DiscordHandler) channelDefaultReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message) {
+	targetLang := h.config.ChannelLanguages[r.ChannelID]
+	if targetLang == "" {
+		h.skip(s, r, "channel has no default language")
+		return
+	}
+	h.translateReaction(s, r, msg, targetLang)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestChannelAllows(t *testing.T) {
+	h := newTestHandler(t, Config{ChannelAllowedLanguages: map[string]string{"c1": "French / English"}}, &fakeTranslator{})
+	tests := []struct {
+		channel string
+		lang    string
+		want    bool
+	}{
+		{"c1", "French", true},
+		{"c1", "english", true},
+		{"c1", "Spanish", false},
+		{"c2", "Spanish", true},
+	}
+	for _, tt := range tests {
+		if got := h.channelAllows(tt.channel, tt.lang); got != tt.want {
+			t.Errorf("channelAllows(%s, %s) = %v, want %v", tt.channel, tt.lang, got, tt.want)
+		}
+	}
+	if got := h.channelLanguages("c1"); !slices.Equal(got, []string{"French", "English"}) {
+		t.Errorf("channelLanguages(c1) = %q, want French and English", got)
+	}
+}
+
+func TestChannelTargets(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		emoji      string
+		want       string
+		wantNotice bool
+	}{
+		{
+			name:   "default applied",
+			config: Config{TranslateEmoji: "🌐", ChannelLanguages: map[string]string{"c1": "French"}},
+			emoji:  "🌐",
+			want:   "French",
+		},
+		{
+			name:   "no default for the channel",
+			config: Config{TranslateEmoji: "🌐", ChannelLanguages: map[string]string{"c2": "French"}},
+			emoji:  "🌐",
+		},
+		{
+			name:   "allowed target",
+			config: Config{ChannelAllowedLanguages: map[string]string{"c1": "French/English"}},
+			emoji:  "🇫🇷",
+			want:   "French",
+		},
+		{
+			name:       "restricted target rejected",
+			config:     Config{ChannelAllowedLanguages: map[string]string{"c1": "French/English"}},
+			emoji:      "🇪🇸",
+			wantNotice: true,
+		},
+		{
+			name: "default outside the allowed targets rejected",
+			config: Config{
+				TranslateEmoji:          "🌐",
+				ChannelLanguages:        map[string]string{"c1": "Spanish"},
+				ChannelAllowedLanguages: map[string]string{"c1": "French/English"},
+			},
+			emoji:      "🌐",
+			wantNotice: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"author"}}`
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, tt.config, translator)
+			h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+
+			h.reactionAdd(s, testReaction("user", tt.emoji))
+
+			if tt.want == "" {
+				if translator.count() != 0 {
+					t.Errorf("translated %d times, want none", translator.count())
+				}
+			} else if translator.count() != 1 || translator.calls[0].TargetLang != tt.want {
+				t.Errorf("translated %+v, want once to %s", translator.calls, tt.want)
+			}
+			notices := sentMessages(t, fake, "dm")
+			if tt.wantNotice {
+				if len(notices) != 1 || notices[0].Content != restrictedNotice([]string{"French", "English"}) {
+					t.Errorf("notices = %+v, want the allowed languages", notices)
+				}
+			} else if len(notices) != 0 {
+				t.Errorf("notices = %+v, want none", notices)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// clarifyPrompt asks for a plain-language explanation of text, written in
+// lang, rather than a translation
+func clarifyPrompt(text, lang string) string {
+	return fmt.Sprintf("Explain in plain, simple %s what the following chat message means, "+
+		"including any slang, abbreviations, jokes or references a reader might miss. "+
+		"Keep it short and don't translate it line by line. Only respond with the explanation:\n\n%s", lang, text)
+}
+
+// clarifyReaction posts an explanation of a confusing message
+func (h *DiscordHandler) clarifyReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message) {
+	text := messageText(msg)
+	if text == "" {
+		h.skip(s, r, "message has no text")
+		return
+	}
+	lang := h.config.NotesLang
+	if !h.allowContent(r.UserID, text, lang) {
+		h.notify(s, r.UserID, noticeAbuse, abuseNotice)
+		return
+	}
+	if !h.admitReaction(s, r) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	reply, err := h.completer.Complete(h.guildContext(ctx, r.GuildID), clarifyPrompt(text, lang))
+	if err != nil {
+		log.Printf("Error explaining message: %v", err)
+		return
+	}
+
+	embed := translationEmbed(msg, strings.TrimSpace(reply), lang)
+	embed.Title = "Explanation"
+	embed.Footer.Text = fmt.Sprintf("Explained in %s", lang) + translationMarker
+	if _, _, err := h.postReactionEmbed(s, r, msg, embed); err != nil {
+		log.Printf("Error sending explanation: %v", err)
+	}
+}
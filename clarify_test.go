@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClarifyPrompt(t *testing.T) {
+	prompt := clarifyPrompt("ngl that's lowkey fire fr", "English")
+	for _, want := range []string{"plain, simple English", "slang", "don't translate it", "\n\nngl that's lowkey fire fr"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("clarifyPrompt() = %q, want it to contain %q", prompt, want)
+		}
+	}
+}
+
+func TestClarifyRouting(t *testing.T) {
+	tests := []struct {
+		name        string
+		clarify     string
+		author      string
+		emoji       string
+		wantExplain bool
+	}{
+		{name: "clarify emoji explains", clarify: "🤔", author: "author", emoji: "🤔", wantExplain: true},
+		{name: "not on translations", clarify: "🤔", author: "bot", emoji: "🤔"},
+		{name: "off by default", author: "author", emoji: "🤔"},
+		{name: "other emoji ignored", clarify: "🤔", author: "author", emoji: "👍"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"ngl that's lowkey fire","author":{"id":"` + tt.author + `"}}`
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{ClarifyEmoji: tt.clarify, NotesLang: "English"}, translator)
+			completer := &fakeCompleter{reply: "They really like it."}
+			h.completer = completer
+
+			h.reactionAdd(s, testReaction("user", tt.emoji))
+
+			if explained := completer.count() > 0; explained != tt.wantExplain {
+				t.Fatalf("explained = %v, want %v", explained, tt.wantExplain)
+			}
+			if translator.count() != 0 {
+				t.Errorf("translated %d times, want none", translator.count())
+			}
+			messages := sentMessages(t, fake, "c1")
+			if !tt.wantExplain {
+				if len(messages) != 0 {
+					t.Errorf("sent %d messages, want none", len(messages))
+				}
+				return
+			}
+			if !strings.Contains(completer.prompts[0], "ngl that's lowkey fire") {
+				t.Errorf("prompt = %q, want it to hold the message", completer.prompts[0])
+			}
+			if len(messages) != 1 || len(messages[0].Embeds) != 1 {
+				t.Fatalf("sent %v, want one explanation embed", messages)
+			}
+			embed := messages[0].Embeds[0]
+			if embed.Title != "Explanation" || embed.Description != "They really like it." {
+				t.Errorf("embed = %q / %q, want the explanation", embed.Title, embed.Description)
+			}
+		})
+	}
+}
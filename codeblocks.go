@@ -0,0 +1,123 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Fenced code blocks with an optional language tag
+var codeFencePattern = regexp.MustCompile("(?s)```([\\w+#-]*)\\n(.*?)```")
+
+// Line comment markers by code block language tag
+var commentMarkers = map[string]string{
+	"go":         "//",
+	"c":          "//",
+	"cpp":        "//",
+	"cs":         "//",
+	"java":       "//",
+	"js":         "//",
+	"javascript": "//",
+	"ts":         "//",
+	"typescript": "//",
+	"kotlin":     "//",
+	"rust":       "//",
+	"swift":      "//",
+	"py":         "#",
+	"python":     "#",
+	"rb":         "#",
+	"ruby":       "#",
+	"sh":         "#",
+	"bash":       "#",
+	"yaml":       "#",
+	"toml":       "#",
+	"sql":        "--",
+	"lua":        "--",
+	"haskell":    "--",
+}
+
+// splitCodeFence breaks a code block into parts so that only its comments
+// are translated. With a known language tag both whole-line and trailing
+// comments are found; without one only lines that are nothing but a
+// comment are, since a marker could mean something else in code.
+func splitCodeFence(m []string) []tokenPart {
+	tag, body := m[1], m[2]
+	markers := []string{"//", "#", "--"}
+	known := false
+	if marker, ok := commentMarkers[strings.ToLower(tag)]; ok {
+		markers = []string{marker}
+		known = true
+	}
+
+	parts := []tokenPart{keep("```" + tag + "\n")}
+	for _, line := range strings.SplitAfter(body, "\n") {
+		code, comment, rest, ok := splitComment(line, markers, known)
+		if !ok {
+			parts = append(parts, keep(line))
+			continue
+		}
+		parts = append(parts, keep(code), translated(comment), keep(rest))
+	}
+	return append(parts, keep("```"))
+}
+
+// splitComment finds a line comment, returning the code up to and including
+// the marker, the comment text and the trailing whitespace
+func splitComment(line string, markers []string, trailing bool) (code, comment, rest string, ok bool) {
+	body := strings.TrimRight(line, " \t\r\n")
+	rest = line[len(body):]
+	for _, marker := range markers {
+		i := commentStart(body, marker, trailing)
+		if i < 0 {
+			continue
+		}
+		start := i + len(marker)
+		for start < len(body) && body[start] == ' ' {
+			start++
+		}
+		if strings.TrimSpace(body[start:]) == "" {
+			continue
+		}
+		return body[:start], body[start:], rest, true
+	}
+	return "", "", "", false
+}
+
+// commentStart returns where marker starts a comment in line, or -1. A
+// trailing comment must follow whitespace and sit outside string literals.
+func commentStart(line, marker string, trailing bool) int {
+	if strings.HasPrefix(strings.TrimLeft(line, " \t"), marker) {
+		return strings.Index(line, marker)
+	}
+	if !trailing {
+		return -1
+	}
+	for i := strings.Index(line, marker); i > 0; {
+		before := line[:i]
+		if (before[i-1] == ' ' || before[i-1] == '\t') && !insideString(before) {
+			return i
+		}
+		next := strings.Index(line[i+1:], marker)
+		if next < 0 {
+			break
+		}
+		i += 1 + next
+	}
+	return -1
+}
+
+// insideString reports whether s ends inside a quoted string, by counting
+// unescaped quotes
+func insideString(s string) bool {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			i++
+		case quote == 0 && (c == '"' || c == '\'' || c == '`'):
+			quote = c
+		case c == quote:
+			quote = 0
+		}
+	}
+	return quote != 0
+}
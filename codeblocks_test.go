@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTranslateCodeComments(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "go",
+			text: "```go\n// suma dos números\nfunc add(a, b int) int {\n\treturn a + b // el total\n}\n```",
+			want: "```go\n// SUMA DOS NÚMEROS\nfunc add(a, b int) int {\n\treturn a + b // EL TOTAL\n}\n```",
+		},
+		{
+			name: "python",
+			text: "```python\n# saluda\nprint(\"hola # no es comentario\")  # imprime\n```",
+			want: "```python\n# SALUDA\nprint(\"hola # no es comentario\")  # IMPRIME\n```",
+		},
+		{
+			name: "sql",
+			text: "```sql\n-- usuarios activos\nSELECT id FROM users WHERE active; -- solo activos\n```",
+			want: "```sql\n-- USUARIOS ACTIVOS\nSELECT id FROM users WHERE active; -- SOLO ACTIVOS\n```",
+		},
+		{
+			name: "untagged keeps trailing markers",
+			text: "```\n# nota\nx = a -- b\n```",
+			want: "```\n# NOTA\nx = a -- b\n```",
+		},
+		{
+			name: "text around the block translated",
+			text: "mira esto:\n```go\nx := 1 // uno\n```",
+			want: "MIRA ESTO:\n```go\nx := 1 // UNO\n```",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := &fakeTranslator{reply: func(req TranslateRequest) (string, error) {
+				return strings.ToUpper(req.Text), nil
+			}}
+			got, err := translateProtected(context.Background(), translator, TranslateRequest{Text: tt.text, TargetLang: "English"}, tokenOptions{codeComments: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("translateProtected() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitComment(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		markers  []string
+		trailing bool
+		code     string
+		comment  string
+		ok       bool
+	}{
+		{name: "whole line", line: "  // hola\n", markers: []string{"//"}, code: "  // ", comment: "hola", ok: true},
+		{name: "trailing", line: "x++ // más\n", markers: []string{"//"}, trailing: true, code: "x++ // ", comment: "más", ok: true},
+		{name: "trailing not allowed", line: "x++ // más\n", markers: []string{"//"}},
+		{name: "inside a string", line: `s := "a // b"` + "\n", markers: []string{"//"}, trailing: true},
+		{name: "url needs a space before", line: "u := x//y\n", markers: []string{"//"}, trailing: true},
+		{name: "empty comment", line: "//\n", markers: []string{"//"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, comment, _, ok := splitComment(tt.line, tt.markers, tt.trailing)
+			if ok != tt.ok || code != tt.code || comment != tt.comment {
+				t.Errorf("splitComment(%q) = %q, %q, %v, want %q, %q, %v", tt.line, code, comment, ok, tt.code, tt.comment, tt.ok)
+			}
+		})
+	}
+}
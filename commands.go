@@ -0,0 +1,368 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	manageServerPermission   int64 = discordgo.PermissionManageServer
+	administratorPermission  int64 = discordgo.PermissionAdministrator
+	manageMessagesPermission int64 = discordgo.PermissionManageMessages
+
+	// For moderating member names
+	manageNicknamesPermission int64 = discordgo.PermissionManageNicknames
+
+	minUndoCount = 1.0
+
+	// Set on guild-only commands
+	dmPermission = false
+)
+
+// Slash commands registered with Discord on startup
+var commands = []*discordgo.ApplicationCommand{
+	{
+		Name:                     "compare",
+		Description:              "Compare translations from every configured provider",
+		DefaultMemberPermissions: &manageServerPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "text",
+				Description: "Text to translate",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "language",
+				Description: "Language to translate to",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "explain",
+		Description: "Translate text and explain its grammar and idioms",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "text",
+				Description: "Text to translate",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "language",
+				Description: "Language to translate to",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "notes_language",
+				Description: "Language for the notes (defaults to your Discord language)",
+			},
+		},
+	},
+	{
+		Name:        "langmenu",
+		Description: "Show which number reactions translate to which language",
+	},
+	{
+		Name:                     "maintenance",
+		Description:              "Pause or resume translations (bot owner only)",
+		DefaultMemberPermissions: &administratorPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "state",
+				Description: "Turn maintenance mode on or off",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "on", Value: "on"},
+					{Name: "off", Value: "off"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "message",
+				Description: "Message shown to users while translations are paused",
+			},
+		},
+	},
+	{
+		Name:                     "status",
+		Description:              "Show whether the bot is connected and what it has translated (bot owner only)",
+		DefaultMemberPermissions: &administratorPermission,
+	},
+	{
+		Type: discordgo.MessageApplicationCommand,
+		Name: translateMenuCommand,
+	},
+	{
+		Type: discordgo.MessageApplicationCommand,
+		Name: translateMineMenuCommand,
+	},
+	{
+		Name:                     "undo",
+		Description:              "Remove the bot's most recent translations in this channel",
+		DefaultMemberPermissions: &manageMessagesPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "count",
+				Description: "How many translations to remove (default 1)",
+				MinValue:    &minUndoCount,
+				MaxValue:    undoCapacity,
+			},
+		},
+	},
+	{
+		Name:         "translate-event",
+		Description:  "Translate a scheduled event's name, description and location",
+		DMPermission: &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "event",
+				Description: "ID of the scheduled event",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "language",
+				Description: "Language to translate to",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:                     "openai-key",
+		Description:              "Use this server's own OpenAI key for its translations (server owner only)",
+		DefaultMemberPermissions: &administratorPermission,
+		DMPermission:             &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "key",
+				Description: "OpenAI API key; leave out to go back to the bot's key",
+			},
+		},
+	},
+	{
+		Name:                     "config",
+		Description:              "Back up or restore this server's translation settings",
+		DefaultMemberPermissions: &manageServerPermission,
+		DMPermission:             &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "export",
+				Description: "Download this server's settings as a JSON file",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "import",
+				Description: "Replace this server's settings with an exported JSON file",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionAttachment,
+						Name:        "file",
+						Description: "Config file from /config export",
+						Required:    true,
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:                     "preview-prompt",
+		Description:              "Show the prompt a translation would send, without sending it (bot owner only)",
+		DefaultMemberPermissions: &administratorPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "text",
+				Description: "Text to translate",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "language",
+				Description: "Language to translate to",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:                     "translate-range",
+		Description:              "Translate every message between two messages into a thread",
+		DefaultMemberPermissions: &manageMessagesPermission,
+		DMPermission:             &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "start",
+				Description: "Link to the first message",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "end",
+				Description: "Link to the last message",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "language",
+				Description: "Language to translate to",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:                     "translate-welcome",
+		Description:              "Translate this server's welcome screen for you to copy",
+		DefaultMemberPermissions: &manageServerPermission,
+		DMPermission:             &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "language",
+				Description: "Language to translate to",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:                     "translate-nick",
+		Description:              "Translate a member's nickname or display name",
+		DefaultMemberPermissions: &manageNicknamesPermission,
+		DMPermission:             &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "user",
+				Description: "Member whose name to translate",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "language",
+				Description: "Language to translate to",
+				Required:    true,
+			},
+		},
+	},
+}
+
+func (h *DiscordHandler) interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		h.applicationCommand(s, i)
+	case discordgo.InteractionMessageComponent:
+		h.messageComponent(s, i)
+	case discordgo.InteractionModalSubmit:
+		h.modalSubmit(s, i)
+	}
+}
+
+func (h *DiscordHandler) messageComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	switch {
+	case customID == prevPageID:
+		h.pageButton(s, i, -1)
+	case customID == nextPageID:
+		h.pageButton(s, i, 1)
+	}
+}
+
+func (h *DiscordHandler) modalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.ModalSubmitData().CustomID
+	switch {
+	case strings.HasPrefix(customID, translateModalPrefix):
+		h.translateSubmitted(s, i)
+	}
+}
+
+func (h *DiscordHandler) applicationCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.ApplicationCommandData().Name {
+	case "compare":
+		h.compareCommand(s, i)
+	case "explain":
+		h.explainCommand(s, i)
+	case "langmenu":
+		h.langMenuCommand(s, i)
+	case "maintenance":
+		h.maintenanceCommand(s, i)
+	case "status":
+		h.statusCommand(s, i)
+	case translateMenuCommand:
+		h.translateMenu(s, i)
+	case translateMineMenuCommand:
+		h.translateMineMenu(s, i)
+	case "undo":
+		h.undoCommand(s, i)
+	case "translate-event":
+		h.translateEventCommand(s, i)
+	case "openai-key":
+		h.openAIKeyCommand(s, i)
+	case "config":
+		h.configCommand(s, i)
+	case "preview-prompt":
+		h.previewPromptCommand(s, i)
+	case "translate-range":
+		h.translateRangeCommand(s, i)
+	case "translate-welcome":
+		h.translateWelcomeCommand(s, i)
+	case "translate-nick":
+		h.translateNickCommand(s, i)
+	}
+}
+
+// commandOptions indexes the options of a slash command by name
+func commandOptions(i *discordgo.InteractionCreate) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	opts := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range i.ApplicationCommandData().Options {
+		opts[opt.Name] = opt
+	}
+	return opts
+}
+
+// deferResponse acknowledges an interaction so we have time to call the provider
+func deferResponse(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+}
+
+// deferEphemeral acknowledges an interaction with a reply only the user sees
+func deferEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// editResponseText replaces a deferred response with a plain message
+func editResponseText(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+	if err != nil {
+		log.Printf("Error editing interaction response: %v", err)
+	}
+}
+
+// respondEphemeral replies to an interaction with a message only the user sees
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error responding to interaction: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type compareResult struct {
+	Provider string
+	Output   string
+	Err      error
+	Latency  time.Duration
+}
+
+// compareTranslators sends the text to every translator at once and returns
+// the results in the same order as translators
+func compareTranslators(ctx context.Context, translators []Translator, text, targetLang string) []compareResult {
+	results := make([]compareResult, len(translators))
+
+	var wg sync.WaitGroup
+	for i, t := range translators {
+		wg.Add(1)
+		go func(i int, t Translator) {
+			defer wg.Done()
+			start := time.Now()
+			result, err := t.Translate(ctx, TranslateRequest{Text: text, TargetLang: targetLang})
+			results[i] = compareResult{
+				Provider: t.Name(),
+				Output:   result.Text,
+				Err:      err,
+				Latency:  time.Since(start),
+			}
+		}(i, t)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// compareEmbeds lays out the results, spilling into more embeds if needed
+func compareEmbeds(targetLang string, results []compareResult) []*discordgo.MessageEmbed {
+	var fields []*discordgo.MessageEmbedField
+	failed := 0
+	for _, r := range results {
+		value := r.Output
+		if r.Err != nil {
+			failed++
+			value = fmt.Sprintf("%s %v", failedMarker, r.Err)
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%s (%dms)", r.Provider, r.Latency.Milliseconds()),
+			Value: value,
+		})
+	}
+
+	embeds := packIntoEmbeds(fields)
+	if len(embeds) > 0 {
+		embeds[0].Title = fmt.Sprintf("Provider comparison (%s)", targetLang)
+		embeds[len(embeds)-1].Footer = &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d succeeded, %d failed", len(results)-failed, failed),
+		}
+	}
+	return embeds
+}
+
+// truncate shortens s to at most max runes, marking the cut with an ellipsis
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+func (h *DiscordHandler) compareCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := commandOptions(i)
+	text := opts["text"].StringValue()
+	targetLang := opts["language"].StringValue()
+	if !h.allowContent(interactionUser(i).ID, text, targetLang) {
+		respondEphemeral(s, i, abuseNotice)
+		return
+	}
+	if !h.admitCommand(s, i) {
+		return
+	}
+
+	if err := deferResponse(s, i); err != nil {
+		log.Printf("Error deferring compare response: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	results := compareTranslators(ctx, h.translators, text, targetLang)
+	sendEmbedResponse(s, i, compareEmbeds(targetLang, results))
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// delayedTranslator answers after delay, or fails with err
+type delayedTranslator struct {
+	name  string
+	delay time.Duration
+	err   error
+}
+
+func (d *delayedTranslator) Name() string { return d.name }
+
+func (d *delayedTranslator) Translate(ctx context.Context, req TranslateRequest) (TranslateResult, error) {
+	time.Sleep(d.delay)
+	if d.err != nil {
+		return TranslateResult{}, d.err
+	}
+	return TranslateResult{Text: d.name + ": " + req.Text, Provider: d.name}, nil
+}
+
+func TestCompareTranslators(t *testing.T) {
+	errDown := errors.New("down")
+	translators := []Translator{
+		&delayedTranslator{name: "slow", delay: 120 * time.Millisecond},
+		&delayedTranslator{name: "fast", delay: 10 * time.Millisecond},
+		&delayedTranslator{name: "broken", delay: 60 * time.Millisecond, err: errDown},
+	}
+	started := time.Now()
+	results := compareTranslators(context.Background(), translators, "hello", "French")
+
+	// Asked one after another, the providers would take 190ms
+	if took := time.Since(started); took >= 190*time.Millisecond {
+		t.Errorf("comparison took %s, want the providers asked at once", took)
+	}
+	tests := []struct {
+		provider   string
+		output     string
+		err        error
+		minLatency time.Duration
+	}{
+		{"slow", "slow: hello", nil, 120 * time.Millisecond},
+		{"fast", "fast: hello", nil, 10 * time.Millisecond},
+		{"broken", "", errDown, 60 * time.Millisecond},
+	}
+	if len(results) != len(tests) {
+		t.Fatalf("got %d results, want %d", len(results), len(tests))
+	}
+	for n, tt := range tests {
+		got := results[n]
+		if got.Provider != tt.provider || got.Output != tt.output || !errors.Is(got.Err, tt.err) {
+			t.Errorf("result %d = %q, %q, %v, want %q, %q, %v", n, got.Provider, got.Output, got.Err, tt.provider, tt.output, tt.err)
+		}
+		if got.Latency < tt.minLatency {
+			t.Errorf("%s latency = %s, want at least %s", tt.provider, got.Latency, tt.minLatency)
+		}
+	}
+	if results[1].Latency >= results[0].Latency {
+		t.Errorf("fast latency %s, slow %s, want each provider timed on its own", results[1].Latency, results[0].Latency)
+	}
+}
+
+func TestCompareEmbeds(t *testing.T) {
+	results := []compareResult{
+		{Provider: "openai/a", Output: "bonjour", Latency: 1500 * time.Millisecond},
+		{Provider: "openai/b", Err: errors.New("timeout"), Latency: 20 * time.Millisecond},
+		{Provider: "openai/c", Output: strings.Repeat("x", 2000), Latency: time.Millisecond},
+	}
+	embeds := compareEmbeds("French", results)
+	if len(embeds) != 1 {
+		t.Fatalf("got %d embeds, want 1", len(embeds))
+	}
+	embed := embeds[0]
+	if embed.Title != "Provider comparison (French)" {
+		t.Errorf("title = %q", embed.Title)
+	}
+	if embed.Footer == nil || embed.Footer.Text != "2 succeeded, 1 failed" {
+		t.Errorf("footer = %+v, want 2 succeeded, 1 failed", embed.Footer)
+	}
+	want := []discordgo.MessageEmbedField{
+		{Name: "openai/a (1500ms)", Value: "bonjour"},
+		{Name: "openai/b (20ms)", Value: failedMarker + " timeout"},
+		{Name: "openai/c (1ms)", Value: strings.Repeat("x", maxFieldValueLength-1) + "…"},
+	}
+	for n, field := range embed.Fields {
+		if field.Name != want[n].Name || field.Value != want[n].Value {
+			t.Errorf("field %d = %q: %.40q, want %q: %.40q", n, field.Name, field.Value, want[n].Name, want[n].Value)
+		}
+	}
+}
+
+func TestCompareEmbedsSpills(t *testing.T) {
+	results := make([]compareResult, maxEmbedFields+1)
+	for n := range results {
+		results[n] = compareResult{Provider: "p", Output: "out"}
+	}
+	embeds := compareEmbeds("French", results)
+	if len(embeds) != 2 {
+		t.Fatalf("got %d embeds, want 2", len(embeds))
+	}
+	if embeds[0].Title == "" || embeds[1].Title != "" {
+		t.Errorf("titles = %q, %q, want only the first titled", embeds[0].Title, embeds[1].Title)
+	}
+	if embeds[0].Footer != nil || embeds[1].Footer == nil {
+		t.Errorf("want the footer on the last embed only")
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	translateMenuCommand     = "Translate"
+	translateMineMenuCommand = "Translate to my language"
+
+	// Custom ID prefix of the translate modal; the target message ID follows
+	translateModalPrefix = "translate-modal:"
+
+	// Custom IDs of the modal's fields
+	modalLanguageID = "language"
+	modalContextID  = "context"
+
+	// Longest context a user can give, keeping the prompt small
+	maxRequestContextLength = 500
+)
+
+// translateModal asks for the target language and, optionally, context
+// that helps the model pick the right meaning. Modals only hold text
+// inputs, so the language is typed rather than picked from a select.
+func translateModal(messageID string) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		CustomID: translateModalPrefix + messageID,
+		Title:    "Translate message",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    modalLanguageID,
+						Label:       "Language",
+						Style:       discordgo.TextInputShort,
+						Placeholder: "e.g. Spanish",
+						Required:    true,
+						MaxLength:   50,
+					},
+				},
+			},
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    modalContextID,
+						Label:       "Context (optional)",
+						Style:       discordgo.TextInputParagraph,
+						Placeholder: "e.g. We're talking about a card game",
+						MaxLength:   maxRequestContextLength,
+					},
+				},
+			},
+		},
+	}
+}
+
+// modalValue returns the value of a modal's text input
+func modalValue(data discordgo.ModalSubmitInteractionData, customID string) string {
+	for _, row := range data.Components {
+		actions, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actions.Components {
+			if input, ok := component.(*discordgo.TextInput); ok && input.CustomID == customID {
+				return strings.TrimSpace(input.Value)
+			}
+		}
+	}
+	return ""
+}
+
+// canonicalLanguage matches a typed language to a supported one regardless
+// of case, passing anything else through for the model to interpret
+func canonicalLanguage(typed string) string {
+	for _, lang := range flagLanguages() {
+		if strings.EqualFold(lang, typed) {
+			return lang
+		}
+	}
+	return typed
+}
+
+// translateMenu handles the "Translate" message context menu command by
+// asking the user which language they want
+func (h *DiscordHandler) translateMenu(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if h.inMaintenance(s, i) {
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: translateModal(i.ApplicationCommandData().TargetID),
+	})
+	if err != nil {
+		log.Printf("Error sending translate modal: %v", err)
+	}
+}
+
+// translateSubmitted translates the message picked from the context menu
+// into the language typed in the modal, using any context the user gave
+func (h *DiscordHandler) translateSubmitted(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	messageID := strings.TrimPrefix(data.CustomID, translateModalPrefix)
+	targetLang := canonicalLanguage(modalValue(data, modalLanguageID))
+	requestContext := modalValue(data, modalContextID)
+	if targetLang == "" {
+		return
+	}
+
+	if h.inMaintenance(s, i) {
+		return
+	}
+
+	h.translateInteraction(s, i, messageID, targetLang, requestContext)
+}
+
+// translateMineMenu handles the "Translate to my language" message context
+// menu command, translating into the requester's client language
+func (h *DiscordHandler) translateMineMenu(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if h.inMaintenance(s, i) {
+		return
+	}
+
+	targetLang, ok := localeLanguage(i.Locale)
+	if !ok {
+		respondEphemeral(s, i, fmt.Sprintf("I don't know which language your Discord client's %q setting is. Use %q to pick one instead.", string(i.Locale), translateMenuCommand))
+		return
+	}
+	h.translateInteraction(s, i, i.ApplicationCommandData().TargetID, targetLang, "")
+}
+
+// translateInteraction translates a message for a context menu command and
+// replies with the translation only the requester sees
+func (h *DiscordHandler) translateInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, messageID, targetLang, requestContext string) {
+	if !h.channelAllows(i.ChannelID, targetLang) {
+		respondEphemeral(s, i, restrictedNotice(h.channelLanguages(i.ChannelID)))
+		return
+	}
+
+	if err := deferEphemeral(s, i); err != nil {
+		log.Printf("Error deferring translation: %v", err)
+		return
+	}
+
+	msg, err := fetchMessage(s, i.ChannelID, messageID)
+	if err != nil {
+		log.Printf("Error fetching message: %v", err)
+		editResponseText(s, i, "Sorry, I couldn't read that message.")
+		return
+	}
+
+	text := messageText(msg)
+	if text == "" {
+		editResponseText(s, i, "That message has no text to translate.")
+		return
+	}
+	user := interactionUser(i)
+	if !h.allowContent(user.ID, text, targetLang) {
+		editResponseText(s, i, abuseNotice)
+		return
+	}
+	if noticeType, notice := h.admit(user.ID, i.GuildID, i.Member, msg.ID); noticeType != "" {
+		editResponseText(s, i, notice)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	result, err := h.translateDetailed(ctx, i.GuildID, i.ChannelID, text, targetLang, requestContext)
+	h.emitTranslation(i.GuildID, targetLang, err)
+	if errors.Is(err, errUnreliableLanguage) {
+		editResponseText(s, i, unreliableNotice(targetLang))
+		return
+	}
+	if err != nil {
+		log.Printf("Error translating text: %v", err)
+		editResponseText(s, i, "Sorry, I couldn't translate that message.")
+		return
+	}
+
+	embed := translationEmbed(msg, result.Text, targetLang)
+	if h.config.ShowOriginal {
+		addOriginalField(embed, text)
+	}
+	if h.config.ShowSourceLang {
+		addSourceLanguage(embed, result.SourceLang)
+	}
+	if h.config.ShowFlag {
+		addLanguageFlag(embed, targetLang)
+	}
+	addDisclaimer(embed, h.qualityWarning(targetLang))
+	addDisclaimer(embed, h.guildConfig(i.GuildID).Disclaimer)
+	embed.Description = truncate(embed.Description, descriptionRoom(embed, 0))
+	embeds := []*discordgo.MessageEmbed{embed}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &embeds,
+	})
+	if err != nil {
+		log.Printf("Error sending translation: %v", err)
+	}
+}
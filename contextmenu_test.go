@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// menuInteraction is a message context menu command on message m1 in
+// channel c1, run by u1 with the given client locale
+func menuInteraction(name string, locale discordgo.Locale) *discordgo.InteractionCreate {
+	i := commandInteraction(name, "u1", nil)
+	i.Locale = locale
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		Name:        name,
+		CommandType: discordgo.MessageApplicationCommand,
+		TargetID:    "m1",
+	}
+	return i
+}
+
+// responseEdits decodes every edit of the original interaction response
+func responseEdits(t *testing.T, fake *fakeDiscord) []discordgo.WebhookEdit {
+	t.Helper()
+	var edits []discordgo.WebhookEdit
+	for _, r := range fake.sent("/messages/@original") {
+		var edit discordgo.WebhookEdit
+		if err := json.Unmarshal([]byte(r.Body), &edit); err != nil {
+			t.Fatal(err)
+		}
+		edits = append(edits, edit)
+	}
+	return edits
+}
+
+func TestTranslateMenuOpensModal(t *testing.T) {
+	s, fake := newTestSession(t)
+	h := newTestHandler(t, Config{}, &fakeTranslator{})
+	h.translateMenu(s, menuInteraction(translateMenuCommand, ""))
+
+	callbacks := fake.sent("/callback")
+	if len(callbacks) != 1 {
+		t.Fatalf("got %d responses, want 1", len(callbacks))
+	}
+	var response struct {
+		Type discordgo.InteractionResponseType `json:"type"`
+		Data struct {
+			CustomID string `json:"custom_id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(callbacks[0].Body), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Type != discordgo.InteractionResponseModal {
+		t.Errorf("response type = %d, want a modal", response.Type)
+	}
+	// The modal carries the target message through to its submission
+	if want := translateModalPrefix + "m1"; response.Data.CustomID != want {
+		t.Errorf("modal custom ID = %q, want %q", response.Data.CustomID, want)
+	}
+}
+
+// modalSubmission submits the translate modal for message m1 with the
+// given field values
+func modalSubmission(values map[string]string) *discordgo.InteractionCreate {
+	i := commandInteraction("", "u1", nil)
+	i.Type = discordgo.InteractionModalSubmit
+	data := discordgo.ModalSubmitInteractionData{CustomID: translateModalPrefix + "m1"}
+	for _, id := range []string{modalLanguageID, modalContextID} {
+		if value, ok := values[id]; ok {
+			data.Components = append(data.Components, &discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{&discordgo.TextInput{CustomID: id, Value: value}},
+			})
+		}
+	}
+	i.Data = data
+	return i
+}
+
+func TestTranslateSubmitted(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		wantLang string
+	}{
+		{name: "typed language", language: "Spanish", wantLang: "Spanish"},
+		{name: "any case", language: " spanish ", wantLang: "Spanish"},
+		{name: "language without a flag", language: "Latin", wantLang: "Latin"},
+		{name: "blank", language: "  "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"author"}}`
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{}, translator)
+			h.translateSubmitted(s, modalSubmission(map[string]string{modalLanguageID: tt.language}))
+
+			if tt.wantLang == "" {
+				if translator.count() != 0 {
+					t.Errorf("translated %d times, want none", translator.count())
+				}
+				return
+			}
+			if translator.count() != 1 {
+				t.Fatalf("translated %d times, want 1", translator.count())
+			}
+			if req := translator.calls[0]; req.Text != "hello" || req.TargetLang != tt.wantLang {
+				t.Errorf("translated %q to %q, want the target message to %s", req.Text, req.TargetLang, tt.wantLang)
+			}
+			edits := responseEdits(t, fake)
+			if len(edits) != 1 || edits[0].Embeds == nil || (*edits[0].Embeds)[0].Description != "translated: hello" {
+				t.Errorf("response edits = %+v, want the translation", edits)
+			}
+		})
+	}
+}
+
+func TestModalValue(t *testing.T) {
+	data := discordgo.ModalSubmitInteractionData{Components: []discordgo.MessageComponent{
+		&discordgo.ActionsRow{Components: []discordgo.MessageComponent{&discordgo.Button{CustomID: "button"}}},
+		&discordgo.ActionsRow{Components: []discordgo.MessageComponent{&discordgo.TextInput{CustomID: modalLanguageID, Value: " French "}}},
+		&discordgo.ActionsRow{Components: []discordgo.MessageComponent{&discordgo.TextInput{CustomID: modalContextID, Value: "about cooking"}}},
+	}}
+	tests := []struct {
+		customID string
+		want     string
+	}{
+		{customID: modalLanguageID, want: "French"},
+		{customID: modalContextID, want: "about cooking"},
+		{customID: "button"},
+		{customID: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.customID, func(t *testing.T) {
+			if got := modalValue(data, tt.customID); got != tt.want {
+				t.Errorf("modalValue(%q) = %q, want %q", tt.customID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalLanguage(t *testing.T) {
+	tests := []struct {
+		typed string
+		want  string
+	}{
+		{"French", "French"},
+		{"french", "French"},
+		{"FILIPINO", "Filipino"},
+		{"Klingon", "Klingon"},
+	}
+	for _, tt := range tests {
+		if got := canonicalLanguage(tt.typed); got != tt.want {
+			t.Errorf("canonicalLanguage(%q) = %q, want %q", tt.typed, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateSubmittedContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		context     string
+		wantContext string
+		wantCalls   int
+	}{
+		{name: "without context", wantCalls: 1},
+		{name: "with context", context: " about cooking ", wantContext: "about cooking", wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"author"}}`
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{}, translator)
+			h.cache = newTranslationCache(time.Hour, 10, normalizeBasic, homoglyphsNone)
+
+			values := map[string]string{modalLanguageID: "Spanish", modalContextID: tt.context}
+			h.translateSubmitted(s, modalSubmission(values))
+			h.translateSubmitted(s, modalSubmission(values))
+
+			if translator.count() != tt.wantCalls {
+				t.Fatalf("translated %d times, want %d", translator.count(), tt.wantCalls)
+			}
+			if got := translator.calls[0].Context; got != tt.wantContext {
+				t.Errorf("prompt context = %q, want %q", got, tt.wantContext)
+			}
+		})
+	}
+}
+func TestTranslateMineMenu(t *testing.T) {
+	tests := []struct {
+		name      string
+		locale    discordgo.Locale
+		wantLang  string
+		wantReply string
+	}{
+		{name: "client language", locale: discordgo.Japanese, wantLang: "Japanese"},
+		{name: "unknown locale", locale: discordgo.Locale("xx"), wantReply: `I don't know which language your Discord client's "xx" setting is. Use "Translate" to pick one instead.`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"author"}}`
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{}, translator)
+			h.translateMineMenu(s, menuInteraction(translateMineMenuCommand, tt.locale))
+
+			if tt.wantReply != "" {
+				if got := ephemeralReplies(t, fake); len(got) != 1 || got[0] != tt.wantReply {
+					t.Errorf("replies = %q, want %q", got, tt.wantReply)
+				}
+				if translator.count() != 0 {
+					t.Errorf("translated %d times, want none", translator.count())
+				}
+				return
+			}
+			if translator.count() != 1 {
+				t.Fatalf("translated %d times, want 1", translator.count())
+			}
+			if req := translator.calls[0]; req.Text != "hello" || req.TargetLang != tt.wantLang {
+				t.Errorf("translated %q to %q, want the target message to %s", req.Text, req.TargetLang, tt.wantLang)
+			}
+			edits := responseEdits(t, fake)
+			if len(edits) != 1 || edits[0].Embeds == nil || (*edits[0].Embeds)[0].Description != "translated: hello" {
+				t.Errorf("response edits = %+v, want the translation", edits)
+			}
+		})
+	}
+}
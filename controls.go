@@ -0,0 +1,11 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// controlReaction acts on one of the bot's own messages
+type controlReaction func(h *DiscordHandler, s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message)
+
+// Reactions that control the bot's translations rather than request one
+var controlReactions = map[string]controlReaction{
+	pinEmoji: (*DiscordHandler).pinReaction,
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exchange is one earlier message in a conversation and its translation
+type Exchange struct {
+	Original    string
+	Translation string
+}
+
+type conversationEntry struct {
+	exchanges []Exchange
+	updated   time.Time
+}
+
+// conversationBuffer remembers the last few translations between a pair of
+// languages in a channel, so they can be given to the model as context and
+// tone and terms stay consistent across a back-and-forth
+type conversationBuffer struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*conversationEntry
+}
+
+func newConversationBuffer(size int, ttl time.Duration) *conversationBuffer {
+	return &conversationBuffer{size: size, ttl: ttl, entries: make(map[string]*conversationEntry)}
+}
+
+// conversationKey identifies a conversation. The pair is unordered so that
+// replies translated the other way belong to the same conversation; without
+// a known source language only the target is used.
+func conversationKey(channelID, sourceLang, targetLang string) string {
+	langs := []string{strings.ToLower(sourceLang), strings.ToLower(targetLang)}
+	sort.Strings(langs)
+	return channelID + "\x00" + langs[0] + "\x00" + langs[1]
+}
+
+// recent returns the remembered exchanges, oldest first
+func (b *conversationBuffer) recent(key string, now time.Time) []Exchange {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, ok := b.entries[key]
+	if !ok {
+		return nil
+	}
+	if now.Sub(entry.updated) > b.ttl {
+		delete(b.entries, key)
+		return nil
+	}
+	return append([]Exchange(nil), entry.exchanges...)
+}
+
+// record adds an exchange, dropping the oldest beyond the buffer size and
+// any conversations that have gone quiet
+func (b *conversationBuffer) record(key string, e Exchange, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for k, entry := range b.entries {
+		if now.Sub(entry.updated) > b.ttl {
+			delete(b.entries, k)
+		}
+	}
+
+	entry, ok := b.entries[key]
+	if !ok {
+		entry = &conversationEntry{}
+		b.entries[key] = entry
+	}
+	entry.exchanges = append(entry.exchanges, e)
+	if len(entry.exchanges) > b.size {
+		entry.exchanges = entry.exchanges[len(entry.exchanges)-b.size:]
+	}
+	entry.updated = now
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConversationBuffer(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ex := func(n string) Exchange { return Exchange{Original: "o" + n, Translation: "t" + n} }
+	type record struct {
+		key string
+		n   string
+		at  time.Duration
+	}
+	tests := []struct {
+		name    string
+		records []record
+		key     string
+		at      time.Duration
+		want    []Exchange
+	}{
+		{name: "empty", key: "a"},
+		{
+			name:    "oldest first",
+			records: []record{{"a", "1", 0}, {"a", "2", time.Second}},
+			key:     "a",
+			at:      2 * time.Second,
+			want:    []Exchange{ex("1"), ex("2")},
+		},
+		{
+			name:    "oldest dropped beyond the size",
+			records: []record{{"a", "1", 0}, {"a", "2", 1}, {"a", "3", 2}, {"a", "4", 3}},
+			key:     "a",
+			at:      time.Second,
+			want:    []Exchange{ex("2"), ex("3"), ex("4")},
+		},
+		{
+			name:    "keys kept apart",
+			records: []record{{"a", "1", 0}, {"b", "2", 0}},
+			key:     "b",
+			want:    []Exchange{ex("2")},
+		},
+		{
+			name:    "expired after the ttl",
+			records: []record{{"a", "1", 0}},
+			key:     "a",
+			at:      time.Minute + time.Second,
+		},
+		{
+			name:    "a new exchange keeps it going",
+			records: []record{{"a", "1", 0}, {"a", "2", 50 * time.Second}},
+			key:     "a",
+			at:      90 * time.Second,
+			want:    []Exchange{ex("1"), ex("2")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newConversationBuffer(3, time.Minute)
+			for _, r := range tt.records {
+				b.record(r.key, ex(r.n), start.Add(r.at))
+			}
+			if got := b.recent(tt.key, start.Add(tt.at)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConversationBufferEvictsQuietConversations(t *testing.T) {
+	b := newConversationBuffer(3, time.Minute)
+	start := time.Now()
+	b.record("a", Exchange{}, start)
+	b.record("b", Exchange{}, start)
+	b.record("c", Exchange{}, start.Add(2*time.Minute))
+	if len(b.entries) != 1 {
+		t.Errorf("buffer holds %d conversations, want only the recent one", len(b.entries))
+	}
+}
+
+func TestConversationKey(t *testing.T) {
+	if conversationKey("c1", "Spanish", "English") != conversationKey("c1", "english", "spanish") {
+		t.Error("replies translated the other way got a different conversation")
+	}
+	if conversationKey("c1", "Spanish", "English") == conversationKey("c2", "Spanish", "English") {
+		t.Error("different channels share a conversation")
+	}
+}
+
+func TestConversationFedToPrompt(t *testing.T) {
+	translator := &fakeTranslator{}
+	h := newTestHandler(t, Config{}, translator)
+	h.conversations = newConversationBuffer(3, time.Minute)
+
+	for _, text := range []string{"hola", "¿qué tal?"} {
+		if _, err := h.translate(context.Background(), "g1", "c1", text, "English"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := h.translate(context.Background(), "g1", "c2", "adiós", "English"); err != nil {
+		t.Fatal(err)
+	}
+
+	second := translator.calls[1]
+	want := []Exchange{{Original: "hola", Translation: "translated: hola"}}
+	if !reflect.DeepEqual(second.History, want) {
+		t.Errorf("second request history = %v, want %v", second.History, want)
+	}
+	if prompt := translationPrompt(second); !strings.Contains(prompt, "Original: hola\nTranslation: translated: hola") {
+		t.Errorf("prompt = %q, want it to include the earlier exchange", prompt)
+	}
+	if other := translator.calls[2]; len(other.History) != 0 {
+		t.Errorf("another channel's request history = %v, want none", other.History)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Looks like an OpenAI key or a Discord bot token
+var secretPattern = regexp.MustCompile(`sk-[A-Za-z0-9_-]{16,}|[A-Za-z0-9_-]{24,}\.[A-Za-z0-9_-]{6}\.[A-Za-z0-9_-]{27,}`)
+
+// Shown in place of anything that looks like a secret
+const redactedMarker = "[redacted]"
+
+// Room left in a field for the code block around it
+const debugFieldLength = maxFieldValueLength - 8
+
+// redactSecrets blanks out the known secrets in text, and anything else
+// shaped like a key or token
+func redactSecrets(text string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redactedMarker)
+		}
+	}
+	return secretPattern.ReplaceAllString(text, redactedMarker)
+}
+
+// debugBlock wraps text in a code block, breaking any fences inside it
+func debugBlock(text string) string {
+	text = strings.ReplaceAll(text, "```", "`\u200b``")
+	return "```\n" + truncate(text, debugFieldLength) + "\n```"
+}
+
+// debugEmbed describes one exchange with a model. The prompt and reply are
+// only shown when content is set; otherwise just their sizes are.
+func debugEmbed(model, guildID, prompt, reply string, content bool, secrets []string) *discordgo.MessageEmbed {
+	if guildID == "" {
+		guildID = "none"
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: "Model exchange",
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%s • guild %s • prompt %d chars • reply %d chars", model, guildID, len(prompt), len(reply)),
+		},
+		Color: 0x808080, // Gray color
+	}
+	if content {
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "Prompt", Value: debugBlock(redactSecrets(prompt, secrets))},
+			{Name: "Response", Value: debugBlock(redactSecrets(reply, secrets))},
+		}
+	}
+	return embed
+}
+
+// debugMirror returns a hook posting model exchanges to channelID, or nil
+// when no channel is set. Posting happens in the background so it doesn't
+// slow translations down.
+func debugMirror(s *discordgo.Session, channelID string, content bool, secrets []string) func(ctx context.Context, model, prompt, reply string) {
+	if channelID == "" {
+		return nil
+	}
+	return func(ctx context.Context, model, prompt, reply string) {
+		// A guild's own key is a secret as well
+		embed := debugEmbed(model, contextGuild(ctx), prompt, reply, content, append(secrets[:len(secrets):len(secrets)], apiKey(ctx, "")))
+		go func() {
+			if _, err := s.ChannelMessageSendEmbed(channelID, embed); err != nil {
+				log.Printf("Error sending to debug channel: %v", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	const botToken = "MTIzNDU2Nzg5MDEyMzQ1Njc4.GaBcDe.abcdefghijklmnopqrstuvwxyz0123"
+	tests := []struct {
+		name    string
+		text    string
+		secrets []string
+		want    string
+	}{
+		{name: "nothing secret", text: "translate hello", want: "translate hello"},
+		{name: "known secret", text: "key is hunter2!", secrets: []string{"hunter2"}, want: "key is [redacted]!"},
+		{name: "empty secret ignored", text: "hello", secrets: []string{""}, want: "hello"},
+		{name: "OpenAI key", text: "use sk-abcdefghijklmnop1234 now", want: "use [redacted] now"},
+		{name: "short sk- word kept", text: "sk-short", want: "sk-short"},
+		{name: "bot token", text: "token " + botToken, want: "token [redacted]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactSecrets(tt.text, tt.secrets); got != tt.want {
+				t.Errorf("redactSecrets(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDebugBlock(t *testing.T) {
+	if got := debugBlock("say ```hi```"); strings.Count(got, "```") != 2 {
+		t.Errorf("debugBlock() = %q, want only its own fences", got)
+	}
+	long := debugBlock(strings.Repeat("x", maxFieldValueLength*2))
+	if l := len([]rune(long)); l > maxFieldValueLength {
+		t.Errorf("debugBlock() is %d characters, over the field limit of %d", l, maxFieldValueLength)
+	}
+}
+
+func TestDebugEmbed(t *testing.T) {
+	tests := []struct {
+		name       string
+		guildID    string
+		content    bool
+		wantFields []string
+		wantFooter string
+	}{
+		{
+			name:       "with content",
+			guildID:    "g1",
+			content:    true,
+			wantFields: []string{"```\nsecret [redacted] prompt\n```", "```\nhola\n```"},
+			wantFooter: "gpt • guild g1 • prompt 24 chars • reply 4 chars",
+		},
+		{
+			name:       "sizes only",
+			guildID:    "g1",
+			wantFooter: "gpt • guild g1 • prompt 24 chars • reply 4 chars",
+		},
+		{
+			name:       "outside a guild",
+			wantFooter: "gpt • guild none • prompt 24 chars • reply 4 chars",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embed := debugEmbed("gpt", tt.guildID, "secret sk-own-key prompt", "hola", tt.content, []string{"sk-own-key"})
+			if embed.Footer.Text != tt.wantFooter {
+				t.Errorf("footer = %q, want %q", embed.Footer.Text, tt.wantFooter)
+			}
+			var fields []string
+			for _, f := range embed.Fields {
+				fields = append(fields, f.Value)
+			}
+			if strings.Join(fields, "|") != strings.Join(tt.wantFields, "|") {
+				t.Errorf("fields = %q, want %q", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestDebugMirror(t *testing.T) {
+	s, fake := newTestSession(t)
+	if hook := debugMirror(s, "", true, nil); hook != nil {
+		t.Error("debugMirror() without a channel returned a hook")
+	}
+
+	hook := debugMirror(s, "debug", true, []string{"sk-shared"})
+	ctx := withAPIKey(context.WithValue(context.Background(), guildIDContextKey{}, "g1"), "guild-own-key")
+	hook(ctx, "gpt", "prompt with sk-shared and guild-own-key", "reply")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(fake.sent("/channels/debug/messages")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	messages := sentMessages(t, fake, "debug")
+	if len(messages) != 1 || len(messages[0].Embeds) != 1 {
+		t.Fatalf("sent %+v, want one debug embed", messages)
+	}
+	prompt := messages[0].Embeds[0].Fields[0].Value
+	if strings.Contains(prompt, "sk-shared") || strings.Contains(prompt, "guild-own-key") {
+		t.Errorf("prompt = %q, want both keys redacted", prompt)
+	}
+	if !strings.Contains(messages[0].Embeds[0].Footer.Text, "guild g1") {
+		t.Errorf("footer = %q, want the guild", messages[0].Embeds[0].Footer.Text)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// What happens to a translation once enough people have asked for it
+const (
+	popularPin       = "pin"
+	popularHighlight = "highlight"
+)
+
+// How long requests for a message count towards its demand
+const demandWindow = 24 * time.Hour
+
+// demandEntry is who asked for one message in one language
+type demandEntry struct {
+	first time.Time
+	users map[string]bool
+	fired bool
+}
+
+// demandTracker counts the different people who asked for each message in
+// each language, so that popular translations can be acted on
+type demandTracker struct {
+	mu        sync.Mutex
+	threshold int
+	entries   map[string]*demandEntry
+}
+
+func newDemandTracker(threshold int) *demandTracker {
+	return &demandTracker{threshold: threshold, entries: make(map[string]*demandEntry)}
+}
+
+// observe records a user asking for a message in a language. It returns how
+// many people have asked, and reports reached only for the request that
+// takes the count to the threshold, so the action fires once.
+func (d *demandTracker) observe(messageID, lang, userID string, now time.Time) (count int, reached bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for key, e := range d.entries {
+		if now.Sub(e.first) >= demandWindow {
+			delete(d.entries, key)
+		}
+	}
+
+	key := messageID + "\x00" + lang
+	e, ok := d.entries[key]
+	if !ok {
+		e = &demandEntry{first: now, users: make(map[string]bool)}
+		d.entries[key] = e
+	}
+	e.users[userID] = true
+	if e.fired || len(e.users) < d.threshold {
+		return len(e.users), false
+	}
+	e.fired = true
+	return len(e.users), true
+}
+
+// popularEmbed copies a translation embed for reposting, noting its demand
+func popularEmbed(embed *discordgo.MessageEmbed, count int) *discordgo.MessageEmbed {
+	popular := *embed
+	popular.Fields = append([]*discordgo.MessageEmbedField(nil), embed.Fields...)
+	if len(popular.Fields) < maxEmbedFields {
+		popular.Fields = append(popular.Fields, &discordgo.MessageEmbedField{
+			Name:  "Popular",
+			Value: fmt.Sprintf("Requested by %d people", count),
+		})
+	}
+	popular.Description = truncate(popular.Description, descriptionRoom(&popular, 0))
+	return &popular
+}
+
+// countDemand records a reaction translation and, when it becomes popular,
+// pins it or reposts it to the guild's POPULAR_CHANNELS channel. sent is the
+// posted translation, nil when it went by DM.
+func (h *DiscordHandler) countDemand(s *discordgo.Session, r *discordgo.MessageReactionAdd, targetLang, channelID string, sent *discordgo.Message, embed *discordgo.MessageEmbed) {
+	if h.demand == nil {
+		return
+	}
+	count, reached := h.demand.observe(r.MessageID, targetLang, r.UserID, time.Now())
+	if !reached {
+		return
+	}
+	log.Printf("Popular translation: message %s to %s requested by %d users", r.MessageID, targetLang, count)
+
+	switch h.config.PopularAction {
+	case popularPin:
+		if sent == nil {
+			return
+		}
+		err := s.ChannelMessagePin(channelID, sent.ID)
+		if isPinLimitError(err) {
+			log.Printf("Not pinning popular translation in channel %s: pin limit reached", channelID)
+			return
+		}
+		if err != nil {
+			log.Printf("Error pinning popular translation: %v", err)
+		}
+	case popularHighlight:
+		target, ok := h.config.PopularChannels[r.GuildID]
+		if !ok {
+			return
+		}
+		if _, err := s.ChannelMessageSendEmbed(target, popularEmbed(embed, count)); err != nil {
+			log.Printf("Error highlighting popular translation: %v", err)
+		}
+	}
+}
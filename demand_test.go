@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDemandTrackerObserve(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	type request struct {
+		message, lang, user string
+		at                  time.Duration
+		wantCount           int
+		wantReached         bool
+	}
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{"reaching the threshold", []request{
+			{"m1", "French", "u1", 0, 1, false},
+			{"m1", "French", "u2", time.Minute, 2, false},
+			{"m1", "French", "u3", 2 * time.Minute, 3, true},
+		}},
+		{"fires once", []request{
+			{"m1", "French", "u1", 0, 1, false},
+			{"m1", "French", "u2", 0, 2, false},
+			{"m1", "French", "u3", 0, 3, true},
+			{"m1", "French", "u4", 0, 4, false},
+		}},
+		{"same user counted once", []request{
+			{"m1", "French", "u1", 0, 1, false},
+			{"m1", "French", "u1", 0, 1, false},
+			{"m1", "French", "u2", 0, 2, false},
+		}},
+		{"languages apart", []request{
+			{"m1", "French", "u1", 0, 1, false},
+			{"m1", "German", "u2", 0, 1, false},
+			{"m1", "French", "u3", 0, 2, false},
+		}},
+		{"messages apart", []request{
+			{"m1", "French", "u1", 0, 1, false},
+			{"m2", "French", "u2", 0, 1, false},
+		}},
+		{"old requests forgotten", []request{
+			{"m1", "French", "u1", 0, 1, false},
+			{"m1", "French", "u2", time.Hour, 2, false},
+			{"m1", "French", "u3", demandWindow, 1, false},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDemandTracker(3)
+			for n, r := range tt.requests {
+				count, reached := d.observe(r.message, r.lang, r.user, start.Add(r.at))
+				if count != r.wantCount || reached != r.wantReached {
+					t.Errorf("request %d: observe() = %d, %v, want %d, %v", n, count, reached, r.wantCount, r.wantReached)
+				}
+			}
+		})
+	}
+}
+
+func TestPopularEmbed(t *testing.T) {
+	embed := &discordgo.MessageEmbed{Description: "bonjour", Fields: []*discordgo.MessageEmbedField{{Name: "Original", Value: "hello"}}}
+	popular := popularEmbed(embed, 5)
+	if len(popular.Fields) != 2 || popular.Fields[1].Value != "Requested by 5 people" {
+		t.Errorf("fields = %+v, want the demand noted", popular.Fields)
+	}
+	if len(embed.Fields) != 1 {
+		t.Errorf("original embed has %d fields, want it untouched", len(embed.Fields))
+	}
+}
+
+func TestCountDemand(t *testing.T) {
+	tests := []struct {
+		name          string
+		action        string
+		sent          *discordgo.Message
+		wantPins      int
+		wantHighlight int
+	}{
+		{name: "pin", action: popularPin, sent: &discordgo.Message{ID: "t1"}, wantPins: 1},
+		{name: "pin sent by DM", action: popularPin},
+		{name: "highlight", action: popularHighlight, sent: &discordgo.Message{ID: "t1"}, wantHighlight: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			h := newTestHandler(t, Config{PopularAction: tt.action, PopularChannels: map[string]string{"g1": "popular"}}, &fakeTranslator{})
+			h.demand = newDemandTracker(2)
+			embed := &discordgo.MessageEmbed{Description: "bonjour"}
+
+			for _, user := range []string{"u1", "u2", "u3", "u4"} {
+				h.countDemand(s, testReaction(user, "🇫🇷"), "French", "c1", tt.sent, embed)
+			}
+
+			if got := len(fake.sent("/channels/c1/pins/t1")); got != tt.wantPins {
+				t.Errorf("pinned %d times, want %d", got, tt.wantPins)
+			}
+			highlights := sentMessages(t, fake, "popular")
+			if len(highlights) != tt.wantHighlight {
+				t.Fatalf("highlighted %d times, want %d", len(highlights), tt.wantHighlight)
+			}
+			if tt.wantHighlight > 0 && !strings.Contains(highlights[0].Embeds[0].Fields[0].Value, "Requested by 2 people") {
+				t.Errorf("highlight = %+v, want the demand noted", highlights[0].Embeds[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// detectPrompt asks which language text is mostly written in
+func detectPrompt(text string) string {
+	return fmt.Sprintf("What language is the following text mostly written in? "+
+		"Reply with only the English name of the language, nothing else: %s", text)
+}
+
+// detectLanguage asks the model for the dominant language of text
+func detectLanguage(ctx context.Context, c Completer, text string) (string, error) {
+	reply, err := c.Complete(ctx, detectPrompt(text))
+	if err != nil {
+		return "", fmt.Errorf("error detecting language: %v", err)
+	}
+	lang := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(reply), "."))
+	if lang == "" {
+		return "", fmt.Errorf("no language detected")
+	}
+	return lang, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How far back each digest looks
+const digestPeriod = 24 * time.Hour
+
+// digestPicks chooses up to size of a guild's translations made since the
+// given time: the longest, then a random sample of the rest. Cache hits and
+// repeats of the same text are left out so nothing shows up twice.
+func digestPicks(records []TranslationRecord, guildID string, since time.Time, size int, rng *rand.Rand) []TranslationRecord {
+	seen := make(map[string]bool)
+	var candidates []TranslationRecord
+	for _, rec := range records {
+		if rec.GuildID != guildID || rec.Cached || rec.Original == "" || rec.At.Before(since) {
+			continue
+		}
+		key := rec.TargetLang + "\x00" + rec.Original
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		candidates = append(candidates, rec)
+	}
+	if len(candidates) == 0 || size <= 0 {
+		return nil
+	}
+
+	longest := 0
+	for n, rec := range candidates {
+		if len(rec.Original) > len(candidates[longest].Original) {
+			longest = n
+		}
+	}
+	picks := []TranslationRecord{candidates[longest]}
+	rest := append(candidates[:longest:longest], candidates[longest+1:]...)
+	rng.Shuffle(len(rest), func(a, b int) { rest[a], rest[b] = rest[b], rest[a] })
+	for _, rec := range rest {
+		if len(picks) == size {
+			break
+		}
+		picks = append(picks, rec)
+	}
+	return picks
+}
+
+// digestEmbed lays out a day's picks, the first one being the longest
+func digestEmbed(picks []TranslationRecord) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: "Translations of the day",
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d translations from the past day", len(picks)),
+		},
+		Color: 0x00BFFF, // Light blue color
+	}
+	for n, rec := range picks {
+		if len(embed.Fields) == maxEmbedFields {
+			break
+		}
+		name := "To " + rec.TargetLang
+		if rec.SourceLang != "" {
+			name = rec.SourceLang + " → " + rec.TargetLang
+		}
+		if n == 0 {
+			name = "Longest, " + name
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  truncate(name, maxFieldNameLength),
+			Value: truncate(fmt.Sprintf("<#%s>\n%s\n→ %s", rec.ChannelID, rec.Original, rec.Translation), maxFieldValueLength),
+		})
+	}
+	return embed
+}
+
+// nextDigest returns the first time after now that the clock in loc reads
+// minute minutes after midnight
+func nextDigest(now time.Time, minute int, loc *time.Location) time.Time {
+	local := now.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), minute/60, minute%60, 0, 0, loc)
+	if !next.After(now) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, minute/60, minute%60, 0, 0, loc)
+	}
+	return next
+}
+
+// runDigest posts each configured guild's digest once a day at minute
+// minutes after midnight in loc
+func (h *DiscordHandler) runDigest(ctx context.Context, s *discordgo.Session, minute int, loc *time.Location, size int) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for {
+		timer := time.NewTimer(time.Until(nextDigest(time.Now(), minute, loc)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		records := h.History()
+		for guildID, channelID := range h.config.DigestChannels {
+			picks := digestPicks(records, guildID, now.Add(-digestPeriod), size, rng)
+			if len(picks) == 0 {
+				continue
+			}
+			if _, err := s.ChannelMessageSendEmbed(channelID, digestEmbed(picks)); err != nil {
+				log.Printf("Error sending translation digest: %v", err)
+			}
+		}
+	}
+}
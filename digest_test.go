@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestDigestPicks(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-digestPeriod)
+	rec := func(guildID, original string, at time.Time) TranslationRecord {
+		return TranslationRecord{At: at, GuildID: guildID, TargetLang: "French", Original: original}
+	}
+	cached := rec("g1", "a cached one that is long", now)
+	cached.Cached = true
+	german := rec("g1", "hi", now)
+	german.TargetLang = "German"
+
+	tests := []struct {
+		name    string
+		records []TranslationRecord
+		size    int
+		want    []string
+	}{
+		{"nothing", nil, 3, nil},
+		{"zero size", []TranslationRecord{rec("g1", "hi", now)}, 0, nil},
+		{
+			"longest first",
+			[]TranslationRecord{rec("g1", "hi", now), rec("g1", "the longest one", now), rec("g1", "medium", now)},
+			1,
+			[]string{"the longest one"},
+		},
+		{
+			"other guilds and old records left out",
+			[]TranslationRecord{rec("g2", "another guild", now), rec("g1", "too old to show", since.Add(-time.Minute)), rec("g1", "hi", now)},
+			3,
+			[]string{"hi"},
+		},
+		{
+			"cached and empty left out",
+			[]TranslationRecord{cached, rec("g1", "", now), rec("g1", "hi", now)},
+			3,
+			[]string{"hi"},
+		},
+		{
+			"repeats left out per language",
+			[]TranslationRecord{rec("g1", "hi", now), rec("g1", "hi", now), german},
+			3,
+			[]string{"hi", "hi"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			picks := digestPicks(tt.records, "g1", since, tt.size, rand.New(rand.NewSource(1)))
+			if len(picks) != len(tt.want) {
+				t.Fatalf("got %d picks, want %d", len(picks), len(tt.want))
+			}
+			for n, pick := range picks {
+				if pick.Original != tt.want[n] {
+					t.Errorf("pick %d = %q, want %q", n, pick.Original, tt.want[n])
+				}
+			}
+		})
+	}
+}
+
+func TestDigestPicksSampleSize(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	var records []TranslationRecord
+	for _, original := range []string{"one", "two", "three", "four", "the longest of them"} {
+		records = append(records, TranslationRecord{At: now, GuildID: "g1", TargetLang: "French", Original: original})
+	}
+	picks := digestPicks(records, "g1", now.Add(-digestPeriod), 3, rand.New(rand.NewSource(1)))
+	if len(picks) != 3 {
+		t.Fatalf("got %d picks, want 3", len(picks))
+	}
+	if picks[0].Original != "the longest of them" {
+		t.Errorf("first pick = %q, want the longest", picks[0].Original)
+	}
+	seen := make(map[string]bool)
+	for _, pick := range picks {
+		if seen[pick.Original] {
+			t.Errorf("%q picked twice", pick.Original)
+		}
+		seen[pick.Original] = true
+	}
+}
+
+func TestNextDigest(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name   string
+		now    time.Time
+		minute int
+		loc    *time.Location
+		want   time.Time
+	}{
+		{"later today", time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC), 9 * 60, time.UTC, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)},
+		{"already passed", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), 9 * 60, time.UTC, time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"exactly now", time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), 9 * 60, time.UTC, time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"end of month", time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC), 30, time.UTC, time.Date(2024, 2, 1, 0, 30, 0, 0, time.UTC)},
+		// 20:00 UTC is already 05:00 the next day in Tokyo
+		{"other timezone", time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC), 9 * 60, tokyo, time.Date(2024, 1, 2, 9, 0, 0, 0, tokyo)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDigest(tt.now, tt.minute, tt.loc); !got.Equal(tt.want) {
+				t.Errorf("nextDigest(%v, %d) = %v, want %v", tt.now, tt.minute, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// adaptiveTranslator translates with a primary model, switching to a cheaper
+// fallback while the primary keeps getting rate limited. After the cooldown
+// the primary is tried again; it is trusted again after enough clean
+// responses, and one more rate limit before then switches straight back.
+type adaptiveTranslator struct {
+	primary, fallback Translator
+
+	// Switch after limitThreshold rate limits within limitWindow
+	limitThreshold int
+	limitWindow    time.Duration
+	cooldown       time.Duration
+	// Clean primary responses needed after the cooldown
+	restoreAfter int
+
+	mu              sync.Mutex
+	limits          []time.Time
+	downgradedUntil time.Time
+	probing         bool
+	clean           int
+}
+
+// Name names the model translations are currently going to
+func (t *adaptiveTranslator) Name() string {
+	if t.downgraded(time.Now()) {
+		return t.fallback.Name()
+	}
+	return t.primary.Name()
+}
+
+func (t *adaptiveTranslator) downgraded(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return now.Before(t.downgradedUntil)
+}
+
+func (t *adaptiveTranslator) Translate(ctx context.Context, req TranslateRequest) (TranslateResult, error) {
+	if t.downgraded(time.Now()) {
+		return t.fallback.Translate(ctx, req)
+	}
+
+	result, err := t.primary.Translate(ctx, req)
+	if t.observe(err, time.Now()) {
+		log.Printf("%s is being rate limited, switching to %s for %s", t.primary.Name(), t.fallback.Name(), t.cooldown)
+	}
+	if isRateLimited(err) {
+		return t.fallback.Translate(ctx, req)
+	}
+	return result, err
+}
+
+// observe records the outcome of a primary call and reports whether it
+// caused a switch to the fallback
+func (t *adaptiveTranslator) observe(err error, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !isRateLimited(err) {
+		if err == nil && t.probing {
+			t.clean++
+			if t.clean >= t.restoreAfter {
+				log.Printf("%s is responding normally again", t.primary.Name())
+				t.probing = false
+			}
+		}
+		return false
+	}
+
+	kept := t.limits[:0]
+	for _, at := range t.limits {
+		if now.Sub(at) < t.limitWindow {
+			kept = append(kept, at)
+		}
+	}
+	t.limits = append(kept, now)
+	if !t.probing && len(t.limits) < t.limitThreshold {
+		return false
+	}
+
+	t.limits = nil
+	t.downgradedUntil = now.Add(t.cooldown)
+	t.probing = true
+	t.clean = 0
+	return true
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveTranslatorObserve(t *testing.T) {
+	limited := &StatusError{StatusCode: http.StatusTooManyRequests}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	type response struct {
+		err            error
+		at             time.Duration
+		wantSwitch     bool
+		wantDowngraded bool
+	}
+	// Three rate limits within a minute switch for five minutes; two clean
+	// responses after that restore the primary
+	tests := []struct {
+		name      string
+		responses []response
+	}{
+		{"burst of 429s downgrades", []response{
+			{limited, 0, false, false},
+			{limited, time.Second, false, false},
+			{limited, 2 * time.Second, true, true},
+			{nil, time.Minute, false, true},
+		}},
+		{"spread out 429s don't", []response{
+			{limited, 0, false, false},
+			{limited, 40 * time.Second, false, false},
+			{limited, 80 * time.Second, false, false},
+		}},
+		{"other errors don't count", []response{
+			{errors.New("down"), 0, false, false},
+			{&StatusError{StatusCode: http.StatusInternalServerError}, time.Second, false, false},
+			{limited, 2 * time.Second, false, false},
+		}},
+		{"clean responses after the cooldown restore", []response{
+			{limited, 0, false, false},
+			{limited, 0, false, false},
+			{limited, 0, true, true},
+			{nil, 5*time.Minute + time.Second, false, false},
+			{nil, 5*time.Minute + 2*time.Second, false, false},
+			// Trusted again, so one rate limit no longer switches
+			{limited, 5*time.Minute + 3*time.Second, false, false},
+		}},
+		{"a 429 while probing switches straight back", []response{
+			{limited, 0, false, false},
+			{limited, 0, false, false},
+			{limited, 0, true, true},
+			{nil, 5*time.Minute + time.Second, false, false},
+			{limited, 5*time.Minute + 2*time.Second, true, true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := &adaptiveTranslator{
+				primary:        &fakeTranslator{},
+				fallback:       &fakeTranslator{},
+				limitThreshold: 3,
+				limitWindow:    time.Minute,
+				cooldown:       5 * time.Minute,
+				restoreAfter:   2,
+			}
+			for n, r := range tt.responses {
+				now := start.Add(r.at)
+				if got := translator.observe(r.err, now); got != r.wantSwitch {
+					t.Errorf("response %d: observe() = %v, want %v", n, got, r.wantSwitch)
+				}
+				if got := translator.downgraded(now); got != r.wantDowngraded {
+					t.Errorf("response %d: downgraded = %v, want %v", n, got, r.wantDowngraded)
+				}
+			}
+		})
+	}
+}
+
+func TestAdaptiveTranslatorDowngrades(t *testing.T) {
+	primary := &fakeTranslator{reply: func(TranslateRequest) (string, error) {
+		return "", &StatusError{StatusCode: http.StatusTooManyRequests}
+	}}
+	fallback := &fakeTranslator{reply: func(TranslateRequest) (string, error) { return "hola", nil }}
+	translator := &adaptiveTranslator{
+		primary:        primary,
+		fallback:       fallback,
+		limitThreshold: 2,
+		limitWindow:    time.Minute,
+		cooldown:       time.Hour,
+		restoreAfter:   1,
+	}
+
+	for n := 0; n < 4; n++ {
+		result, err := translator.Translate(context.Background(), TranslateRequest{Text: "hello", TargetLang: "Spanish"})
+		if err != nil || result.Text != "hola" {
+			t.Fatalf("translation %d = %q, %v, want the fallback's", n, result.Text, err)
+		}
+	}
+	if primary.count() != 2 {
+		t.Errorf("primary got %d calls, want it left alone after 2 rate limits", primary.count())
+	}
+	if fallback.count() != 4 {
+		t.Errorf("fallback got %d calls, want 4", fallback.count())
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord's embed limits
+const (
+	maxEmbedFields      = 25
+	maxEmbedTotalLength = 6000
+	maxFieldNameLength  = 256
+	maxFieldValueLength = 1024
+	maxTitleLength      = 256
+	maxFooterLength     = 2048
+	maxEmbedsPerMessage = 10
+)
+
+// embedLength counts the characters of e that Discord holds to
+// maxEmbedTotalLength
+func embedLength(e *discordgo.MessageEmbed) int {
+	n := len([]rune(e.Title)) + len([]rune(e.Description))
+	if e.Footer != nil {
+		n += len([]rune(e.Footer.Text))
+	}
+	if e.Author != nil {
+		n += len([]rune(e.Author.Name))
+	}
+	for _, f := range e.Fields {
+		n += len([]rune(f.Name)) + len([]rune(f.Value))
+	}
+	return n
+}
+
+// descriptionRoom returns how long e's description may be once the rest of
+// the embed is counted, keeping reserve characters free for parts added
+// when it's sent
+func descriptionRoom(e *discordgo.MessageEmbed, reserve int) int {
+	rest := embedLength(e) - len([]rune(e.Description))
+	return max(1, min(maxDescriptionLength, maxEmbedTotalLength-rest-reserve))
+}
+
+// packIntoEmbeds spreads fields over as many embeds as needed to stay within
+// Discord's per-field, per-embed field count and total length limits. Room
+// is left in each embed for a title.
+func packIntoEmbeds(fields []*discordgo.MessageEmbedField) []*discordgo.MessageEmbed {
+	const budget = maxEmbedTotalLength - maxTitleLength
+
+	var embeds []*discordgo.MessageEmbed
+	var current *discordgo.MessageEmbed
+	length := 0
+	for _, f := range fields {
+		field := &discordgo.MessageEmbedField{
+			Name:   truncate(f.Name, maxFieldNameLength),
+			Value:  truncate(f.Value, maxFieldValueLength),
+			Inline: f.Inline,
+		}
+		size := len([]rune(field.Name)) + len([]rune(field.Value))
+
+		if current == nil || len(current.Fields) == maxEmbedFields || length+size > budget {
+			current = &discordgo.MessageEmbed{Color: 0x00BFFF} // Light blue color
+			embeds = append(embeds, current)
+			length = 0
+		}
+		current.Fields = append(current.Fields, field)
+		length += size
+	}
+	return embeds
+}
+
+// chunkEmbeds groups embeds into batches that fit in one message each
+func chunkEmbeds(embeds []*discordgo.MessageEmbed) [][]*discordgo.MessageEmbed {
+	var chunks [][]*discordgo.MessageEmbed
+	for len(embeds) > maxEmbedsPerMessage {
+		chunks = append(chunks, embeds[:maxEmbedsPerMessage])
+		embeds = embeds[maxEmbedsPerMessage:]
+	}
+	if len(embeds) > 0 {
+		chunks = append(chunks, embeds)
+	}
+	return chunks
+}
+
+// sendEmbedResponse fills a deferred interaction response with embeds,
+// posting follow-up messages for any that don't fit in the first one
+func sendEmbedResponse(s *discordgo.Session, i *discordgo.InteractionCreate, embeds []*discordgo.MessageEmbed) {
+	for n, chunk := range chunkEmbeds(embeds) {
+		var err error
+		if n == 0 {
+			_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Embeds: &chunk})
+		} else {
+			_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{Embeds: chunk})
+		}
+		if err != nil {
+			log.Printf("Error sending embeds: %v", err)
+			return
+		}
+	}
+}
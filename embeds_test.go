@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// testFields returns n fields with values of the given length
+func testFields(n, valueLength int) []*discordgo.MessageEmbedField {
+	fields := make([]*discordgo.MessageEmbedField, n)
+	for i := range fields {
+		fields[i] = &discordgo.MessageEmbedField{Name: fmt.Sprintf("Language %d", i), Value: strings.Repeat("x", valueLength)}
+	}
+	return fields
+}
+
+func TestPackIntoEmbeds(t *testing.T) {
+	tests := []struct {
+		name       string
+		fields     []*discordgo.MessageEmbedField
+		wantEmbeds int
+	}{
+		{"none", nil, 0},
+		{"one", testFields(3, 100), 1},
+		{"field count limit", testFields(maxEmbedFields+1, 10), 2},
+		{"total length limit", testFields(8, maxFieldValueLength), 2},
+		{"many", testFields(40, maxFieldValueLength), 8},
+		{"overlong values are cut", testFields(1, 5000), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embeds := packIntoEmbeds(tt.fields)
+			if len(embeds) != tt.wantEmbeds {
+				t.Fatalf("got %d embeds, want %d", len(embeds), tt.wantEmbeds)
+			}
+			packed := 0
+			for n, embed := range embeds {
+				if len(embed.Fields) > maxEmbedFields {
+					t.Errorf("embed %d has %d fields", n, len(embed.Fields))
+				}
+				// A title may still be added to any of them
+				if length := embedLength(embed); length > maxEmbedTotalLength-maxTitleLength {
+					t.Errorf("embed %d is %d long, leaving no room for a title", n, length)
+				}
+				for _, field := range embed.Fields {
+					if len([]rune(field.Value)) > maxFieldValueLength {
+						t.Errorf("field %q is %d long", field.Name, len([]rune(field.Value)))
+					}
+				}
+				packed += len(embed.Fields)
+			}
+			// Every language is shown, in order
+			if packed != len(tt.fields) {
+				t.Errorf("packed %d fields, want all %d", packed, len(tt.fields))
+			}
+			if len(embeds) > 0 && embeds[len(embeds)-1].Fields[len(embeds[len(embeds)-1].Fields)-1].Name != tt.fields[len(tt.fields)-1].Name {
+				t.Errorf("last packed field isn't the last given")
+			}
+		})
+	}
+}
+
+func TestChunkEmbeds(t *testing.T) {
+	tests := []struct {
+		embeds int
+		want   []int
+	}{
+		{0, nil},
+		{3, []int{3}},
+		{maxEmbedsPerMessage, []int{maxEmbedsPerMessage}},
+		{maxEmbedsPerMessage*2 + 1, []int{maxEmbedsPerMessage, maxEmbedsPerMessage, 1}},
+	}
+	for _, tt := range tests {
+		embeds := make([]*discordgo.MessageEmbed, tt.embeds)
+		var got []int
+		for _, chunk := range chunkEmbeds(embeds) {
+			got = append(got, len(chunk))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("chunkEmbeds(%d embeds) = %v, want %v", tt.embeds, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// eventEmbed shows the translated parts of a scheduled event. translated maps
+// each non-empty original part to its translation.
+func eventEmbed(event *discordgo.GuildScheduledEvent, translated map[string]string, targetLang string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       truncate(translated[event.Name], maxTitleLength),
+		Description: "*No description*",
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Event translated to %s", targetLang),
+		},
+		Color: 0x00BFFF, // Light blue color
+	}
+	if event.Description != "" {
+		embed.Description = truncate(translated[event.Description], maxDescriptionLength)
+	}
+	if location := event.EntityMetadata.Location; location != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Location",
+			Value: truncate(translated[location], maxFieldValueLength),
+		})
+	}
+	return embed
+}
+
+// eventParts lists the non-empty texts of an event that need translating
+func eventParts(event *discordgo.GuildScheduledEvent) []string {
+	var parts []string
+	for _, part := range []string{event.Name, event.Description, event.EntityMetadata.Location} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func (h *DiscordHandler) translateEventCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := commandOptions(i)
+	eventID := opts["event"].StringValue()
+	targetLang := opts["language"].StringValue()
+	if h.blocksLanguage(targetLang) {
+		respondEphemeral(s, i, unreliableNotice(targetLang))
+		return
+	}
+	if !h.admitCommand(s, i) {
+		return
+	}
+
+	if err := deferResponse(s, i); err != nil {
+		log.Printf("Error deferring event response: %v", err)
+		return
+	}
+
+	event, err := s.GuildScheduledEvent(i.GuildID, eventID, false)
+	if err != nil {
+		log.Printf("Error fetching scheduled event: %v", err)
+		editResponseText(s, i, "I couldn't find that event in this server.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	parts := eventParts(event)
+	items := h.translateBatch(ctx, i.GuildID, parts, targetLang)
+	translated := make(map[string]string, len(parts))
+	for n, part := range parts {
+		translated[part] = items[n].display()
+	}
+
+	embed := eventEmbed(event, translated, targetLang)
+	addDisclaimer(embed, h.qualityWarning(targetLang))
+	embeds := []*discordgo.MessageEmbed{embed}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Embeds: &embeds})
+	if err != nil {
+		log.Printf("Error sending event translation: %v", err)
+	}
+}
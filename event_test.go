@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestEventEmbed(t *testing.T) {
+	translated := map[string]string{
+		"Noche de juegos":    "Game night",
+		"Traigan bocadillos": "Bring snacks",
+		"Sala principal":     "Main hall",
+	}
+	tests := []struct {
+		name       string
+		event      discordgo.GuildScheduledEvent
+		wantParts  []string
+		wantDesc   string
+		wantFields int
+	}{
+		{
+			name: "every part",
+			event: discordgo.GuildScheduledEvent{
+				Name:           "Noche de juegos",
+				Description:    "Traigan bocadillos",
+				EntityMetadata: discordgo.GuildScheduledEventEntityMetadata{Location: "Sala principal"},
+			},
+			wantParts:  []string{"Noche de juegos", "Traigan bocadillos", "Sala principal"},
+			wantDesc:   "Bring snacks",
+			wantFields: 1,
+		},
+		{
+			name:      "empty description",
+			event:     discordgo.GuildScheduledEvent{Name: "Noche de juegos"},
+			wantParts: []string{"Noche de juegos"},
+			wantDesc:  "*No description*",
+		},
+		{
+			name: "no location",
+			event: discordgo.GuildScheduledEvent{
+				Name:        "Noche de juegos",
+				Description: "Traigan bocadillos",
+			},
+			wantParts: []string{"Noche de juegos", "Traigan bocadillos"},
+			wantDesc:  "Bring snacks",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eventParts(&tt.event); !slices.Equal(got, tt.wantParts) {
+				t.Errorf("eventParts() = %q, want %q", got, tt.wantParts)
+			}
+			embed := eventEmbed(&tt.event, translated, "English")
+			if embed.Title != "Game night" {
+				t.Errorf("title = %q, want the translated name", embed.Title)
+			}
+			if embed.Description != tt.wantDesc {
+				t.Errorf("description = %q, want %q", embed.Description, tt.wantDesc)
+			}
+			if len(embed.Fields) != tt.wantFields {
+				t.Fatalf("got %d fields, want %d", len(embed.Fields), tt.wantFields)
+			}
+			if tt.wantFields > 0 && embed.Fields[0].Value != "Main hall" {
+				t.Errorf("location = %q, want the translated location", embed.Fields[0].Value)
+			}
+			if !strings.Contains(embed.Footer.Text, "English") {
+				t.Errorf("footer = %q, want it to name the language", embed.Footer.Text)
+			}
+		})
+	}
+}
+
+func TestTranslateEventCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantTitle string
+		wantText  string
+	}{
+		{name: "found", wantTitle: "en:Noche de juegos"},
+		{name: "missing", status: http.StatusNotFound, wantText: "couldn't find that event"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/scheduled-events/e1"] = `{"id":"e1","guild_id":"g1","name":"Noche de juegos","description":"Traigan bocadillos"}`
+			if tt.status != 0 {
+				fake.statuses = map[string]int{"/scheduled-events/e1": tt.status}
+			}
+			translator := &fakeTranslator{reply: func(req TranslateRequest) (string, error) {
+				return strings.ReplaceAll(req.Text, "]] ", "]] en:"), nil
+			}}
+			h := newTestHandler(t, Config{}, translator)
+			h.translateEventCommand(s, commandInteraction("translate-event", "user", map[string]string{"event": "e1", "language": "English"}))
+
+			edits := responseEdits(t, fake)
+			if len(edits) != 1 {
+				t.Fatalf("got %d response edits, want 1", len(edits))
+			}
+			if tt.wantText != "" {
+				if edits[0].Content == nil || !strings.Contains(*edits[0].Content, tt.wantText) {
+					t.Errorf("response = %v, want it to contain %q", edits[0].Content, tt.wantText)
+				}
+				if translator.count() != 0 {
+					t.Errorf("translated %d times for a missing event", translator.count())
+				}
+				return
+			}
+			if edits[0].Embeds == nil || len(*edits[0].Embeds) != 1 {
+				t.Fatalf("response embeds = %v, want 1", edits[0].Embeds)
+			}
+			embed := (*edits[0].Embeds)[0]
+			if embed.Title != tt.wantTitle || embed.Description != "en:Traigan bocadillos" {
+				t.Errorf("embed = %q / %q, want the translated name and description", embed.Title, embed.Description)
+			}
+			if translator.count() != 1 {
+				t.Errorf("got %d translation calls, want the parts in one", translator.count())
+			}
+		})
+	}
+}
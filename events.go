@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// Events waiting to be posted; more are dropped rather than blocking
+	eventQueueSize = 100
+	eventRetries   = 3
+)
+
+// TranslationEvent describes one translation for external dashboards
+type TranslationEvent struct {
+	GuildID   string    `json:"guild_id"`
+	Language  string    `json:"language"`
+	Outcome   string    `json:"outcome"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// EventSink receives translation events. Send must never block.
+type EventSink interface {
+	Send(e TranslationEvent)
+}
+
+// noopSink discards events when no webhook is configured
+type noopSink struct{}
+
+func (noopSink) Send(TranslationEvent) {}
+
+// httpSink posts events as JSON to a webhook from a background goroutine
+type httpSink struct {
+	url    string
+	client *http.Client
+	queue  chan TranslationEvent
+}
+
+func newHTTPSink(url string) *httpSink {
+	return &httpSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan TranslationEvent, eventQueueSize),
+	}
+}
+
+func (s *httpSink) Send(e TranslationEvent) {
+	select {
+	case s.queue <- e:
+	default:
+		log.Printf("Event queue full, dropping event")
+	}
+}
+
+// run posts queued events until ctx is done
+func (s *httpSink) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-s.queue:
+			if err := s.post(ctx, e); err != nil {
+				log.Printf("Error sending event: %v", err)
+			}
+		}
+	}
+}
+
+// post sends one event, retrying with a growing delay
+func (s *httpSink) post(ctx context.Context, e TranslationEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error marshaling event: %v", err)
+	}
+
+	delay := time.Second
+	for attempt := 1; ; attempt++ {
+		err = s.postOnce(ctx, body)
+		if err == nil || attempt == eventRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (s *httpSink) postOnce(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// emitTranslation reports the outcome of a translation to the event sink
+func (h *DiscordHandler) emitTranslation(guildID, targetLang string, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	h.events.Send(TranslationEvent{
+		GuildID:   guildID,
+		Language:  targetLang,
+		Outcome:   outcome,
+		Timestamp: time.Now().UTC(),
+	})
+}
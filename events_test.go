@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPSinkPayload(t *testing.T) {
+	got := make(chan map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Error(err)
+		}
+		got <- payload
+	}))
+	defer server.Close()
+
+	sink := newHTTPSink(server.URL)
+	at := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	if err := sink.post(context.Background(), TranslationEvent{GuildID: "g1", Language: "French", Outcome: "success", Timestamp: at}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"guild_id": "g1", "language": "French", "outcome": "success", "timestamp": "2024-01-31T12:00:00Z"}
+	payload := <-got
+	if len(payload) != len(want) {
+		t.Errorf("payload = %v, want %v", payload, want)
+	}
+	for key, value := range want {
+		if payload[key] != value {
+			t.Errorf("payload[%q] = %v, want %v", key, payload[key], value)
+		}
+	}
+}
+
+func TestHTTPSinkSendNeverBlocks(t *testing.T) {
+	// Nothing drains the queue, as when the webhook is down
+	sink := newHTTPSink("http://127.0.0.1:0")
+	done := make(chan struct{})
+	go func() {
+		for range eventQueueSize + 10 {
+			sink.Send(TranslationEvent{GuildID: "g1"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Send blocked on a full queue")
+	}
+	if len(sink.queue) != eventQueueSize {
+		t.Errorf("queue holds %d events, want %d", len(sink.queue), eventQueueSize)
+	}
+}
+
+func TestHTTPSinkGivesUpOnShutdown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sink := newHTTPSink(server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := sink.post(ctx, TranslationEvent{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("post() error = %v, want it to stop waiting to retry", err)
+	}
+}
+
+func TestEventSinkErrorsDontAffectTranslation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sink := newHTTPSink(server.URL)
+	go sink.run(ctx)
+
+	s, fake := newTestSession(t)
+	h := newTestHandler(t, Config{}, &fakeTranslator{})
+	h.events = sink
+	h.deliverTranslation(s, testReaction("u1", "🇫🇷"), testMessage("hello"), "hello", nil, "French")
+
+	if posted := sentMessages(t, fake, "c1"); len(posted) != 1 {
+		t.Errorf("posted %d translations, want 1 whatever the sink does", len(posted))
+	}
+}
+
+func TestEmitTranslation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"success", nil, "success"},
+		{"failure", errors.New("down"), "failure"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := newHTTPSink("")
+			h := newTestHandler(t, Config{}, &fakeTranslator{})
+			h.events = sink
+			h.emitTranslation("g1", "French", tt.err)
+			e := <-sink.queue
+			if e.GuildID != "g1" || e.Language != "French" || e.Outcome != tt.want || e.Timestamp.IsZero() {
+				t.Errorf("event = %+v, want a %s in g1 to French", e, tt.want)
+			}
+		})
+	}
+}
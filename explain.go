@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// explanation is the structured reply expected from explain mode
+type explanation struct {
+	Translation string `json:"translation"`
+	Notes       string `json:"notes"`
+}
+
+// explainPrompt asks for a translation into targetLang plus grammar and
+// idiom notes written in notesLang
+func explainPrompt(text, targetLang, notesLang string) string {
+	return fmt.Sprintf("Translate the following text to %s, then explain its grammar, vocabulary and any idioms. "+
+		"Write the explanation in %s. Respond with a JSON object with two string fields: "+
+		"\"translation\" holding the %s translation and \"notes\" holding the explanation in %s.\n\nText: %s",
+		targetLang, notesLang, targetLang, notesLang, text)
+}
+
+// parseExplanation decodes an explain-mode JSON reply
+func parseExplanation(reply string) (explanation, error) {
+	var e explanation
+	if err := json.Unmarshal([]byte(reply), &e); err != nil {
+		return explanation{}, fmt.Errorf("error decoding explanation: %v", err)
+	}
+	e.Translation = strings.TrimSpace(e.Translation)
+	e.Notes = strings.TrimSpace(e.Notes)
+	if e.Translation == "" {
+		return explanation{}, fmt.Errorf("explanation is missing the translation")
+	}
+	if e.Notes == "" {
+		return explanation{}, fmt.Errorf("explanation is missing the notes")
+	}
+	return e, nil
+}
+
+// notesLanguage picks the language for explain-mode notes: the explicit
+// choice, then the requester's client locale, then NOTES_LANG
+func (h *DiscordHandler) notesLanguage(requested string, locale discordgo.Locale) string {
+	if requested != "" {
+		return requested
+	}
+	if lang, ok := localeLanguage(locale); ok {
+		return lang
+	}
+	return h.config.NotesLang
+}
+
+func (h *DiscordHandler) explainCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := commandOptions(i)
+	text := opts["text"].StringValue()
+	targetLang := opts["language"].StringValue()
+	var requestedNotesLang string
+	if opt, ok := opts["notes_language"]; ok {
+		requestedNotesLang = opt.StringValue()
+	}
+	notesLang := h.notesLanguage(requestedNotesLang, i.Locale)
+	if !h.allowContent(interactionUser(i).ID, text, targetLang) {
+		respondEphemeral(s, i, abuseNotice)
+		return
+	}
+	if !h.admitCommand(s, i) {
+		return
+	}
+
+	if err := deferResponse(s, i); err != nil {
+		log.Printf("Error deferring explain response: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	reply, err := h.completer.CompleteJSON(h.guildContext(ctx, i.GuildID), explainPrompt(text, targetLang, notesLang))
+	if err != nil {
+		log.Printf("Error explaining text: %v", err)
+		editResponseText(s, i, "Sorry, I couldn't explain that text.")
+		return
+	}
+
+	result, err := parseExplanation(reply)
+	if err != nil {
+		log.Printf("Error parsing explanation: %v", err)
+		editResponseText(s, i, "Sorry, I couldn't explain that text.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Description: result.Translation,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  "Notes",
+				Value: truncate(result.Notes, maxFieldValueLength),
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Translated to %s, notes in %s", targetLang, notesLang),
+		},
+		Color: 0x00BFFF, // Light blue color
+	}
+	embeds := []*discordgo.MessageEmbed{embed}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Embeds: &embeds})
+	if err != nil {
+		log.Printf("Error sending explanation: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestExplainPrompt(t *testing.T) {
+	prompt := explainPrompt("猫が好き", "English", "Japanese")
+	for _, want := range []string{
+		"Translate the following text to English",
+		"Write the explanation in Japanese.",
+		"\"translation\" holding the English translation",
+		"\"notes\" holding the explanation in Japanese",
+		"Text: 猫が好き",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("explainPrompt() = %q, missing %q", prompt, want)
+		}
+	}
+}
+
+func TestParseExplanation(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    explanation
+		wantErr string
+	}{
+		{"both languages", `{"translation":"I like cats","notes":"「好き」は形容動詞です。"}`, explanation{"I like cats", "「好き」は形容動詞です。"}, ""},
+		{"trims", `{"translation":" I like cats\n","notes":" Notes "}`, explanation{"I like cats", "Notes"}, ""},
+		{"extra fields", `{"translation":"hi","notes":"n","confidence":1}`, explanation{"hi", "n"}, ""},
+		{"missing translation", `{"notes":"n"}`, explanation{}, "missing the translation"},
+		{"blank notes", `{"translation":"hi","notes":"  "}`, explanation{}, "missing the notes"},
+		{"wrong type", `{"translation":1,"notes":"n"}`, explanation{}, "error decoding"},
+		{"not json", `Sure! Here you go`, explanation{}, "error decoding"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExplanation(tt.reply)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseExplanation() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("parseExplanation() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotesLanguage(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		locale    discordgo.Locale
+		want      string
+	}{
+		{"requested wins", "German", discordgo.Japanese, "German"},
+		{"client locale", "", discordgo.Japanese, "Japanese"},
+		{"unknown locale", "", discordgo.Locale("xx"), "English"},
+		{"no locale", "", "", "English"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, Config{NotesLang: "English"}, &fakeTranslator{})
+			if got := h.notesLanguage(tt.requested, tt.locale); got != tt.want {
+				t.Errorf("notesLanguage(%q, %q) = %q, want %q", tt.requested, tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplainCommand(t *testing.T) {
+	s, fake := newTestSession(t)
+	h := newTestHandler(t, Config{NotesLang: "English"}, &fakeTranslator{})
+	completer := &fakeCompleter{reply: `{"translation":"Me gustan los gatos","notes":"Gustar works backwards."}`}
+	h.completer = completer
+	i := commandInteraction("explain", "u1", map[string]string{"text": "I like cats", "language": "Spanish"})
+
+	h.explainCommand(s, i)
+
+	if completer.count() != 1 || !strings.Contains(completer.prompts[0], "Write the explanation in English.") {
+		t.Fatalf("prompts = %q, want one with English notes", completer.prompts)
+	}
+	edits := fake.sent("/messages/@original")
+	if len(edits) != 1 {
+		t.Fatalf("got %d response edits, want 1", len(edits))
+	}
+	var edit discordgo.WebhookEdit
+	if err := json.Unmarshal([]byte(edits[0].Body), &edit); err != nil {
+		t.Fatal(err)
+	}
+	embed := (*edit.Embeds)[0]
+	if embed.Description != "Me gustan los gatos" || embed.Fields[0].Value != "Gustar works backwards." {
+		t.Errorf("embed = %q / %q", embed.Description, embed.Fields[0].Value)
+	}
+	if want := "Translated to Spanish, notes in English"; embed.Footer.Text != want {
+		t.Errorf("footer = %q, want %q", embed.Footer.Text, want)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// Features switches optional subsystems on and off. Disabled features are
+// never wired up, so they register no commands, handlers or goroutines.
+type Features struct {
+	Compare     bool `envconfig:"FEATURE_COMPARE" default:"true"`
+	Explain     bool `envconfig:"FEATURE_EXPLAIN" default:"true"`
+	NumberMenu  bool `envconfig:"FEATURE_NUMBER_MENU" default:"true"`
+	Pagination  bool `envconfig:"FEATURE_PAGINATION" default:"true"`
+	Cache       bool `envconfig:"FEATURE_CACHE" default:"true"`
+	HealthCheck bool `envconfig:"FEATURE_HEALTH_CHECK"`
+
+	// Remember translations by message as well as by content, forgetting
+	// them when the message is edited
+	MessageCache bool `envconfig:"FEATURE_MESSAGE_CACHE"`
+	// Translate with a typed "!tr <language> <text>" command. Needs the
+	// privileged message content intent.
+	TextCommands bool `envconfig:"FEATURE_TEXT_COMMANDS"`
+}
+
+// enabledCommands returns the slash commands whose feature is turned on
+func (f Features) enabledCommands() []*discordgo.ApplicationCommand {
+	enabled := map[string]bool{
+		"compare":  f.Compare,
+		"explain":  f.Explain,
+		"langmenu": f.NumberMenu,
+	}
+
+	var cmds []*discordgo.ApplicationCommand
+	for _, cmd := range commands {
+		if on, gated := enabled[cmd.Name]; gated && !on {
+			continue
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
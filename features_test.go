@@ -0,0 +1,71 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+func TestEnabledCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		features Features
+		without  []string
+	}{
+		{"everything on", Features{Compare: true, Explain: true, NumberMenu: true}, nil},
+		{"compare off", Features{Explain: true, NumberMenu: true}, []string{"compare"}},
+		{"everything off", Features{}, []string{"compare", "explain", "langmenu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, cmd := range tt.features.enabledCommands() {
+				got = append(got, cmd.Name)
+			}
+			for _, cmd := range commands {
+				want := !slices.Contains(tt.without, cmd.Name)
+				if slices.Contains(got, cmd.Name) != want {
+					t.Errorf("command %q registered = %v, want %v", cmd.Name, !want, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFeatureFlagsFromEnvironment(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Features
+	}{
+		{
+			name: "defaults",
+			want: Features{Compare: true, Explain: true, NumberMenu: true, Pagination: true, Cache: true},
+		},
+		{
+			name: "switched",
+			env: map[string]string{
+				"FEATURE_COMPARE":       "false",
+				"FEATURE_CACHE":         "false",
+				"FEATURE_HEALTH_CHECK":  "true",
+				"FEATURE_TEXT_COMMANDS": "true",
+			},
+			want: Features{Explain: true, NumberMenu: true, Pagination: true, HealthCheck: true, TextCommands: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			var got Features
+			if err := envconfig.Process("", &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("features = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// parseLanguagePair reads a language pair written as First/Second
+func parseLanguagePair(s string) (first, second string, ok bool) {
+	first, second, ok = strings.Cut(s, "/")
+	first, second = strings.TrimSpace(first), strings.TrimSpace(second)
+	if !ok || first == "" || second == "" || strings.EqualFold(first, second) {
+		return "", "", false
+	}
+	return first, second, true
+}
+
+// flipTarget picks the other language of the pair than the one detected.
+// Messages in neither language are translated to the first, the server's
+// main language.
+func flipTarget(detected, first, second string) string {
+	if strings.EqualFold(detected, first) {
+		return second
+	}
+	return first
+}
+
+// flipReaction translates a message into whichever language of the guild's
+// pair it isn't written in
+func (h *DiscordHandler) flipReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message) {
+	first, second, ok := parseLanguagePair(h.guildConfig(r.GuildID).LanguagePair)
+	if !ok {
+		h.skip(s, r, "no language pair configured")
+		return
+	}
+	text := messageText(msg)
+	if text == "" {
+		h.skip(s, r, "message has no text")
+		return
+	}
+	// The target isn't known until the language is detected, but a channel
+	// that allows neither language of the pair can be refused before any
+	// quota is used
+	if !h.channelAllows(r.ChannelID, first) && !h.channelAllows(r.ChannelID, second) {
+		h.notify(s, r.UserID, noticeRestricted, restrictedNotice(h.channelLanguages(r.ChannelID)))
+		return
+	}
+	if !h.allowContent(r.UserID, text, "") {
+		h.notify(s, r.UserID, noticeAbuse, abuseNotice)
+		return
+	}
+	if !h.admitTranslation(s, r, msg) {
+		return
+	}
+
+	ctx := h.guildContext(context.Background(), r.GuildID)
+	detected, err := detectLanguage(ctx, h.completer, text)
+	if err != nil {
+		log.Printf("Error flipping translation: %v", err)
+		return
+	}
+	targetLang := flipTarget(detected, first, second)
+	if !h.channelAllows(r.ChannelID, targetLang) {
+		h.notify(s, r.UserID, noticeRestricted, restrictedNotice(h.channelLanguages(r.ChannelID)))
+		return
+	}
+	log.Printf("Flipping %s message %s to %s", detected, msg.ID, targetLang)
+	h.deliverTranslation(s, r, msg, text, nil, targetLang)
+}
@@ -0,0 +1,85 @@
+package main
+
+import "testing"
+
+func TestParseLanguagePair(t *testing.T) {
+	tests := []struct {
+		pair       string
+		wantFirst  string
+		wantSecond string
+		wantOK     bool
+	}{
+		{pair: "English/Spanish", wantFirst: "English", wantSecond: "Spanish", wantOK: true},
+		{pair: " English / Spanish ", wantFirst: "English", wantSecond: "Spanish", wantOK: true},
+		{pair: "English"},
+		{pair: "English/"},
+		{pair: "English/english"},
+		{pair: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pair, func(t *testing.T) {
+			first, second, ok := parseLanguagePair(tt.pair)
+			if first != tt.wantFirst || second != tt.wantSecond || ok != tt.wantOK {
+				t.Errorf("parseLanguagePair(%q) = %q, %q, %v, want %q, %q, %v", tt.pair, first, second, ok, tt.wantFirst, tt.wantSecond, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFlipTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		detected string
+		want     string
+	}{
+		{name: "first to second", detected: "English", want: "Spanish"},
+		{name: "second to first", detected: "Spanish", want: "English"},
+		{name: "any case", detected: "spanish", want: "English"},
+		{name: "third language to the first", detected: "French", want: "English"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flipTarget(tt.detected, "English", "Spanish"); got != tt.want {
+				t.Errorf("flipTarget(%q) = %q, want %q", tt.detected, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlipReaction(t *testing.T) {
+	tests := []struct {
+		name     string
+		pairs    map[string]string
+		detected string
+		want     string
+	}{
+		{name: "Spanish message", pairs: map[string]string{"g1": "English/Spanish"}, detected: "Spanish", want: "English"},
+		{name: "English message", pairs: map[string]string{"g1": "English/Spanish"}, detected: "English", want: "Spanish"},
+		{name: "French message", pairs: map[string]string{"g1": "English/Spanish"}, detected: "French", want: "English"},
+		{name: "no pair for the guild", pairs: map[string]string{"g2": "English/Spanish"}, detected: "Spanish"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hola","author":{"id":"author"}}`
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{FlipEmoji: "🔄", LanguagePairs: tt.pairs}, translator)
+			h.completer = &fakeCompleter{reply: tt.detected}
+
+			h.reactionAdd(s, testReaction("user", "🔄"))
+
+			if tt.want == "" {
+				if translator.count() != 0 {
+					t.Errorf("translated %d times, want none", translator.count())
+				}
+				return
+			}
+			if translator.count() != 1 {
+				t.Fatalf("translated %d times, want 1", translator.count())
+			}
+			if got := translator.calls[0].TargetLang; got != tt.want {
+				t.Errorf("translated to %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
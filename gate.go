@@ -0,0 +1,38 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// canTrigger reports whether a user may request translations. Holding the
+// required role lets a user skip the account and membership age checks.
+func (h *DiscordHandler) canTrigger(userID string, member *discordgo.Member, now time.Time) bool {
+	c := h.config
+	ageGated := c.MinAccountAge > 0 || c.MinMemberAge > 0
+	if c.RequiredRoleID == "" && !ageGated {
+		return true
+	}
+
+	if c.RequiredRoleID != "" && member != nil && slices.Contains(member.Roles, c.RequiredRoleID) {
+		return true
+	}
+	if !ageGated {
+		return false
+	}
+
+	if c.MinAccountAge > 0 {
+		created, err := discordgo.SnowflakeTimestamp(userID)
+		if err != nil || now.Sub(created) < c.MinAccountAge {
+			return false
+		}
+	}
+	if c.MinMemberAge > 0 {
+		if member == nil || member.JoinedAt.IsZero() || now.Sub(member.JoinedAt) < c.MinMemberAge {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// snowflakeAt returns a Discord ID created at t
+func snowflakeAt(t time.Time) string {
+	return strconv.FormatInt((t.UnixMilli()-1420070400000)<<22, 10)
+}
+
+func TestCanTrigger(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	oldAccount := snowflakeAt(now.Add(-365 * 24 * time.Hour))
+	newAccount := snowflakeAt(now.Add(-time.Hour))
+	withRole := &discordgo.Member{Roles: []string{"other", "r1"}, JoinedAt: now.Add(-time.Hour)}
+	withoutRole := &discordgo.Member{Roles: []string{"other"}, JoinedAt: now.Add(-time.Hour)}
+	longMember := &discordgo.Member{JoinedAt: now.Add(-30 * 24 * time.Hour)}
+	tests := []struct {
+		name   string
+		config Config
+		userID string
+		member *discordgo.Member
+		want   bool
+	}{
+		{"no requirement", Config{}, newAccount, nil, true},
+		{"role present", Config{RequiredRoleID: "r1"}, newAccount, withRole, true},
+		{"role absent", Config{RequiredRoleID: "r1"}, oldAccount, withoutRole, false},
+		{"role absent in DMs", Config{RequiredRoleID: "r1"}, oldAccount, nil, false},
+		{"old enough account", Config{MinAccountAge: 7 * 24 * time.Hour}, oldAccount, withoutRole, true},
+		{"account too young", Config{MinAccountAge: 7 * 24 * time.Hour}, newAccount, withoutRole, false},
+		{"role skips the age check", Config{RequiredRoleID: "r1", MinAccountAge: 7 * 24 * time.Hour}, newAccount, withRole, true},
+		{"role or old enough account", Config{RequiredRoleID: "r1", MinAccountAge: 7 * 24 * time.Hour}, oldAccount, withoutRole, true},
+		{"long enough member", Config{MinMemberAge: 7 * 24 * time.Hour}, newAccount, longMember, true},
+		{"member too new", Config{MinMemberAge: 7 * 24 * time.Hour}, oldAccount, withoutRole, false},
+		{"member age unknown", Config{MinMemberAge: 7 * 24 * time.Hour}, oldAccount, nil, false},
+		{"invalid user ID", Config{MinAccountAge: time.Hour}, "not-a-snowflake", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &DiscordHandler{config: &tt.config}
+			if got := h.canTrigger(tt.userID, tt.member, now); got != tt.want {
+				t.Errorf("canTrigger() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
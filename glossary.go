@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Most forced terms a guild can set per language
+const maxGlossaryTerms = 200
+
+// glossaryFor returns a guild's forced terms for a target language, source
+// term to the exact term to use instead
+func (c GuildConfig) glossaryFor(targetLang string) map[string]string {
+	for lang, terms := range c.Glossary {
+		if strings.EqualFold(lang, targetLang) {
+			return terms
+		}
+	}
+	return nil
+}
+
+// glossaryKey identifies a set of forced terms, so translations made with
+// different glossaries aren't mixed up in the cache
+func glossaryKey(terms map[string]string) string {
+	sources := make([]string, 0, len(terms))
+	for source := range terms {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	h := sha256.New()
+	for _, source := range sources {
+		h.Write([]byte(source + "\x00" + terms[source] + "\x00"))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// isWordRune reports whether \b treats r as part of a word
+func isWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// glossaryPattern matches any source term, case-insensitively and as whole
+// words where the term starts or ends with a letter or digit. Longer terms
+// are tried first so "game night" wins over "game".
+func glossaryPattern(terms map[string]string) *regexp.Regexp {
+	sources := make([]string, 0, len(terms))
+	for source := range terms {
+		if source != "" {
+			sources = append(sources, source)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool { return len(sources[i]) > len(sources[j]) })
+
+	alternatives := make([]string, len(sources))
+	for i, source := range sources {
+		alt := regexp.QuoteMeta(source)
+		if first, _ := utf8.DecodeRuneInString(source); isWordRune(first) {
+			alt = `\b` + alt
+		}
+		if last, _ := utf8.DecodeLastRuneInString(source); isWordRune(last) {
+			alt += `\b`
+		}
+		alternatives[i] = alt
+	}
+	return regexp.MustCompile(`(?i)(?:` + strings.Join(alternatives, "|") + `)`)
+}
+
+// glossaryRule swaps forced terms for placeholders that are restored as the
+// exact target term, so the model can't paraphrase them
+func glossaryRule(terms map[string]string) tokenRule {
+	lower := make(map[string]string, len(terms))
+	for source, target := range terms {
+		lower[strings.ToLower(source)] = target
+	}
+	return tokenRule{
+		pattern: glossaryPattern(terms),
+		split: func(m []string) []tokenPart {
+			return []tokenPart{keep(lower[strings.ToLower(m[0])])}
+		},
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGlossaryFor(t *testing.T) {
+	c := GuildConfig{Glossary: map[string]map[string]string{"Spanish": {"server": "servidor"}}}
+	if got := c.glossaryFor("spanish"); got["server"] != "servidor" {
+		t.Errorf("glossaryFor(spanish) = %v, want the Spanish terms", got)
+	}
+	if got := c.glossaryFor("French"); got != nil {
+		t.Errorf("glossaryFor(French) = %v, want none", got)
+	}
+}
+
+func TestGlossaryKey(t *testing.T) {
+	a := glossaryKey(map[string]string{"server": "servidor", "raid": "incursión"})
+	b := glossaryKey(map[string]string{"raid": "incursión", "server": "servidor"})
+	c := glossaryKey(map[string]string{"server": "servidor", "raid": "redada"})
+	if a != b {
+		t.Errorf("keys differ for the same terms: %s and %s", a, b)
+	}
+	if a == c {
+		t.Errorf("key %s is the same for different target terms", a)
+	}
+}
+
+func TestForcedTerms(t *testing.T) {
+	terms := map[string]string{
+		"game night": "Noche de Juegos",
+		"game":       "juego",
+		"Salin":      "Salin",
+		"C++":        "C++",
+	}
+	tests := []struct {
+		name  string
+		text  string
+		reply func(masked string) string
+		want  string
+	}{
+		{
+			name:  "kept where the model puts it",
+			text:  "Salin hosts game night",
+			reply: func(string) string { return "{{0}} organiza la {{1}}" },
+			want:  "Salin organiza la Noche de Juegos",
+		},
+		{
+			name:  "any case in the source",
+			text:  "GAME NIGHT at 8",
+			reply: func(string) string { return "{{0}} a las 8" },
+			want:  "Noche de Juegos a las 8",
+		},
+		{
+			name:  "not inside other words",
+			text:  "a gamer and a game",
+			reply: func(string) string { return "un jugador y un {{0}}" },
+			want:  "un jugador y un juego",
+		},
+		{
+			name:  "terms ending in symbols",
+			text:  "learning C++ today",
+			reply: func(string) string { return "aprendiendo {{0}} hoy" },
+			want:  "aprendiendo C++ hoy",
+		},
+		{
+			name:  "dropped by the model",
+			text:  "join game night",
+			reply: func(string) string { return "únete a la noche de juegos" },
+			want:  "únete a la noche de juegos Noche de Juegos",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masked, tokens := protectTokens(tt.text, tokenOptions{terms: terms})
+			if got := restoreTokens(tt.reply(masked), tokens); got != tt.want {
+				t.Errorf("restoreTokens() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForcedTermsInTranslation(t *testing.T) {
+	translator := &fakeTranslator{reply: func(req TranslateRequest) (string, error) {
+		// Whatever the model makes of the rest, the placeholder comes back
+		return strings.ReplaceAll(req.Text, "tonight", "esta noche"), nil
+	}}
+	h := newTestHandler(t, Config{}, translator)
+	if err := h.guildConfigs.set("g1", GuildConfig{
+		Version:  guildConfigVersion,
+		Glossary: map[string]map[string]string{"Spanish": {"game night": "Noche de Juegos"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := h.translate(context.Background(), "g1", "c1", "Game Night tonight", "Spanish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Noche de Juegos esta noche" {
+		t.Errorf("translate() = %q, want the forced term", got)
+	}
+	if sent := translator.calls[0].Text; strings.Contains(strings.ToLower(sent), "game night") {
+		t.Errorf("sent %q, want the term hidden from the model", sent)
+	}
+
+	// Other languages don't use the Spanish terms
+	if _, err := h.translate(context.Background(), "g1", "c1", "Game Night tonight", "French"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := translator.calls[1].Text; sent != "Game Night tonight" {
+		t.Errorf("sent %q to French, want the text untouched", sent)
+	}
+}
@@ -5,10 +5,11 @@ go 1.22.3
 require (
 	github.com/bwmarrin/discordgo v0.28.1
 	github.com/kelseyhightower/envconfig v1.4.0
+	golang.org/x/text v0.14.0
 )
 
 require (
 	github.com/gorilla/websocket v1.4.2 // indirect
 	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b // indirect
-	golang.org/x/sys v0.0.0-20201119102817-f84b799fce68 // indirect
+	golang.org/x/sys v0.5.0 // indirect
 )
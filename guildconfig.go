@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Version of the exported guild config format
+	guildConfigVersion = 1
+
+	// Largest config file /config import accepts
+	maxGuildConfigSize = 64 << 10
+)
+
+// Discord IDs are decimal snowflakes
+var snowflakePattern = regexp.MustCompile(`^\d{17,20}$`)
+
+// GuildConfig is a server's own settings, as exported and imported with
+// /config. Fields left empty fall back to the bot's environment settings.
+type GuildConfig struct {
+	Version int `json:"version"`
+	// Channel ID translations are posted to instead of where the reaction
+	// happened
+	TranslationChannel string `json:"translation_channel,omitempty"`
+	// formal or informal
+	Formality string `json:"formality,omitempty"`
+	// Send reaction translations to the requester by DM only. A pointer so
+	// a guild can turn it off when the environment turns it on.
+	EphemeralOnly *bool `json:"ephemeral_only,omitempty"`
+	// Two languages the flip reaction translates between, e.g.
+	// English/Spanish
+	LanguagePair string `json:"language_pair,omitempty"`
+	// Appended to the footer of every translation
+	Disclaimer string `json:"disclaimer,omitempty"`
+	// Starts the text command, e.g. ! for !tr
+	CommandPrefix string `json:"command_prefix,omitempty"`
+	// Daily window such as 22:00-07:00 when reaction and mirror
+	// translations pause, in an IANA timezone such as Europe/Paris
+	QuietHours    string `json:"quiet_hours,omitempty"`
+	QuietTimezone string `json:"quiet_timezone,omitempty"`
+	// Target language to forced terms, source term to the exact term the
+	// translation must use; see glossaryRule
+	Glossary map[string]map[string]string `json:"glossary,omitempty"`
+}
+
+// validate checks an imported config before it replaces the current one
+func (c GuildConfig) validate() error {
+	if c.Version != guildConfigVersion {
+		return fmt.Errorf("unsupported config version %d, want %d", c.Version, guildConfigVersion)
+	}
+	if c.TranslationChannel != "" && !snowflakePattern.MatchString(c.TranslationChannel) {
+		return fmt.Errorf("translation_channel %q is not a channel ID", c.TranslationChannel)
+	}
+	if c.Formality != "" && parseFormality(c.Formality) == FormalityDefault {
+		return fmt.Errorf("formality %q must be formal or informal", c.Formality)
+	}
+	if _, _, ok := parseLanguagePair(c.LanguagePair); c.LanguagePair != "" && !ok {
+		return fmt.Errorf("language_pair %q must look like English/Spanish", c.LanguagePair)
+	}
+	if len([]rune(c.Disclaimer)) > maxFooterLength/2 {
+		return fmt.Errorf("disclaimer must be at most %d characters", maxFooterLength/2)
+	}
+	if len([]rune(c.CommandPrefix)) > maxCommandPrefixLength || strings.ContainsAny(c.CommandPrefix, " \t\n") {
+		return fmt.Errorf("command_prefix must be at most %d characters with no spaces", maxCommandPrefixLength)
+	}
+	if c.QuietHours != "" {
+		if _, err := parseQuietHours(c.QuietHours, c.QuietTimezone); err != nil {
+			return err
+		}
+	}
+	for lang, terms := range c.Glossary {
+		if len(terms) > maxGlossaryTerms {
+			return fmt.Errorf("glossary for %s has more than %d terms", lang, maxGlossaryTerms)
+		}
+		for source := range terms {
+			if strings.TrimSpace(source) == "" {
+				return fmt.Errorf("glossary for %s has an empty term", lang)
+			}
+		}
+	}
+	return nil
+}
+
+// parseGuildConfig decodes and validates an imported config, rejecting
+// fields it doesn't know so typos aren't silently dropped
+func parseGuildConfig(data []byte) (GuildConfig, error) {
+	var c GuildConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return GuildConfig{}, fmt.Errorf("error decoding config: %v", err)
+	}
+	if err := c.validate(); err != nil {
+		return GuildConfig{}, err
+	}
+	return c, nil
+}
+
+// guildConfigs holds the configs servers imported, saved to a file when one
+// is configured
+type guildConfigs struct {
+	mu      sync.RWMutex
+	configs map[string]GuildConfig
+	path    string
+}
+
+func newGuildConfigs(path string) (*guildConfigs, error) {
+	g := &guildConfigs{configs: make(map[string]GuildConfig), path: path}
+	if path == "" {
+		return g, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return g, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading guild configs: %v", err)
+	}
+	if err := json.Unmarshal(data, &g.configs); err != nil {
+		return nil, fmt.Errorf("error decoding guild configs: %v", err)
+	}
+	return g, nil
+}
+
+func (g *guildConfigs) get(guildID string) (GuildConfig, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	c, ok := g.configs[guildID]
+	return c, ok
+}
+
+func (g *guildConfigs) set(guildID string, c GuildConfig) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.configs[guildID] = c
+	if g.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(g.configs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding guild configs: %v", err)
+	}
+	if err := os.WriteFile(g.path, data, 0o600); err != nil {
+		return fmt.Errorf("error writing guild configs: %v", err)
+	}
+	return nil
+}
+
+// guildConfig returns the settings in effect for a guild: its imported
+// config, with empty fields filled in from the environment
+func (h *DiscordHandler) guildConfig(guildID string) GuildConfig {
+	c, _ := h.guildConfigs.get(guildID)
+	c.Version = guildConfigVersion
+	if c.TranslationChannel == "" {
+		c.TranslationChannel = h.config.TranslationChannels[guildID]
+	}
+	if c.Formality == "" {
+		c.Formality = h.config.Formality[guildID]
+	}
+	if c.EphemeralOnly == nil {
+		ephemeral := slices.Contains(h.config.EphemeralOnlyGuilds, guildID)
+		c.EphemeralOnly = &ephemeral
+	}
+	if c.LanguagePair == "" {
+		c.LanguagePair = h.config.LanguagePairs[guildID]
+	}
+	if c.Disclaimer == "" {
+		c.Disclaimer = h.config.DisclaimerText
+	}
+	if c.CommandPrefix == "" {
+		c.CommandPrefix = h.config.CommandPrefix
+	}
+	if c.QuietHours == "" {
+		c.QuietHours = h.config.QuietHours
+		c.QuietTimezone = h.config.QuietTimezone
+	}
+	return c
+}
+
+// ephemeralOnly reports whether reaction translations go by DM only
+func (c GuildConfig) ephemeralOnly() bool {
+	return c.EphemeralOnly != nil && *c.EphemeralOnly
+}
+
+func (h *DiscordHandler) configCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	sub := i.ApplicationCommandData().Options[0]
+	switch sub.Name {
+	case "export":
+		h.exportConfig(s, i)
+	case "import":
+		h.importConfig(s, i, sub)
+	}
+}
+
+// exportConfig sends the guild's own settings as a JSON file. Environment
+// settings aren't included, so importing the file back doesn't pin them.
+func (h *DiscordHandler) exportConfig(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	c, _ := h.guildConfigs.get(i.GuildID)
+	c.Version = guildConfigVersion
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		log.Printf("Error encoding guild config: %v", err)
+		respondEphemeral(s, i, "Sorry, I couldn't export this server's config.")
+		return
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "This server's translation config:",
+			Files: []*discordgo.File{{
+				Name:        fmt.Sprintf("salin-config-%s.json", i.GuildID),
+				ContentType: "application/json",
+				Reader:      bytes.NewReader(data),
+			}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error sending guild config: %v", err)
+	}
+}
+
+// importConfig replaces the guild's settings with an uploaded JSON file
+func (h *DiscordHandler) importConfig(s *discordgo.Session, i *discordgo.InteractionCreate, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	var attachmentID string
+	for _, opt := range sub.Options {
+		if opt.Name == "file" {
+			attachmentID, _ = opt.Value.(string)
+		}
+	}
+	attachment, ok := i.ApplicationCommandData().Resolved.Attachments[attachmentID]
+	if !ok {
+		respondEphemeral(s, i, "Please attach the config file to import.")
+		return
+	}
+	if attachment.Size > maxGuildConfigSize {
+		respondEphemeral(s, i, "That file is too large to be a config export.")
+		return
+	}
+
+	if err := deferEphemeral(s, i); err != nil {
+		log.Printf("Error deferring config import response: %v", err)
+		return
+	}
+	data, err := downloadAttachment(attachment.URL, maxGuildConfigSize)
+	if err != nil {
+		log.Printf("Error downloading config import: %v", err)
+		editResponseText(s, i, "Sorry, I couldn't download that file.")
+		return
+	}
+	c, err := parseGuildConfig(data)
+	if err != nil {
+		editResponseText(s, i, fmt.Sprintf("That config can't be imported: %v", err))
+		return
+	}
+	if err := h.guildConfigs.set(i.GuildID, c); err != nil {
+		log.Printf("Error saving guild config: %v", err)
+		editResponseText(s, i, "Sorry, I couldn't save the config.")
+		return
+	}
+	log.Printf("Imported config for guild %s", i.GuildID)
+	editResponseText(s, i, "Config imported.")
+}
+
+// downloadAttachment fetches a small uploaded file, reading at most limit
+// bytes
+func downloadAttachment(url string, limit int64) ([]byte, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestGuildConfigRoundTrip(t *testing.T) {
+	ephemeral := false
+	want := GuildConfig{
+		Version:            guildConfigVersion,
+		TranslationChannel: "123456789012345678",
+		Formality:          "formal",
+		EphemeralOnly:      &ephemeral,
+		LanguagePair:       "English/Spanish",
+		Disclaimer:         "Machine translated",
+		CommandPrefix:      "!",
+		QuietHours:         "22:00-07:00",
+		QuietTimezone:      "UTC",
+		Glossary:           map[string]map[string]string{"Spanish": {"Salin": "Salin", "server": "servidor"}},
+	}
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseGuildConfig(data)
+	if err != nil {
+		t.Fatalf("parseGuildConfig(%s) error = %v", data, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseGuildConfigRejects(t *testing.T) {
+	tooMany := make(map[string]string)
+	for n := 0; n <= maxGlossaryTerms; n++ {
+		tooMany[fmt.Sprintf("term%d", n)] = "x"
+	}
+	tooManyJSON, err := json.Marshal(tooMany)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not json", `version: 1`},
+		{"unknown field", `{"version":1,"formalty":"formal"}`},
+		{"missing version", `{"formality":"formal"}`},
+		{"newer version", `{"version":2}`},
+		{"channel name", `{"version":1,"translation_channel":"#general"}`},
+		{"unknown formality", `{"version":1,"formality":"stiff"}`},
+		{"one language", `{"version":1,"language_pair":"English"}`},
+		{"same language twice", `{"version":1,"language_pair":"English/english"}`},
+		{"long disclaimer", `{"version":1,"disclaimer":"` + strings.Repeat("x", maxFooterLength) + `"}`},
+		{"prefix with a space", `{"version":1,"command_prefix":"! "}`},
+		{"bad quiet hours", `{"version":1,"quiet_hours":"late"}`},
+		{"empty glossary term", `{"version":1,"glossary":{"Spanish":{" ":"x"}}}`},
+		{"too many glossary terms", `{"version":1,"glossary":{"Spanish":` + string(tooManyJSON) + `}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := parseGuildConfig([]byte(tt.data)); err == nil {
+				t.Errorf("parseGuildConfig(%s) = %+v, want an error", tt.data, c)
+			}
+		})
+	}
+}
+
+func TestGuildConfigsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "guilds.json")
+	configs, err := newGuildConfigs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := GuildConfig{Version: guildConfigVersion, LanguagePair: "English/Tagalog"}
+	if err := configs.set("g1", want); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := newGuildConfigs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := reloaded.get("g1"); !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("reloaded config = %+v, %v, want %+v", got, ok, want)
+	}
+}
+
+// importInteraction is /config import with an attachment at url
+func importInteraction(url string) *discordgo.InteractionCreate {
+	i := commandInteraction("config", "owner", nil)
+	data := i.Data.(discordgo.ApplicationCommandInteractionData)
+	data.Options = []*discordgo.ApplicationCommandInteractionDataOption{{
+		Name: "import",
+		Type: discordgo.ApplicationCommandOptionSubCommand,
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "file", Type: discordgo.ApplicationCommandOptionAttachment, Value: "a1"},
+		},
+	}}
+	data.Resolved = &discordgo.ApplicationCommandInteractionDataResolved{
+		Attachments: map[string]*discordgo.MessageAttachment{"a1": {ID: "a1", URL: url, Size: 100}},
+	}
+	i.Data = data
+	return i
+}
+
+func TestImportConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		want      string
+		wantSaved bool
+	}{
+		{name: "valid", file: `{"version":1,"language_pair":"English/Spanish"}`, want: "Config imported.", wantSaved: true},
+		{name: "invalid", file: `{"version":1,"language_pair":"English"}`, want: "can't be imported: language_pair"},
+		{name: "unknown field", file: `{"version":1,"languages":"all"}`, want: "can't be imported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.file))
+			}))
+			defer server.Close()
+			s, fake := newTestSession(t)
+			h := newTestHandler(t, Config{}, &fakeTranslator{})
+
+			h.configCommand(s, importInteraction(server.URL))
+
+			edits := responseEdits(t, fake)
+			if len(edits) != 1 || edits[0].Content == nil || !strings.Contains(*edits[0].Content, tt.want) {
+				t.Fatalf("response edits = %v, want one containing %q", edits, tt.want)
+			}
+			c, saved := h.guildConfigs.get("g1")
+			if saved != tt.wantSaved {
+				t.Fatalf("saved = %v, want %v", saved, tt.wantSaved)
+			}
+			if saved && c.LanguagePair != "English/Spanish" {
+				t.Errorf("saved config = %+v, want the imported one", c)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// guildKeys holds the OpenAI keys servers bring for themselves. With a file
+// configured the keys are kept there encrypted with AES-GCM, so the file on
+// its own gives nothing away; otherwise they only live in memory.
+type guildKeys struct {
+	mu   sync.RWMutex
+	keys map[string]string
+	path string
+	aead cipher.AEAD
+}
+
+func newGuildKeys(path, secret string) (*guildKeys, error) {
+	g := &guildKeys{keys: make(map[string]string), path: path}
+	if path == "" {
+		return g, nil
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("GUILD_KEYS_SECRET is required with GUILD_KEYS_FILE")
+	}
+
+	sum := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %v", err)
+	}
+	g.aead, err = cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %v", err)
+	}
+	if err := g.load(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// load reads and decrypts the key file, if there is one yet
+func (g *guildKeys) load() error {
+	data, err := os.ReadFile(g.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading guild keys: %v", err)
+	}
+
+	var sealed map[string]string
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return fmt.Errorf("error decoding guild keys: %v", err)
+	}
+	for guildID, value := range sealed {
+		raw, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || len(raw) < g.aead.NonceSize() {
+			return fmt.Errorf("guild key for %s is corrupt", guildID)
+		}
+		nonce, ciphertext := raw[:g.aead.NonceSize()], raw[g.aead.NonceSize():]
+		key, err := g.aead.Open(nil, nonce, ciphertext, []byte(guildID))
+		if err != nil {
+			return fmt.Errorf("error decrypting guild key for %s, is GUILD_KEYS_SECRET right?", guildID)
+		}
+		g.keys[guildID] = string(key)
+	}
+	return nil
+}
+
+// save writes every key to the file, encrypted. Callers hold the lock.
+func (g *guildKeys) save() error {
+	if g.path == "" {
+		return nil
+	}
+	sealed := make(map[string]string, len(g.keys))
+	for guildID, key := range g.keys {
+		nonce := make([]byte, g.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return fmt.Errorf("error generating nonce: %v", err)
+		}
+		// The guild ID is bound in so keys can't be swapped between guilds
+		raw := g.aead.Seal(nonce, nonce, []byte(key), []byte(guildID))
+		sealed[guildID] = base64.StdEncoding.EncodeToString(raw)
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return fmt.Errorf("error encoding guild keys: %v", err)
+	}
+	if err := os.WriteFile(g.path, data, 0o600); err != nil {
+		return fmt.Errorf("error writing guild keys: %v", err)
+	}
+	return nil
+}
+
+func (g *guildKeys) get(guildID string) (string, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	key, ok := g.keys[guildID]
+	return key, ok
+}
+
+// set stores a guild's key, or removes it when key is empty
+func (g *guildKeys) set(guildID, key string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if key == "" {
+		delete(g.keys, guildID)
+	} else {
+		g.keys[guildID] = key
+	}
+	return g.save()
+}
+
+type (
+	apiKeyContextKey  struct{}
+	guildIDContextKey struct{}
+)
+
+// withAPIKey makes providers use key instead of their own for calls made
+// with the returned context
+func withAPIKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
+// apiKey returns the key set with withAPIKey, or fallback
+func apiKey(ctx context.Context, fallback string) string {
+	if key, ok := ctx.Value(apiKeyContextKey{}).(string); ok && key != "" {
+		return key
+	}
+	return fallback
+}
+
+// contextGuild returns the guild a provider call is made for, if known
+func contextGuild(ctx context.Context) string {
+	guildID, _ := ctx.Value(guildIDContextKey{}).(string)
+	return guildID
+}
+
+// guildContext records which guild provider calls made with ctx are for,
+// and attaches the guild's own OpenAI key if it has one
+func (h *DiscordHandler) guildContext(ctx context.Context, guildID string) context.Context {
+	if guildID == "" {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, guildIDContextKey{}, guildID)
+	if h.keys == nil {
+		return ctx
+	}
+	if key, ok := h.keys.get(guildID); ok {
+		return withAPIKey(ctx, key)
+	}
+	return ctx
+}
+
+// openAIKeyCommand sets or clears the server's own OpenAI key. The key is
+// never echoed back or logged.
+func (h *DiscordHandler) openAIKeyCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	guild, err := s.State.Guild(i.GuildID)
+	if err != nil {
+		guild, err = s.Guild(i.GuildID)
+	}
+	if err != nil {
+		log.Printf("Error getting guild %s: %v", i.GuildID, err)
+		respondEphemeral(s, i, "Sorry, I couldn't check who owns this server.")
+		return
+	}
+	if interactionUser(i).ID != guild.OwnerID {
+		respondEphemeral(s, i, "Only the server owner can set the server's OpenAI key.")
+		return
+	}
+
+	var key string
+	if opt, ok := commandOptions(i)["key"]; ok {
+		key = strings.TrimSpace(opt.StringValue())
+	}
+	if err := h.keys.set(i.GuildID, key); err != nil {
+		log.Printf("Error saving OpenAI key for guild %s: %v", i.GuildID, err)
+		respondEphemeral(s, i, "Sorry, I couldn't save the key.")
+		return
+	}
+
+	if key == "" {
+		log.Printf("OpenAI key cleared for guild %s", i.GuildID)
+		respondEphemeral(s, i, "This server's OpenAI key was removed; translations use the bot's key again.")
+	} else {
+		log.Printf("OpenAI key set for guild %s", i.GuildID)
+		respondEphemeral(s, i, "This server's OpenAI key was saved and will be used for its translations.")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGuildKeySelection(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     map[string]string
+		guildID  string
+		wantAuth string
+	}{
+		{name: "no guild keys", guildID: "g1", wantAuth: "Bearer global"},
+		{name: "guild without a key", keys: map[string]string{"g2": "sk-g2"}, guildID: "g1", wantAuth: "Bearer global"},
+		{name: "guild with a key", keys: map[string]string{"g1": "sk-g1", "g2": "sk-g2"}, guildID: "g1", wantAuth: "Bearer sk-g1"},
+		{name: "outside a guild", keys: map[string]string{"g1": "sk-g1"}, wantAuth: "Bearer global"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAuth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAuth = r.Header.Get("Authorization")
+				w.Write([]byte(`{"choices":[{"message":{"content":"hola"}}]}`))
+			}))
+			defer server.Close()
+
+			translator := NewOpenAITranslator("global", "model")
+			translator.baseURL = server.URL
+			h := newTestHandler(t, Config{}, translator)
+			if tt.keys != nil {
+				keys, err := newGuildKeys("", "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				for guildID, key := range tt.keys {
+					if err := keys.set(guildID, key); err != nil {
+						t.Fatal(err)
+					}
+				}
+				h.keys = keys
+			}
+
+			if _, err := translator.Complete(h.guildContext(context.Background(), tt.guildID), "hello"); err != nil {
+				t.Fatal(err)
+			}
+			if gotAuth != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", gotAuth, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestGuildKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	keys, err := newGuildKeys(path, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.set("g1", "sk-very-secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-very-secret") {
+		t.Errorf("key file holds the key in plain text: %s", data)
+	}
+
+	reloaded, err := newGuildKeys(path, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, ok := reloaded.get("g1"); !ok || key != "sk-very-secret" {
+		t.Errorf("reloaded key = %q, %v, want the saved key", key, ok)
+	}
+	if _, err := newGuildKeys(path, "wrong"); err == nil {
+		t.Error("loaded the keys with the wrong secret")
+	}
+	if _, err := newGuildKeys(path, ""); err == nil {
+		t.Error("loaded a key file without a secret")
+	}
+}
+
+func TestOpenAIKeyCommandHidesKey(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	tests := []struct {
+		name    string
+		user    string
+		key     string
+		wantKey string
+	}{
+		{name: "owner sets a key", user: "owner", key: "sk-very-secret", wantKey: "sk-very-secret"},
+		{name: "others can't", user: "member", key: "sk-very-secret"},
+		{name: "owner clears the key", user: "owner"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			s, fake := newTestSession(t)
+			addPinState(t, s)
+			h := newTestHandler(t, Config{}, &fakeTranslator{})
+			h.keys, _ = newGuildKeys("", "")
+
+			h.openAIKeyCommand(s, commandInteraction("openai-key", tt.user, map[string]string{"key": tt.key}))
+
+			if key, _ := h.keys.get("g1"); key != tt.wantKey {
+				t.Errorf("stored key = %q, want %q", key, tt.wantKey)
+			}
+			if strings.Contains(logs.String(), "sk-very-secret") {
+				t.Errorf("key logged: %q", logs.String())
+			}
+			for _, reply := range ephemeralReplies(t, fake) {
+				if strings.Contains(reply, "sk-very-secret") {
+					t.Errorf("key echoed back: %q", reply)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// healthChecker tracks consecutive failed health checks
+type healthChecker struct {
+	threshold int
+	failures  int
+}
+
+// record notes the outcome of a check and reports whether the failure
+// threshold was reached, resetting the count when it was
+func (c *healthChecker) record(err error) bool {
+	if err == nil {
+		c.failures = 0
+		return false
+	}
+	c.failures++
+	if c.failures < c.threshold {
+		return false
+	}
+	c.failures = 0
+	return true
+}
+
+// runHealthCheck periodically makes a cheap API call and reconnects the
+// session after too many consecutive failures
+func (h *DiscordHandler) runHealthCheck(ctx context.Context, s *discordgo.Session, interval time.Duration, threshold int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	checker := &healthChecker{threshold: threshold}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		h.checkHealth(checker, func() error {
+			_, err := s.User("@me")
+			return err
+		}, func() error {
+			if err := s.Close(); err != nil {
+				log.Printf("Error closing connection: %v", err)
+			}
+			return s.Open()
+		})
+	}
+}
+
+// checkHealth runs one health check, keeping the readiness flag up to date,
+// and reconnects once checker reaches its threshold. It reports whether it
+// tried to reconnect.
+func (h *DiscordHandler) checkHealth(checker *healthChecker, check, reconnect func() error) bool {
+	err := check()
+	if h.ready.Swap(err == nil) != (err == nil) {
+		log.Printf("Readiness changed: ready=%t", err == nil)
+	}
+	if err != nil {
+		log.Printf("Health check failed: %v", err)
+	}
+	if !checker.record(err) {
+		return false
+	}
+
+	log.Printf("Health check failed %d times in a row, reconnecting", checker.threshold)
+	if err := reconnect(); err != nil {
+		log.Printf("Error reopening connection: %v", err)
+		return true
+	}
+	h.ready.Store(true)
+	return true
+}
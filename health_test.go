@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestHealthCheckerRecord(t *testing.T) {
+	errDown := errors.New("down")
+	tests := []struct {
+		name      string
+		threshold int
+		results   []error
+		want      []bool
+	}{
+		{"healthy", 2, []error{nil, nil, nil}, []bool{false, false, false}},
+		{"reaches threshold", 2, []error{errDown, errDown}, []bool{false, true}},
+		{"success resets", 2, []error{errDown, nil, errDown, errDown}, []bool{false, false, false, true}},
+		{"counts again after triggering", 2, []error{errDown, errDown, errDown, errDown}, []bool{false, true, false, true}},
+		{"threshold of one", 1, []error{errDown, nil, errDown}, []bool{true, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := &healthChecker{threshold: tt.threshold}
+			var got []bool
+			for _, err := range tt.results {
+				got = append(got, checker.record(err))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("record() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	errDown := errors.New("down")
+	tests := []struct {
+		name         string
+		checks       []error
+		reconnectErr error
+		reconnects   int
+		wantReady    bool
+	}{
+		{name: "healthy", checks: []error{nil, nil}, wantReady: true},
+		{name: "one failure", checks: []error{errDown}, wantReady: false},
+		{name: "recovers", checks: []error{errDown, nil}, wantReady: true},
+		{name: "reconnects", checks: []error{errDown, errDown, errDown}, reconnects: 1, wantReady: true},
+		{name: "reconnect fails", checks: []error{errDown, errDown, errDown}, reconnectErr: errDown, reconnects: 1, wantReady: false},
+		{name: "interrupted failures", checks: []error{errDown, errDown, nil, errDown, errDown}, wantReady: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, Config{}, &fakeTranslator{})
+			h.ready.Store(true)
+			checker := &healthChecker{threshold: 3}
+			reconnects := 0
+			for _, result := range tt.checks {
+				h.checkHealth(checker, func() error {
+					return result
+				}, func() error {
+					reconnects++
+					return tt.reconnectErr
+				})
+			}
+			if reconnects != tt.reconnects {
+				t.Errorf("reconnected %d times, want %d", reconnects, tt.reconnects)
+			}
+			if got := h.Metrics().Ready; got != tt.wantReady {
+				t.Errorf("Ready = %v, want %v", got, tt.wantReady)
+			}
+		})
+	}
+}
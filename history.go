@@ -0,0 +1,85 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// TranslationRecord describes one translation the bot made. Its text is
+// only kept when something needs it, such as the daily digest.
+type TranslationRecord struct {
+	At        time.Time
+	GuildID   string
+	ChannelID string
+	// SourceLang is the detected language of the original, empty if it
+	// wasn't detected
+	SourceLang string
+	TargetLang string
+	// Cached is set when the translation came from the cache
+	Cached bool
+	// Original and Translation are empty unless the history keeps text
+	Original    string
+	Translation string
+}
+
+// translationHistory keeps the most recent translations in a ring buffer
+type translationHistory struct {
+	mu      sync.Mutex
+	records []TranslationRecord
+	next    int
+	full    bool
+	// Keep the text of each translation as well
+	keepText bool
+}
+
+func newTranslationHistory(size int, keepText bool) *translationHistory {
+	return &translationHistory{records: make([]TranslationRecord, size), keepText: keepText}
+}
+
+// record adds a translation, overwriting the oldest once the buffer is full.
+// It does nothing on a nil history, so callers needn't check.
+func (t *translationHistory) record(guildID, channelID, sourceLang, targetLang, original, translation string, cached bool, now time.Time) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rec := TranslationRecord{
+		At:         now,
+		GuildID:    guildID,
+		ChannelID:  channelID,
+		SourceLang: sourceLang,
+		TargetLang: targetLang,
+		Cached:     cached,
+	}
+	if t.keepText {
+		rec.Original = original
+		rec.Translation = translation
+	}
+	t.records[t.next] = rec
+	t.next = (t.next + 1) % len(t.records)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// recent returns the kept translations, oldest first
+func (t *translationHistory) recent() []TranslationRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.full {
+		return append([]TranslationRecord(nil), t.records[:t.next]...)
+	}
+	return append(append([]TranslationRecord(nil), t.records[t.next:]...), t.records[:t.next]...)
+}
+
+// History returns recent translations, oldest first. It is safe to call
+// from any goroutine.
+func (h *DiscordHandler) History() []TranslationRecord {
+	if h.history == nil {
+		return nil
+	}
+	return h.history.recent()
+}
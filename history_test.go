@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranslationHistory(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		size       int
+		keepText   bool
+		records    int
+		wantTarget []string
+	}{
+		{name: "not yet full", size: 3, records: 2, wantTarget: []string{"lang0", "lang1"}},
+		{name: "exactly full", size: 3, records: 3, wantTarget: []string{"lang0", "lang1", "lang2"}},
+		{name: "oldest overwritten", size: 3, records: 5, wantTarget: []string{"lang2", "lang3", "lang4"}},
+		{name: "keeping text", size: 2, keepText: true, records: 1, wantTarget: []string{"lang0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := newTranslationHistory(tt.size, tt.keepText)
+			for n := 0; n < tt.records; n++ {
+				history.record("g1", "c1", "French", "lang"+string(rune('0'+n)), "bonjour", "hello", false, now)
+			}
+			got := history.recent()
+			if len(got) != len(tt.wantTarget) {
+				t.Fatalf("got %d records, want %d", len(got), len(tt.wantTarget))
+			}
+			for n, rec := range got {
+				if rec.TargetLang != tt.wantTarget[n] || rec.SourceLang != "French" {
+					t.Errorf("record %d = %s to %s, want French to %s", n, rec.SourceLang, rec.TargetLang, tt.wantTarget[n])
+				}
+				if kept := rec.Original != "" || rec.Translation != ""; kept != tt.keepText {
+					t.Errorf("record %d kept text = %v, want %v", n, kept, tt.keepText)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceLanguageRecorded(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		detected   string
+		wantSource string
+		wantFooter string
+	}{
+		{
+			name:       "shown in the footer",
+			config:     Config{ShowSourceLang: true},
+			detected:   "French",
+			wantSource: "French",
+			wantFooter: "Translated from French to English",
+		},
+		{
+			name:       "detected without showing",
+			config:     Config{DetectSource: true},
+			detected:   "French",
+			wantSource: "French",
+			wantFooter: "Translated to English",
+		},
+		{
+			name:       "detection failing",
+			config:     Config{ShowSourceLang: true},
+			wantFooter: "Translated to English",
+		},
+		{
+			name:       "not detected",
+			detected:   "French",
+			wantFooter: "Translated to English",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"bonjour","author":{"id":"author"}}`
+			h := newTestHandler(t, tt.config, &fakeTranslator{})
+			h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+			h.completer = &fakeCompleter{reply: tt.detected}
+			h.history = newTranslationHistory(5, false)
+			h.cache = newTranslationCache(time.Hour, 10, normalizeBasic, homoglyphsNone)
+
+			h.reactionAdd(s, testReaction("user", "🇬🇧"))
+			// The second comes from the cache, which remembers the source
+			h.reactionAdd(s, testReaction("user2", "🇬🇧"))
+
+			messages := sentMessages(t, fake, "c1")
+			if len(messages) != 2 {
+				t.Fatalf("sent %d translations, want 2", len(messages))
+			}
+			for n, msg := range messages {
+				if got := strings.TrimSuffix(msg.Embeds[0].Footer.Text, translationMarker); got != tt.wantFooter {
+					t.Errorf("translation %d footer = %q, want %q", n, got, tt.wantFooter)
+				}
+			}
+			records := h.History()
+			if len(records) != 2 || records[0].Cached || !records[1].Cached {
+				t.Fatalf("history = %+v, want a fresh then a cached translation", records)
+			}
+			for n, rec := range records {
+				if rec.SourceLang != tt.wantSource || rec.TargetLang != "English" {
+					t.Errorf("record %d = %s to %s, want %q to English", n, rec.SourceLang, rec.TargetLang, tt.wantSource)
+				}
+			}
+		})
+	}
+}
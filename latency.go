@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Most latency samples kept, dropping the oldest past it
+const maxLatencySamples = 10000
+
+type latencySample struct {
+	at       time.Time
+	duration time.Duration
+}
+
+// latencyWindow keeps translation latencies over a sliding window
+type latencyWindow struct {
+	mu      sync.Mutex
+	window  time.Duration
+	samples []latencySample
+}
+
+func newLatencyWindow(window time.Duration) *latencyWindow {
+	return &latencyWindow{window: window}
+}
+
+func (w *latencyWindow) record(d time.Duration, now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.samples = append(w.samples, latencySample{at: now, duration: d})
+	if len(w.samples) > maxLatencySamples {
+		w.samples = slices.Delete(w.samples, 0, len(w.samples)-maxLatencySamples)
+	}
+}
+
+// recent drops samples older than the window and returns the rest, sorted
+func (w *latencyWindow) recent(now time.Time) []time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	cut := 0
+	for cut < len(w.samples) && now.Sub(w.samples[cut].at) > w.window {
+		cut++
+	}
+	w.samples = slices.Delete(w.samples, 0, cut)
+
+	durations := make([]time.Duration, len(w.samples))
+	for i, s := range w.samples {
+		durations[i] = s.duration
+	}
+	slices.Sort(durations)
+	return durations
+}
+
+// percentile returns the nearest-rank p-th percentile, 0 < p <= 100, of
+// sorted durations, or 0 if there are none
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// runLatencyLog logs translation latency percentiles every interval
+func (h *DiscordHandler) runLatencyLog(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		sorted := h.latencies.recent(time.Now())
+		if len(sorted) == 0 {
+			continue
+		}
+		log.Printf("translation_latency count=%d p50=%s p95=%s p99=%s window=%s",
+			len(sorted), percentile(sorted, 50), percentile(sorted, 95), percentile(sorted, 99), h.latencies.window)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// component is a background part of the bot that has to be shut down
+type component struct {
+	name  string
+	start func(ctx context.Context) error
+	stop  func(ctx context.Context) error
+}
+
+// Lifecycle starts background components in the order they were added and
+// shuts them down in reverse, giving each its own stop timeout
+type Lifecycle struct {
+	stopTimeout time.Duration
+	components  []component
+	started     int
+	// ctx is canceled when Shutdown begins
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newLifecycle(stopTimeout time.Duration) *Lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Lifecycle{stopTimeout: stopTimeout, ctx: ctx, cancel: cancel}
+}
+
+// Context is canceled as soon as shutting down begins, for work that
+// should give up rather than hold the shutdown up
+func (l *Lifecycle) Context() context.Context {
+	return l.ctx
+}
+
+// Add registers a component. Either function may be nil.
+func (l *Lifecycle) Add(name string, start, stop func(ctx context.Context) error) {
+	l.components = append(l.components, component{name: name, start: start, stop: stop})
+}
+
+// AddLoop registers a goroutine that runs until its context is canceled.
+// Stopping it cancels the context and waits for run to return.
+func (l *Lifecycle) AddLoop(name string, run func(ctx context.Context)) {
+	var cancel context.CancelFunc
+	var wg sync.WaitGroup
+	l.Add(name, func(ctx context.Context) error {
+		ctx, cancel = context.WithCancel(context.Background())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(ctx)
+		}()
+		return nil
+	}, func(ctx context.Context) error {
+		cancel()
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// Start starts every component in order. If one fails, the ones already
+// started are shut down again.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	for _, c := range l.components[l.started:] {
+		if c.start != nil {
+			if err := c.start(ctx); err != nil {
+				err = fmt.Errorf("error starting %s: %v", c.name, err)
+				return errors.Join(err, l.Shutdown(ctx))
+			}
+		}
+		l.started++
+	}
+	return nil
+}
+
+// Shutdown stops the started components, last started first. A component
+// that doesn't stop within the timeout is given up on and the rest are still
+// stopped. All errors are returned together.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.cancel()
+	var errs []error
+	for ; l.started > 0; l.started-- {
+		c := l.components[l.started-1]
+		if c.stop == nil {
+			continue
+		}
+		stopCtx, cancel := context.WithTimeout(ctx, l.stopTimeout)
+		err := runStop(stopCtx, c.stop)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error stopping %s: %v", c.name, err))
+			continue
+		}
+		log.Printf("Stopped %s", c.name)
+	}
+	return errors.Join(errs...)
+}
+
+// runStop calls stop, giving up once ctx is done even if stop ignores it
+func runStop(ctx context.Context, stop func(ctx context.Context) error) error {
+	done := make(chan error, 1)
+	go func() { done <- stop(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
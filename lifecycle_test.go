@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLifecycle(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name      string
+		failStart string
+		failStop  string
+		stuckStop string
+		wantStart string
+		wantStop  string
+		wantCalls []string
+	}{
+		{
+			name:      "stops in reverse",
+			wantCalls: []string{"start a", "start b", "start c", "stop c", "stop b", "stop a"},
+		},
+		{
+			name:      "failed start unwinds",
+			failStart: "c",
+			wantStart: "error starting c: boom",
+			wantCalls: []string{"start a", "start b", "start c", "stop b", "stop a"},
+		},
+		{
+			name:      "failed stop keeps going",
+			failStop:  "b",
+			wantStop:  "error stopping b: boom",
+			wantCalls: []string{"start a", "start b", "start c", "stop c", "stop b", "stop a"},
+		},
+		{
+			name:      "stuck stop times out",
+			stuckStop: "b",
+			wantStop:  "error stopping b: context deadline exceeded",
+			wantCalls: []string{"start a", "start b", "start c", "stop c", "stop b", "stop a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := make(chan string, 10)
+			l := newLifecycle(20 * time.Millisecond)
+			block := make(chan struct{})
+			defer close(block)
+			for _, name := range []string{"a", "b", "c"} {
+				l.Add(name, func(ctx context.Context) error {
+					calls <- "start " + name
+					if name == tt.failStart {
+						return errBoom
+					}
+					return nil
+				}, func(ctx context.Context) error {
+					calls <- "stop " + name
+					if name == tt.stuckStop {
+						// Ignores ctx, as a misbehaving component would
+						<-block
+					}
+					if name == tt.failStop {
+						return errBoom
+					}
+					return nil
+				})
+			}
+
+			err := l.Start(context.Background())
+			checkError(t, "Start", err, tt.wantStart)
+			if err == nil {
+				checkError(t, "Shutdown", l.Shutdown(context.Background()), tt.wantStop)
+			}
+
+			var got []string
+			for len(calls) > 0 {
+				got = append(got, <-calls)
+			}
+			if !reflect.DeepEqual(got, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func checkError(t *testing.T, what string, err error, want string) {
+	t.Helper()
+	if want == "" {
+		if err != nil {
+			t.Errorf("%s() error = %v", what, err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("%s() error = %v, want %q", what, err, want)
+	}
+}
+
+func TestLifecycleAddLoop(t *testing.T) {
+	l := newLifecycle(time.Second)
+	stopped := false
+	l.AddLoop("loop", func(ctx context.Context) {
+		<-ctx.Done()
+		stopped = true
+	})
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !stopped {
+		t.Error("loop was still running after Shutdown")
+	}
+}
+
+func TestLifecycleContextCanceledOnShutdown(t *testing.T) {
+	l := newLifecycle(time.Second)
+	var stopSawCanceled bool
+	l.Add("component", nil, func(ctx context.Context) error {
+		stopSawCanceled = l.Context().Err() != nil
+		return nil
+	})
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if l.Context().Err() != nil {
+		t.Fatal("context canceled before Shutdown")
+	}
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !stopSawCanceled {
+		t.Error("context was still live while components stopped")
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const messageCapNotice = "That message has already been translated as many times as allowed. Please try again later."
+
+type messageCount struct {
+	count       int
+	windowStart time.Time
+}
+
+// messageCap limits how many translations a single message can get within
+// a time window, so one message can't drain the budget
+type messageCap struct {
+	mu       sync.Mutex
+	max      int
+	window   time.Duration
+	messages map[string]*messageCount
+}
+
+func newMessageCap(max int, window time.Duration) *messageCap {
+	return &messageCap{max: max, window: window, messages: make(map[string]*messageCount)}
+}
+
+// allow counts a translation of messageID and reports whether it is within
+// the cap. A max of 0 disables the cap.
+func (c *messageCap) allow(messageID string, now time.Time) bool {
+	if c.max <= 0 {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, mc := range c.messages {
+		if now.Sub(mc.windowStart) >= c.window {
+			delete(c.messages, id)
+		}
+	}
+
+	mc, ok := c.messages[messageID]
+	if !ok {
+		mc = &messageCount{windowStart: now}
+		c.messages[messageID] = mc
+	}
+	if mc.count >= c.max {
+		return false
+	}
+	mc.count++
+	return true
+}
+
+// tokenBucket allows bursts up to its capacity, refilling steadily
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Which tier of the rate limiter turned a request away
+type limitTier string
+
+const (
+	tierNone  limitTier = ""
+	tierUser  limitTier = "user"
+	tierGuild limitTier = "guild"
+)
+
+// rateLimiter applies a per-user and a guild-wide limit to translations.
+// A limit of 0 per minute disables that tier.
+type rateLimiter struct {
+	mu           sync.Mutex
+	userPerMin   int
+	guildPerMin  int
+	userBuckets  map[string]*tokenBucket
+	guildBuckets map[string]*tokenBucket
+}
+
+func newRateLimiter(userPerMin, guildPerMin int) *rateLimiter {
+	return &rateLimiter{
+		userPerMin:   userPerMin,
+		guildPerMin:  guildPerMin,
+		userBuckets:  make(map[string]*tokenBucket),
+		guildBuckets: make(map[string]*tokenBucket),
+	}
+}
+
+// refill tops up the bucket for key and returns it
+func refill(buckets map[string]*tokenBucket, key string, perMin int, now time.Time) *tokenBucket {
+	b, ok := buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(perMin), last: now}
+		buckets[key] = b
+	}
+	elapsed := now.Sub(b.last).Minutes()
+	b.tokens = min(float64(perMin), b.tokens+elapsed*float64(perMin))
+	b.last = now
+	return b
+}
+
+// prune drops buckets that have refilled to capacity. They behave exactly
+// like the fresh bucket refill would make, so forgetting them is safe.
+func prune(buckets map[string]*tokenBucket, perMin int, now time.Time) {
+	for key, b := range buckets {
+		if b.tokens+now.Sub(b.last).Minutes()*float64(perMin) >= float64(perMin) {
+			delete(buckets, key)
+		}
+	}
+}
+
+// allow takes a token from both the user's and the guild's bucket if both
+// have one, and otherwise reports which tier denied the request. Nothing is
+// taken from either bucket when a request is denied.
+func (l *rateLimiter) allow(userID, guildID string, now time.Time) limitTier {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	prune(l.userBuckets, l.userPerMin, now)
+	prune(l.guildBuckets, l.guildPerMin, now)
+
+	var user, guild *tokenBucket
+	if l.userPerMin > 0 {
+		user = refill(l.userBuckets, userID, l.userPerMin, now)
+		if user.tokens < 1 {
+			return tierUser
+		}
+	}
+	if l.guildPerMin > 0 && guildID != "" {
+		guild = refill(l.guildBuckets, guildID, l.guildPerMin, now)
+		if guild.tokens < 1 {
+			return tierGuild
+		}
+	}
+
+	if user != nil {
+		user.tokens--
+	}
+	if guild != nil {
+		guild.tokens--
+	}
+	return tierNone
+}
+
+// rateLimitNotice explains a denial to the user
+func rateLimitNotice(tier limitTier) string {
+	if tier == tierGuild {
+		return "This server is requesting a lot of translations right now. Please try again in a minute."
+	}
+	return "You're requesting translations too quickly. Please try again in a minute."
+}
+
+// semaphore bounds how many calls run at once. A nil semaphore doesn't.
+type semaphore chan struct{}
+
+// errBusy means ctx was done before a semaphore slot came free
+var errBusy = errors.New("no free slot")
+
+func newSemaphore(n int) semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return make(semaphore, n)
+}
+
+// acquire waits for a free slot or for ctx to be done
+func (s semaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", errBusy, ctx.Err())
+	}
+}
+
+func (s semaphore) release() {
+	if s != nil {
+		<-s
+	}
+}
+
+// keyedMutex serializes work per key, such as a message ID, while work for
+// different keys runs in parallel. A nil keyedMutex doesn't serialize.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	mu sync.Mutex
+	// Holders and waiters; the lock is dropped from the map at zero
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedLock)}
+}
+
+// lock waits until no other work holds key and returns the function that
+// releases it
+func (k *keyedMutex) lock(key string) func() {
+	if k == nil {
+		return func() {}
+	}
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		k.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
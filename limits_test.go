@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	type call struct {
+		user, guild string
+		after       time.Duration
+		want        limitTier
+	}
+	tests := []struct {
+		name        string
+		userPerMin  int
+		guildPerMin int
+		calls       []call
+	}{
+		{
+			name:       "user burst then denied",
+			userPerMin: 2,
+			calls: []call{
+				{"u1", "g1", 0, tierNone},
+				{"u1", "g1", 0, tierNone},
+				{"u1", "g1", 0, tierUser},
+				{"u2", "g1", 0, tierNone},
+			},
+		},
+		{
+			name:       "user refills over time",
+			userPerMin: 1,
+			calls: []call{
+				{"u1", "g1", 0, tierNone},
+				{"u1", "g1", 30 * time.Second, tierUser},
+				{"u1", "g1", 30 * time.Second, tierNone},
+			},
+		},
+		{
+			name:        "guild shared by users",
+			userPerMin:  5,
+			guildPerMin: 2,
+			calls: []call{
+				{"u1", "g1", 0, tierNone},
+				{"u2", "g1", 0, tierNone},
+				{"u3", "g1", 0, tierGuild},
+				{"u3", "g2", 0, tierNone},
+			},
+		},
+		{
+			name:        "denial takes no tokens",
+			userPerMin:  1,
+			guildPerMin: 2,
+			calls: []call{
+				{"u1", "g1", 0, tierNone},
+				{"u1", "g1", 0, tierUser},
+				{"u1", "g1", 0, tierUser},
+				{"u2", "g1", 0, tierNone},
+			},
+		},
+		{
+			name:        "no guild limit in DMs",
+			guildPerMin: 1,
+			calls: []call{
+				{"u1", "", 0, tierNone},
+				{"u1", "", 0, tierNone},
+			},
+		},
+		{
+			name: "disabled",
+			calls: []call{
+				{"u1", "g1", 0, tierNone},
+				{"u1", "g1", 0, tierNone},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter(tt.userPerMin, tt.guildPerMin)
+			now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+			for n, c := range tt.calls {
+				now = now.Add(c.after)
+				if got := l.allow(c.user, c.guild, now); got != c.want {
+					t.Errorf("call %d: allow(%q, %q) = %q, want %q", n, c.user, c.guild, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterPrunesFullBuckets(t *testing.T) {
+	l := newRateLimiter(1, 0)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.allow("u1", "g1", now)
+	l.allow("u2", "g1", now.Add(2*time.Minute))
+	if _, ok := l.userBuckets["u1"]; ok {
+		t.Error("refilled bucket for u1 was not pruned")
+	}
+	if _, ok := l.userBuckets["u2"]; !ok {
+		t.Error("bucket for u2 is missing")
+	}
+}
+
+func TestMessageCapAllow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	type request struct {
+		messageID string
+		at        time.Duration
+		want      bool
+	}
+	tests := []struct {
+		name     string
+		max      int
+		requests []request
+	}{
+		{"disabled", 0, []request{{"m1", 0, true}, {"m1", 0, true}, {"m1", 0, true}}},
+		{"up to the cap", 2, []request{{"m1", 0, true}, {"m1", time.Second, true}, {"m1", 2 * time.Second, false}}},
+		{"per message", 1, []request{{"m1", 0, true}, {"m2", 0, true}, {"m1", time.Second, false}}},
+		{"window resets", 1, []request{{"m1", 0, true}, {"m1", 59 * time.Minute, false}, {"m1", time.Hour, true}, {"m1", time.Hour + time.Second, false}}},
+		{"refusals don't restart the window", 1, []request{{"m1", 0, true}, {"m1", 50 * time.Minute, false}, {"m1", time.Hour, true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newMessageCap(tt.max, time.Hour)
+			for n, r := range tt.requests {
+				if got := c.allow(r.messageID, start.Add(r.at)); got != r.want {
+					t.Errorf("request %d: allow(%q) = %v, want %v", n+1, r.messageID, got, r.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMessageCapNotice(t *testing.T) {
+	s, fake := newTestSession(t)
+	translator := &fakeTranslator{}
+	h := newTestHandler(t, Config{MaxTranslationsPerMessage: 1, MessageCapWindow: time.Hour}, translator)
+	msg := testMessage("hello")
+	h.translateReaction(s, testReaction("u1", "🇫🇷"), msg, "French")
+	h.translateReaction(s, testReaction("u2", "🇩🇪"), msg, "German")
+
+	if translator.count() != 1 {
+		t.Errorf("translated %d times, want 1", translator.count())
+	}
+	if dms := sentMessages(t, fake, "dm"); len(dms) != 1 || dms[0].Content != messageCapNotice {
+		t.Errorf("DMs = %+v, want the cap notice", dms)
+	}
+}
+
+func TestProviderConcurrency(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		wantPeak func(peak int) bool
+	}{
+		{name: "limit 1 serializes", limit: 1, wantPeak: func(peak int) bool { return peak == 1 }},
+		{name: "limit 2", limit: 2, wantPeak: func(peak int) bool { return peak <= 2 }},
+		{name: "unlimited", wantPeak: func(peak int) bool { return peak > 1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			inFlight, peak := 0, 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				inFlight++
+				if inFlight > peak {
+					peak = inFlight
+				}
+				mu.Unlock()
+				time.Sleep(20 * time.Millisecond)
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				w.Write([]byte(`{"choices":[{"message":{"content":"hola"}}]}`))
+			}))
+			defer server.Close()
+
+			translator := NewOpenAITranslator("token", "model")
+			translator.baseURL = server.URL
+			translator.sem = newSemaphore(tt.limit)
+
+			var wg sync.WaitGroup
+			for n := 0; n < 4; n++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := translator.Translate(context.Background(), TranslateRequest{Text: "hello", TargetLang: "Spanish"}); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if !tt.wantPeak(peak) {
+				t.Errorf("%d calls ran at once with limit %d", peak, tt.limit)
+			}
+		})
+	}
+}
+
+// holders checks that no two holders of a key overlap, and that holders of
+// different keys all get in at once: each waits until every key has been
+// entered, which would never happen if keys blocked each other
+type holders struct {
+	t        *testing.T
+	mu       sync.Mutex
+	inFlight map[string]int
+	entered  map[string]bool
+	keys     int
+	all      chan struct{}
+}
+
+func newHolders(t *testing.T, keys int) *holders {
+	return &holders{t: t, inFlight: make(map[string]int), entered: make(map[string]bool), keys: keys, all: make(chan struct{})}
+}
+
+func (h *holders) hold(key string) {
+	h.mu.Lock()
+	h.inFlight[key]++
+	if h.inFlight[key] > 1 {
+		h.t.Errorf("%d holders of %s at once", h.inFlight[key], key)
+	}
+	if !h.entered[key] {
+		h.entered[key] = true
+		if len(h.entered) == h.keys {
+			close(h.all)
+		}
+	}
+	h.mu.Unlock()
+
+	select {
+	case <-h.all:
+	case <-time.After(2 * time.Second):
+		h.t.Errorf("%s waited for other keys that never got in", key)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	h.mu.Lock()
+	h.inFlight[key]--
+	h.mu.Unlock()
+}
+
+func TestKeyedMutex(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+	}{
+		{name: "same key", keys: []string{"m1", "m1", "m1"}},
+		{name: "different keys", keys: []string{"m1", "m2", "m3"}},
+		{name: "mixed", keys: []string{"m1", "m1", "m2", "m2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locks := newKeyedMutex()
+			distinct := make(map[string]bool)
+			for _, key := range tt.keys {
+				distinct[key] = true
+			}
+			h := newHolders(t, len(distinct))
+			var wg sync.WaitGroup
+			for _, key := range tt.keys {
+				wg.Add(1)
+				go func(key string) {
+					defer wg.Done()
+					release := locks.lock(key)
+					defer release()
+					h.hold(key)
+				}(key)
+			}
+			wg.Wait()
+
+			if len(locks.locks) != 0 {
+				t.Errorf("%d locks left after release, want none", len(locks.locks))
+			}
+		})
+	}
+}
+
+func TestKeyedMutexNil(t *testing.T) {
+	var locks *keyedMutex
+	release := locks.lock("m1")
+	// Doesn't block
+	locks.lock("m1")()
+	release()
+}
+
+func TestReactionsSerializedPerMessage(t *testing.T) {
+	s, fake := newTestSession(t)
+	fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"one","author":{"id":"author"}}`
+	fake.replies["/channels/c1/messages/m2"] = `{"id":"m2","channel_id":"c1","content":"two","author":{"id":"author"}}`
+	holders := newHolders(t, 2)
+	translator := &fakeTranslator{reply: func(req TranslateRequest) (string, error) {
+		holders.hold(req.Text)
+		return req.Text, nil
+	}}
+	h := newTestHandler(t, Config{}, translator)
+	h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+	h.messageLocks = newKeyedMutex()
+
+	var wg sync.WaitGroup
+	for _, messageID := range []string{"m1", "m2"} {
+		for _, flag := range []string{"🇫🇷", "🇩🇪", "🇪🇸"} {
+			r := testReaction("user", flag)
+			r.MessageID = messageID
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				h.reactionAdd(s, r)
+			}()
+		}
+	}
+	wg.Wait()
+
+	if translator.count() != 6 {
+		t.Errorf("translated %d times, want 6", translator.count())
+	}
+}
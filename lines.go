@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+)
+
+// How many lines of a message are translated at the same time
+const lineWorkers = 4
+
+// translateLines translates each line of text on its own so the output has
+// exactly as many lines as the input. Blank lines are kept as they are, and
+// lines that fail are marked rather than failing the whole message.
+func translateLines(ctx context.Context, t Translator, req TranslateRequest, opts tokenOptions) (string, error) {
+	lines := strings.Split(req.Text, "\n")
+	items := make([]batchItem, len(lines))
+
+	sem := make(chan struct{}, lineWorkers)
+	var wg sync.WaitGroup
+	attempted := 0
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			items[i].Text = line
+			continue
+		}
+
+		attempted++
+		wg.Add(1)
+		go func(i int, line string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			out, err := translateProtected(ctx, t, req.with(line), opts)
+			// A line must not turn into several
+			items[i] = batchItem{Text: strings.ReplaceAll(strings.TrimSpace(out), "\n", " "), Err: err}
+		}(i, line)
+	}
+	wg.Wait()
+
+	translated := make([]string, len(items))
+	failed := 0
+	var lastErr error
+	for i, item := range items {
+		if item.Err != nil {
+			failed++
+			lastErr = item.Err
+		}
+		translated[i] = item.display()
+	}
+	if attempted > 0 && failed == attempted {
+		return "", lastErr
+	}
+	if failed > 0 {
+		log.Printf("Translated lines with some failures: %s", batchSummary(items))
+	}
+	return strings.Join(translated, "\n"), nil
+}
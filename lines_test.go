@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTranslateLines(t *testing.T) {
+	errDown := errors.New("down")
+	tests := []struct {
+		name    string
+		text    string
+		reply   func(req TranslateRequest) (string, error)
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "one translation per line",
+			text: "hello\nhow are you\nbye",
+			want: "translated: hello\ntranslated: how are you\ntranslated: bye",
+		},
+		{
+			name: "blank lines kept",
+			text: "hello\n\n  \nbye",
+			want: "translated: hello\n\n  \ntranslated: bye",
+		},
+		{
+			name:  "line breaks in a reply are joined",
+			text:  "hello\nbye",
+			reply: func(req TranslateRequest) (string, error) { return req.Text + "\nextra\n", nil },
+			want:  "hello extra\nbye extra",
+		},
+		{
+			name: "failed lines are marked",
+			text: "hello\nbye",
+			reply: func(req TranslateRequest) (string, error) {
+				if req.Text == "bye" {
+					return "", errDown
+				}
+				return "hola", nil
+			},
+			want: "hola\n" + failedMarker,
+		},
+		{
+			name:    "every line failing fails",
+			text:    "hello\nbye",
+			reply:   func(TranslateRequest) (string, error) { return "", errDown },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := &fakeTranslator{reply: tt.reply}
+			got, err := translateLines(context.Background(), translator, TranslateRequest{Text: tt.text, TargetLang: "Spanish"}, tokenOptions{})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("translateLines() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("translateLines() = %q, want %q", got, tt.want)
+			}
+			if in, out := strings.Count(tt.text, "\n"), strings.Count(got, "\n"); in != out {
+				t.Errorf("output has %d line breaks, input %d", out, in)
+			}
+		})
+	}
+}
+
+func TestPreserveLinesTranslatesLineByLine(t *testing.T) {
+	translator := &fakeTranslator{}
+	h := newTestHandler(t, Config{PreserveLines: true}, translator)
+	result, err := h.translateDetailed(context.Background(), "g1", "c1", "one\ntwo\nthree", "Spanish", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if translator.count() != 3 {
+		t.Errorf("got %d translation calls, want one per line", translator.count())
+	}
+	if lines := strings.Split(result.Text, "\n"); len(lines) != 3 {
+		t.Errorf("translation %q has %d lines, want 3", result.Text, len(lines))
+	}
+}
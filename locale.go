@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Map of Discord client locales to language names
+var localeToLang = map[discordgo.Locale]string{
+	discordgo.EnglishUS:    "English",
+	discordgo.EnglishGB:    "English",
+	discordgo.Bulgarian:    "Bulgarian",
+	discordgo.ChineseCN:    "Chinese",
+	discordgo.ChineseTW:    "Traditional Chinese",
+	discordgo.Croatian:     "Croatian",
+	discordgo.Czech:        "Czech",
+	discordgo.Danish:       "Danish",
+	discordgo.Dutch:        "Dutch",
+	discordgo.Finnish:      "Finnish",
+	discordgo.French:       "French",
+	discordgo.German:       "German",
+	discordgo.Greek:        "Greek",
+	discordgo.Hindi:        "Hindi",
+	discordgo.Hungarian:    "Hungarian",
+	discordgo.Italian:      "Italian",
+	discordgo.Japanese:     "Japanese",
+	discordgo.Korean:       "Korean",
+	discordgo.Lithuanian:   "Lithuanian",
+	discordgo.Norwegian:    "Norwegian",
+	discordgo.Polish:       "Polish",
+	discordgo.PortugueseBR: "Portuguese",
+	discordgo.Romanian:     "Romanian",
+	discordgo.Russian:      "Russian",
+	discordgo.SpanishES:    "Spanish",
+	discordgo.SpanishLATAM: "Spanish",
+	discordgo.Swedish:      "Swedish",
+	discordgo.Thai:         "Thai",
+	discordgo.Turkish:      "Turkish",
+	discordgo.Ukrainian:    "Ukrainian",
+	discordgo.Vietnamese:   "Vietnamese",
+}
+
+// localeLanguage maps a client locale to a language name. Regional variants
+// Discord adds later, e.g. pt-PT, fall back to another locale of the same
+// language.
+func localeLanguage(locale discordgo.Locale) (string, bool) {
+	if lang, ok := localeToLang[locale]; ok {
+		return lang, true
+	}
+	base, _, _ := strings.Cut(string(locale), "-")
+	if base == "" {
+		return "", false
+	}
+	// Any regional variant will do, but pick the same one every time
+	var match discordgo.Locale
+	for known := range localeToLang {
+		knownBase, _, _ := strings.Cut(string(known), "-")
+		if strings.EqualFold(knownBase, base) && (match == "" || known < match) {
+			match = known
+		}
+	}
+	if match == "" {
+		return "", false
+	}
+	return localeToLang[match], true
+}
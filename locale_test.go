@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestLocaleLanguage(t *testing.T) {
+	tests := []struct {
+		locale discordgo.Locale
+		want   string
+		wantOK bool
+	}{
+		{locale: discordgo.Japanese, want: "Japanese", wantOK: true},
+		{locale: discordgo.EnglishGB, want: "English", wantOK: true},
+		{locale: discordgo.SpanishLATAM, want: "Spanish", wantOK: true},
+		{locale: discordgo.ChineseTW, want: "Traditional Chinese", wantOK: true},
+		// Regional variants Discord doesn't have yet
+		{locale: "pt-PT", want: "Portuguese", wantOK: true},
+		{locale: "es-MX", want: "Spanish", wantOK: true},
+		{locale: "FR-CA", want: "French", wantOK: true},
+		// Several candidates, so always the first of them
+		{locale: "zh-HK", want: "Chinese", wantOK: true},
+		{locale: "xx"},
+		{locale: "xx-YY"},
+		{locale: ""},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.locale), func(t *testing.T) {
+			got, ok := localeLanguage(tt.locale)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("localeLanguage(%q) = %q, %v, want %q, %v", tt.locale, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
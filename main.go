@@ -1,22 +1,323 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/kelseyhightower/envconfig"
 )
 
 type Config struct {
+	Features
+
 	DiscordToken string `envconfig:"DISCORD_TOKEN" required:"true"`
 	OpenAIToken  string `envconfig:"OPENAI_TOKEN" required:"true"`
+	// The first model handles translations; the rest are only used by /compare
+	OpenAIModels []string `envconfig:"OPENAI_MODELS" default:"gpt-3.5-turbo"`
+	// Where chat completions are sent, e.g. an enterprise proxy or gateway
+	OpenAIBaseURL string `envconfig:"OPENAI_BASE_URL" default:"https://api.openai.com/v1"`
+	// Static headers for every OpenAI request, as Name=value,Name=value.
+	// Authorization and Content-Type are only replaced if override is set.
+	OpenAIExtraHeaders         string `envconfig:"OPENAI_EXTRA_HEADERS"`
+	OpenAIExtraHeadersOverride bool   `envconfig:"OPENAI_EXTRA_HEADERS_OVERRIDE"`
+	// Gzip OpenAI request bodies of at least COMPRESS_THRESHOLD bytes, for
+	// big batch prompts over constrained networks
+	CompressRequests  bool `envconfig:"COMPRESS_REQUESTS"`
+	CompressThreshold int  `envconfig:"COMPRESS_THRESHOLD" default:"1024"`
+	// Most requests in flight to each model at once; 0 means no limit.
+	// PROVIDER_CONCURRENCY overrides the default per model, as model:n.
+	DefaultConcurrency  int            `envconfig:"DEFAULT_CONCURRENCY"`
+	ProviderConcurrency map[string]int `envconfig:"PROVIDER_CONCURRENCY"`
+	// Cheaper model to translate with while the default model keeps being
+	// rate limited: after DOWNGRADE_AFTER 429s within DOWNGRADE_WINDOW it
+	// is used for DOWNGRADE_COOLDOWN, and the default model is trusted
+	// again after RESTORE_AFTER clean responses
+	FallbackModel     string        `envconfig:"FALLBACK_MODEL"`
+	DowngradeAfter    int           `envconfig:"DOWNGRADE_AFTER" default:"5"`
+	DowngradeWindow   time.Duration `envconfig:"DOWNGRADE_WINDOW" default:"1m"`
+	DowngradeCooldown time.Duration `envconfig:"DOWNGRADE_COOLDOWN" default:"5m"`
+	RestoreAfter      int           `envconfig:"RESTORE_AFTER" default:"3"`
+	// Retry once when a model returns an empty translation
+	RetryEmpty bool `envconfig:"RETRY_EMPTY" default:"true"`
+	// Detect the source language of every translation and keep it in the
+	// translation history; SHOW_SOURCE_LANG also names it in the footer.
+	// This costs an extra provider call per translation.
+	DetectSource   bool `envconfig:"DETECT_SOURCE"`
+	ShowSourceLang bool `envconfig:"SHOW_SOURCE_LANG"`
+	// Recent translations kept in memory for analytics; 0 keeps none
+	HistorySize int `envconfig:"HISTORY_SIZE" default:"1000"`
+
+	// Retry once, naming the expected script, when a translation to a
+	// language such as Japanese or Arabic comes back in another script
+	VerifyScript bool `envconfig:"VERIFY_SCRIPT"`
+	// Every GLOSSARY_SUGGEST_INTERVAL, post the names the model left
+	// untranslated at least GLOSSARY_SUGGEST_MIN times to each guild's log
+	// channel as glossary candidates; 0 turns it off
+	GlossarySuggestInterval time.Duration `envconfig:"GLOSSARY_SUGGEST_INTERVAL"`
+	GlossarySuggestMin      int           `envconfig:"GLOSSARY_SUGGEST_MIN" default:"5"`
+	// Post a digest of up to DIGEST_SIZE of the past day's translations to
+	// each guild's channel, given as guild:channel, at DIGEST_TIME (HH:MM in
+	// DIGEST_TIMEZONE). Needs HISTORY_SIZE, which then keeps the text.
+	DigestChannels map[string]string `envconfig:"DIGEST_CHANNELS"`
+	DigestTime     string            `envconfig:"DIGEST_TIME" default:"09:00"`
+	DigestTimezone string            `envconfig:"DIGEST_TIMEZONE" default:"UTC"`
+	DigestSize     int               `envconfig:"DIGEST_SIZE" default:"5"`
+	// Translate reactions on the same message one at a time, while
+	// different messages still go in parallel
+	SerializePerMessage bool `envconfig:"SERIALIZE_PER_MESSAGE" default:"true"`
+	// Instead of failing a reaction translation the provider rate limits,
+	// keep retrying it for up to MAX_QUEUE_DELAY, marking the message ⏳
+	QueueRateLimited bool          `envconfig:"QUEUE_RATE_LIMITED"`
+	MaxQueueDelay    time.Duration `envconfig:"MAX_QUEUE_DELAY" default:"2m"`
+	// Target language to the model that should translate into it, for
+	// languages the default model handles poorly
+	LanguageRoutes map[string]string `envconfig:"LANGUAGE_ROUTES"`
+
+	// Requests that look like prompts for the model rather than messages,
+	// scored by abuseScore, are let through once per ABUSE_WINDOW from
+	// ABUSE_THROTTLE_SCORE and refused from ABUSE_REFUSE_SCORE; 0 turns
+	// either off
+	AbuseThrottleScore int           `envconfig:"ABUSE_THROTTLE_SCORE"`
+	AbuseRefuseScore   int           `envconfig:"ABUSE_REFUSE_SCORE"`
+	AbuseWindow        time.Duration `envconfig:"ABUSE_WINDOW" default:"10m"`
+
+	// Timeout for each Discord REST call
+	DiscordTimeout time.Duration `envconfig:"DISCORD_TIMEOUT" default:"15s"`
+
+	// Used when FEATURE_HEALTH_CHECK is on
+	HealthCheckInterval time.Duration `envconfig:"HEALTH_CHECK_INTERVAL" default:"1m"`
+	HealthCheckFailures int           `envconfig:"HEALTH_CHECK_FAILURES" default:"3"`
+
+	// Language for /explain notes when the requester's locale isn't known
+	NotesLang string `envconfig:"NOTES_LANG" default:"English"`
+
+	// Include the original text in translation embeds, for learners and
+	// for checking the translation
+	ShowOriginal bool `envconfig:"SHOW_ORIGINAL"`
+
+	// Show the target language's flag in translation footers
+	ShowFlag bool `envconfig:"SHOW_FLAG"`
+
+	// Ping the author of a message when a translation replies to it
+	PingAuthor bool `envconfig:"PING_AUTHOR"`
+
+	// Guilds whose reaction translations are sent to the requester by DM
+	// instead of being posted
+	EphemeralOnlyGuilds []string `envconfig:"EPHEMERAL_ONLY_GUILDS"`
+
+	// Appended to the footer of every translation, e.g. "Automated
+	// translation, may contain errors"; guilds can set their own
+	DisclaimerText string `envconfig:"DISCLAIMER_TEXT"`
+
+	// Daily window such as 22:00-07:00, in QUIET_TIMEZONE, when reaction and
+	// mirror translations pause but commands still work; guilds can set
+	// their own
+	QuietHours       string `envconfig:"QUIET_HOURS"`
+	QuietTimezone    string `envconfig:"QUIET_TIMEZONE" default:"UTC"`
+	NotifyQuietHours bool   `envconfig:"NOTIFY_QUIET_HOURS"`
+
+	// Once POPULAR_THRESHOLD different people have asked for the same message
+	// in the same language, pin the translation or, with highlight, repost it
+	// to the guild's POPULAR_CHANNELS channel (guild:channel); 0 turns it off
+	PopularThreshold int               `envconfig:"POPULAR_THRESHOLD"`
+	PopularAction    string            `envconfig:"POPULAR_ACTION" default:"pin"`
+	PopularChannels  map[string]string `envconfig:"POPULAR_CHANNELS"`
+
+	// Source channel ID to target channel ID and language; every message in
+	// the source is translated and posted to the target under its author's
+	// name, e.g. 123:456/French. Needs the privileged message content intent.
+	MirrorChannels map[string]string `envconfig:"MIRROR_CHANNELS"`
+
+	// Prefix of the text command when FEATURE_TEXT_COMMANDS is on; guilds
+	// can set their own
+	CommandPrefix string `envconfig:"COMMAND_PREFIX" default:"!"`
+
+	// Guild ID to channel ID; translations in these guilds are posted to the
+	// given channel instead of where the reaction happened
+	TranslationChannels map[string]string `envconfig:"TRANSLATION_CHANNELS"`
+
+	// DM users who react with a flag that isn't mapped to a language
+	NotifyUnmappedFlags bool `envconfig:"NOTIFY_UNMAPPED_FLAGS"`
+
+	// DM users when the bot can't read the message they reacted to
+	NotifyMissingAccess bool `envconfig:"NOTIFY_MISSING_ACCESS"`
+
+	// Menu number (1-9) to language, for servers that prefer number reactions
+	// over flags
+	NumberLanguages map[string]string `envconfig:"NUMBER_LANGUAGES"`
+
+	// Only users with this role, or whose account and guild membership are
+	// old enough, may trigger translations. All are optional.
+	RequiredRoleID string        `envconfig:"REQUIRED_ROLE_ID"`
+	MinAccountAge  time.Duration `envconfig:"MIN_ACCOUNT_AGE"`
+	MinMemberAge   time.Duration `envconfig:"MIN_MEMBER_AGE"`
+	// DM users who are turned away by the checks above
+	NotifyGated bool `envconfig:"NOTIFY_GATED"`
+
+	// Page buttons on long translations wrap around instead of stopping at
+	// the first and last page
+	PageWraparound bool `envconfig:"PAGE_WRAPAROUND"`
+	// Instead of paging, show the first TRUNCATE_LENGTH characters of a long
+	// translation and post the full text in a thread linked from it
+	TruncateWithLink bool `envconfig:"TRUNCATE_WITH_LINK"`
+	TruncateLength   int  `envconfig:"TRUNCATE_LENGTH" default:"1000"`
+
+	// Translate multi-line messages line by line so poems, lyrics and lists
+	// keep their shape
+	PreserveLines bool `envconfig:"PRESERVE_LINES"`
+	// Keep unicode emoji exactly as written instead of letting the model
+	// drop or describe them
+	PreserveEmoji bool `envconfig:"PRESERVE_EMOJI" default:"true"`
+	// Keep numbers, amounts and dates exactly as written
+	PreserveNumbers bool `envconfig:"PRESERVE_NUMBERS"`
+	// Guild ID to formality (formal or informal) for that guild's
+	// translations; guilds not listed use the model's default register
+	Formality map[string]string `envconfig:"FORMALITY"`
+	// Target language to register (formal or informal), e.g.
+	// japanese:formal,german:informal, for guilds without a FORMALITY. The
+	// register is only asked for in translations to those languages.
+	LanguageRegister map[string]string `envconfig:"LANGUAGE_REGISTER"`
+	// How @everyone and @here in translations are neutralized: escape breaks
+	// them with a zero-width space, remove drops the @. They never ping
+	// either way.
+	MassMentions string `envconfig:"MASS_MENTIONS" default:"escape"`
+	// Translate only the anchor text of markdown links, never their URLs
+	TranslateLinkText bool `envconfig:"TRANSLATE_LINK_TEXT"`
+	// In fenced code blocks, translate only the comments and leave the code
+	// as written
+	TranslateCodeComments bool `envconfig:"TRANSLATE_CODE_COMMENTS"`
+	// Detect the source language first and name it in the prompt. This costs
+	// an extra provider call per translation.
+	IncludeSourceHint bool `envconfig:"INCLUDE_SOURCE_HINT"`
+	// Source>Target language pair to an instruction appended to prompts for
+	// that pair, e.g. Japanese>English:Keep honorifics such as -san. Use *
+	// as the source to match any. Instructions can't contain commas or
+	// colons. The source is detected when a pair needs it.
+	PairPrompts map[string]string `envconfig:"PAIR_PROMPTS"`
+
+	// Used when FEATURE_CACHE is on. Normalization is none, basic or
+	// aggressive; see normalizeForCache. Homoglyph folding is none, mixed or
+	// all; see foldHomoglyphs.
+	CacheTTL           time.Duration `envconfig:"CACHE_TTL" default:"1h"`
+	CacheSize          int           `envconfig:"CACHE_SIZE" default:"1000"`
+	CacheNormalization string        `envconfig:"CACHE_NORMALIZATION" default:"basic"`
+	CacheHomoglyphs    string        `envconfig:"CACHE_HOMOGLYPHS" default:"none"`
+
+	// How many recent translations between the same languages in a channel
+	// are sent along as context, and how long a quiet conversation is kept;
+	// 0 turns conversation context off
+	ConversationSize int           `envconfig:"CONVERSATION_SIZE"`
+	ConversationTTL  time.Duration `envconfig:"CONVERSATION_TTL" default:"10m"`
+
+	// In these channels each translation is scored by the model, and ones
+	// scoring below the threshold (out of 10) are redone with the escalation
+	// model
+	QualityChannels  []string `envconfig:"QUALITY_CHANNELS"`
+	QualityThreshold int      `envconfig:"QUALITY_THRESHOLD" default:"7"`
+	EscalationModel  string   `envconfig:"ESCALATION_MODEL"`
+
+	// Cap on translations of any one message per window; 0 means no cap
+	MaxTranslationsPerMessage int           `envconfig:"MAX_TRANSLATIONS_PER_MESSAGE"`
+	MessageCapWindow          time.Duration `envconfig:"MESSAGE_CAP_WINDOW" default:"1h"`
+
+	// Translations allowed per minute for each user and each guild; 0 means
+	// no limit
+	UserRateLimitPerMinute  int `envconfig:"USER_RATE_LIMIT_PER_MINUTE"`
+	GuildRateLimitPerMinute int `envconfig:"GUILD_RATE_LIMIT_PER_MINUTE"`
+
+	// Reacting with this emoji posts a description of the message's other
+	// reactions, for screen reader users; empty turns it off
+	DescribeReactionsEmoji string `envconfig:"DESCRIBE_REACTIONS_EMOJI"`
+	DescribeReactionsLang  string `envconfig:"DESCRIBE_REACTIONS_LANG" default:"English"`
+
+	// Reacting with this emoji, e.g. 📝, posts a translated summary of the
+	// message as a few bullet points; off unless set. Summaries are in the
+	// channel's CHANNEL_LANGUAGES entry where it has one.
+	SummarizeEmoji string `envconfig:"SUMMARIZE_EMOJI"`
+	SummaryLang    string `envconfig:"SUMMARY_LANG" default:"English"`
+	// Reacting with this emoji, e.g. 🤔, posts a plain-language explanation
+	// of the message in NOTES_LANG, without translating it; off unless set
+	ClarifyEmoji string `envconfig:"CLARIFY_EMOJI"`
+	// Reacting with this emoji, e.g. 🔄, translates the message into the
+	// other language of the guild's pair, e.g. English for a Spanish message
+	// in an English/Spanish server; off unless set
+	FlipEmoji string `envconfig:"FLIP_EMOJI"`
+	// Guild ID to language pair, e.g. 123:English/Spanish
+	LanguagePairs map[string]string `envconfig:"LANGUAGE_PAIRS"`
+	// Reacting with this emoji, e.g. 🌐, translates the message into its
+	// channel's CHANNEL_LANGUAGES entry; off unless set
+	TranslateEmoji string `envconfig:"TRANSLATE_EMOJI"`
+	// Channel ID to the language the translate emoji picks there, e.g. for a
+	// French practice channel
+	ChannelLanguages map[string]string `envconfig:"CHANNEL_LANGUAGES"`
+	// Channel ID to the only target languages allowed there, e.g.
+	// 123:French/English
+	ChannelAllowedLanguages map[string]string `envconfig:"CHANNEL_ALLOWED_LANGUAGES"`
+
+	// Added to a message the bot saw but chose not to act on, e.g. one with
+	// no text, such as ℹ️; off unless set
+	SkipReaction string `envconfig:"SKIP_REACTION"`
+
+	// Estimated monthly spend per guild on the shared OpenAI key. Once a
+	// guild passes COST_WARN_THRESHOLD of MONTHLY_BUDGET (in dollars), a
+	// warning is posted to its log channel, once per month.
+	MonthlyBudget     float64           `envconfig:"MONTHLY_BUDGET"`
+	CostPer1KTokens   float64           `envconfig:"COST_PER_1K_TOKENS" default:"0.002"`
+	CostWarnThreshold float64           `envconfig:"COST_WARN_THRESHOLD" default:"0.8"`
+	LogChannels       map[string]string `envconfig:"LOG_CHANNELS"`
+
+	// Target languages the model translates well. Others get a quality
+	// warning in the footer, or with UNRELIABLE_POLICY=block aren't
+	// translated at all. Empty trusts every language.
+	ReliableLanguages []string `envconfig:"RELIABLE_LANGUAGES"`
+	UnreliablePolicy  string   `envconfig:"UNRELIABLE_POLICY" default:"warn"`
+
+	// Private channel that gets every model exchange, for troubleshooting
+	// prompts. Only sizes are posted unless DEBUG_CONTENT is set; keys and
+	// tokens are redacted either way.
+	DebugChannelID string `envconfig:"DEBUG_CHANNEL_ID"`
+	DebugContent   bool   `envconfig:"DEBUG_CONTENT"`
+
+	// Translation events are POSTed here as JSON when set
+	EventWebhookURL string `envconfig:"EVENT_WEBHOOK_URL"`
+
+	// Post-processors applied to every translation, in order
+	PostProcessors []string `envconfig:"POST_PROCESSORS" default:"trim"`
+
+	// Strip labels, quotes and notes models wrap around translations
+	StripWrappers bool `envconfig:"STRIP_WRAPPERS"`
+
+	// Log p50/p95/p99 translation latency over the last LATENCY_WINDOW this
+	// often; 0 turns it off
+	LatencyLogInterval time.Duration `envconfig:"LATENCY_LOG_INTERVAL"`
+	LatencyWindow      time.Duration `envconfig:"LATENCY_WINDOW" default:"5m"`
+
+	// How long each background component gets to stop on shutdown
+	ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"10s"`
+
+	// Exit on startup if the warmup translation fails instead of only logging
+	StrictStartup bool `envconfig:"STRICT_STARTUP"`
+
+	// Where servers' own OpenAI keys are kept, encrypted with the secret.
+	// Without a file they are forgotten on restart.
+	GuildKeysFile   string `envconfig:"GUILD_KEYS_FILE"`
+	GuildKeysSecret string `envconfig:"GUILD_KEYS_SECRET"`
+
+	// Where configs imported with /config are saved; without a file they
+	// are forgotten on restart
+	GuildConfigFile string `envconfig:"GUILD_CONFIG_FILE"`
+
+	// Discord user ID allowed to run owner-only commands
+	OwnerID string `envconfig:"OWNER_ID"`
 }
 
 var (
@@ -33,30 +334,77 @@ var (
 		"🇨🇳": "Chinese",    // Chinese flag
 		"🇵🇹": "Portuguese", // Portuguese flag
 		"🇷🇺": "Russian",    // Russian flag
+		"🇵🇭": "Filipino",   // Philippines flag
+		"🇻🇳": "Vietnamese", // Vietnam flag
+		"🇹🇭": "Thai",       // Thailand flag
+		"🇸🇦": "Arabic",     // Saudi Arabia flag
+		"🇪🇬": "Arabic",     // Egypt flag
+		"🇮🇳": "Hindi",      // India flag
+		"🇮🇩": "Indonesian", // Indonesia flag
+		"🇲🇾": "Malay",      // Malaysia flag
+		"🇳🇱": "Dutch",      // Netherlands flag
+		"🇵🇱": "Polish",     // Poland flag
+		"🇹🇷": "Turkish",    // Turkey flag
+		"🇺🇦": "Ukrainian",  // Ukraine flag
+		"🇬🇷": "Greek",      // Greece flag
+		"🇸🇪": "Swedish",    // Sweden flag
+		"🇮🇱": "Hebrew",     // Israel flag
+		"🇮🇷": "Persian",    // Iran flag
 		// Add more flags as needed
 	}
 )
 
-type OpenAIRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-}
-
-type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
+type DiscordHandler struct {
+	config      *Config
+	translators []Translator
+	routes      map[string]Translator
+	completer   Completer
+	triggers    []LanguageTrigger
+	pages       *paginator
+	maintenance maintenanceMode
+	posted      *postedTranslations
+	cache       *translationCache
+	events      EventSink
+	messageCap  *messageCap
+	limiter     *rateLimiter
+	notices     *noticeThrottle
+	postProcess postChain
+	metrics     metrics
 
-type OpenAIResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-}
+	// conversations is nil unless CONVERSATION_SIZE is set
+	conversations *conversationBuffer
+	// messages is nil unless FEATURE_MESSAGE_CACHE is on
+	messages *messageCache
+	// abuse is nil unless ABUSE_THROTTLE_SCORE or ABUSE_REFUSE_SCORE is set
+	abuse *abuseGuard
+	// mirrors maps source channels to the channel and language they are
+	// mirrored into
+	mirrors     map[string]mirrorRule
+	mirrorHooks *mirrorWebhooks
+	// messageLocks is nil unless SERIALIZE_PER_MESSAGE is set
+	messageLocks *keyedMutex
+	// terms is nil unless GLOSSARY_SUGGEST_INTERVAL is set
+	terms *termTracker
+	// history is nil unless HISTORY_SIZE is set
+	history *translationHistory
+	// latencies is nil unless LATENCY_LOG_INTERVAL is set
+	latencies *latencyWindow
+	// demand is nil unless POPULAR_THRESHOLD is set
+	demand *demandTracker
+	// registers maps lowercase target languages to their LANGUAGE_REGISTER
+	registers map[string]Formality
+	// keys holds servers' own OpenAI keys
+	keys *guildKeys
+	// guildConfigs holds settings servers imported with /config
+	guildConfigs *guildConfigs
+	// escalation retranslates low-scoring translations in QUALITY_CHANNELS
+	escalation Translator
 
-type DiscordHandler struct {
-	config *Config
+	// ready is false while the session is failing health checks
+	ready atomic.Bool
+	// ctx is canceled once the bot starts shutting down, ending waits such
+	// as queued retries
+	ctx context.Context
 }
 
 func (h *DiscordHandler) reactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
@@ -65,49 +413,373 @@ func (h *DiscordHandler) reactionAdd(s *discordgo.Session, r *discordgo.MessageR
 		return
 	}
 
-	// Check if the reaction is a flag emoji we support
-	targetLang, ok := flagToLang[r.Emoji.Name]
-	if !ok {
-		return // Not a supported flag emoji
+	// Check if the reaction is a control emoji or a flag or menu emoji we support
+	control, isControl := controlReactions[r.Emoji.Name]
+	targetLang, isTrigger := h.resolveLanguage(r.Emoji.Name)
+	isDescribe := h.config.DescribeReactionsEmoji != "" && r.Emoji.Name == h.config.DescribeReactionsEmoji
+	isSummarize := h.config.SummarizeEmoji != "" && r.Emoji.Name == h.config.SummarizeEmoji
+	isClarify := h.config.ClarifyEmoji != "" && r.Emoji.Name == h.config.ClarifyEmoji
+	isFlip := h.config.FlipEmoji != "" && r.Emoji.Name == h.config.FlipEmoji
+	isDefault := h.config.TranslateEmoji != "" && r.Emoji.Name == h.config.TranslateEmoji
+	if !isControl && !isTrigger && !isDescribe && !isSummarize && !isClarify && !isFlip && !isDefault {
+		// Let users know a flag they tried doesn't translate, but stay quiet
+		// about every other emoji
+		if h.config.NotifyUnmappedFlags && isFlagEmoji(r.Emoji.Name) {
+			h.notify(s, r.UserID, noticeUnmappedFlag, unmappedFlagNotice())
+		}
+		return // Not an emoji we act on
 	}
 
 	// Get the message that was reacted to
-	msg, err := s.ChannelMessage(r.ChannelID, r.MessageID)
+	msg, err := fetchMessage(s, r.ChannelID, r.MessageID)
 	if err != nil {
-		log.Printf("Error fetching message: %v", err)
+		h.handleFetchError(s, r, err)
 		return
 	}
 
+	// Translations, including ones posted by another instance of the bot or
+	// forwarded, are never translated again
+	if (isTrigger || isFlip || isDefault) && !isControl && isMarkedTranslation(msg) {
+		h.notify(s, r.UserID, noticeTranslated, "That message is already a translation. React to the original message to translate it into another language.")
+		return
+	}
+
+	// Control emoji only act on the bot's own translations, and triggers only
+	// on other messages, so a flag on a translation never translates it again
+	if msg.Author != nil && msg.Author.ID == s.State.User.ID {
+		if isControl {
+			control(h, s, r, msg)
+		}
+		return
+	}
+	switch {
+	case isDescribe:
+		h.describeReactions(s, r, msg)
+	case isSummarize:
+		h.summarizeReaction(s, r, msg)
+	case isClarify:
+		h.clarifyReaction(s, r, msg)
+	case isFlip:
+		h.flipReaction(s, r, msg)
+	case isDefault:
+		h.channelDefaultReaction(s, r, msg)
+	case isTrigger:
+		h.translateReaction(s, r, msg, targetLang)
+	}
+}
+
+// admitReaction reports whether the user behind r may have the bot call the
+// provider right now, telling them why not where that's useful. Every kind
+// of reaction waits out quiet hours; commands don't.
+func (h *DiscordHandler) admitReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd) bool {
+	if h.quietReaction(s, r) {
+		return false
+	}
+	return h.admitByDM(s, r.UserID, r.GuildID, r.Member, "")
+}
+
+// Told to gated accounts when NOTIFY_GATED is set
+const gatedNotice = "Sorry, your account isn't allowed to request translations on this server yet."
+
+// admit runs the checks every translation request goes through, in order:
+// maintenance, the account gate, the rate limiter and, when messageID is
+// set, the per-message cap. It returns the kind and text of the notice to
+// give the user, or an empty kind when the request may go ahead.
+func (h *DiscordHandler) admit(userID, guildID string, member *discordgo.Member, messageID string) (noticeType, notice string) {
+	// Tell users why nothing happens while translations are paused
+	if on, message := h.maintenance.active(); on {
+		return noticeMaintenance, message
+	}
+
+	// Keep throwaway accounts from using the bot
+	if !h.canTrigger(userID, member, time.Now()) {
+		return noticeGated, gatedNotice
+	}
+
+	// Protect the shared API key from heavy users and busy servers
+	if tier := h.limiter.allow(userID, guildID, time.Now()); tier != tierNone {
+		reason := DroppedGuildRateLimit
+		if tier == tierUser {
+			reason = DroppedUserRateLimit
+		}
+		h.recordDropped(reason, guildID, userID)
+		return noticeRateLimited, rateLimitNotice(tier)
+	}
+
+	// Stop one message from being translated into every language
+	if messageID != "" && !h.messageCap.allow(messageID, time.Now()) {
+		h.recordDropped(DroppedMessageCap, guildID, userID)
+		return noticeMessageCap, messageCapNotice
+	}
+	return "", ""
+}
+
+// admitByDM is admit for requests with no interaction to reply to, telling
+// the user why by DM
+func (h *DiscordHandler) admitByDM(s *discordgo.Session, userID, guildID string, member *discordgo.Member, messageID string) bool {
+	noticeType, notice := h.admit(userID, guildID, member, messageID)
+	if noticeType == "" {
+		return true
+	}
+	if noticeType != noticeGated || h.config.NotifyGated {
+		h.notify(s, userID, noticeType, notice)
+	}
+	return false
+}
+
+// admitCommand is admit for slash commands, replying with the notice only
+// the user sees. A command isn't about one message, so the per-message cap
+// doesn't apply.
+func (h *DiscordHandler) admitCommand(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	noticeType, notice := h.admit(interactionUser(i).ID, i.GuildID, i.Member, "")
+	if noticeType == "" {
+		return true
+	}
+	respondEphemeral(s, i, notice)
+	return false
+}
+
+// translateReaction posts a translation of msg requested by a reaction
+func (h *DiscordHandler) translateReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message, targetLang string) {
 	// Don't translate empty messages
-	if msg.Content == "" {
+	text := messageText(msg)
+	files := textAttachments(msg)
+	if text == "" && len(files) == 0 {
+		h.skip(s, r, "message has no text")
+		return
+	}
+	if !h.channelAllows(r.ChannelID, targetLang) {
+		h.notify(s, r.UserID, noticeRestricted, restrictedNotice(h.channelLanguages(r.ChannelID)))
+		return
+	}
+	if !h.allowContent(r.UserID, text, targetLang) {
+		h.notify(s, r.UserID, noticeAbuse, abuseNotice)
 		return
 	}
 
-	// Translate the message
-	translation, err := translateWithOpenAI(msg.Content, targetLang, h.config.OpenAIToken)
-	if err != nil {
-		log.Printf("Error translating text: %v", err)
+	if !h.admitTranslation(s, r, msg) {
+		return
+	}
+	h.deliverTranslation(s, r, msg, text, files, targetLang)
+}
+
+// admitTranslation is admitReaction plus the per-message cap on languages
+func (h *DiscordHandler) admitTranslation(s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message) bool {
+	if h.quietReaction(s, r) {
+		return false
+	}
+	return h.admitByDM(s, r.UserID, r.GuildID, r.Member, msg.ID)
+}
+
+// deliverTranslation translates a message and its text files and posts the
+// result where the guild wants it
+func (h *DiscordHandler) deliverTranslation(s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message, text string, files []*discordgo.MessageAttachment, targetLang string) {
+	// Reactions in several languages at once are posted one after another
+	release := h.messageLocks.lock(msg.ID)
+	defer func() { release() }()
+
+	// Text files are translated and attached to a reply of their own
+	if len(files) > 0 {
+		h.translateTextFiles(s, r, msg, files, targetLang)
+	}
+	if text == "" {
 		return
 	}
 
+	// Translate the message, unless it was translated to this language
+	// before and hasn't been edited since
+	cacheLang := cacheLanguage(targetLang, h.formalityFor(r.GuildID, targetLang), h.guildConfig(r.GuildID).glossaryFor(targetLang))
+	var result translationResult
+	hit := false
+	if h.messages != nil {
+		result, hit = h.messages.get(msg.ID, cacheLang, time.Now())
+		h.metrics.recordCacheLookup(hit)
+	}
+	if !hit {
+		var err error
+		result, err = h.translateDetailed(h.ctx, r.GuildID, r.ChannelID, text, targetLang, "")
+		if h.queues(err) {
+			// Other reactions on the message go ahead while this one waits
+			release()
+			result, err = h.translateQueued(s, r, text, targetLang, err)
+			release = h.messageLocks.lock(msg.ID)
+		}
+		h.emitTranslation(r.GuildID, targetLang, err)
+		if errors.Is(err, errUnreliableLanguage) {
+			h.notify(s, r.UserID, noticeUnreliable, unreliableNotice(targetLang))
+			return
+		}
+		if err != nil {
+			log.Printf("Error translating text: %v", err)
+			return
+		}
+		if h.messages != nil {
+			h.messages.put(msg.ID, cacheLang, result, time.Now())
+		}
+	}
+
 	// Create response embed
+	embed := translationEmbed(msg, result.Text, targetLang)
+	if h.config.ShowOriginal {
+		addOriginalField(embed, text)
+	}
+	if h.config.ShowSourceLang {
+		addSourceLanguage(embed, result.SourceLang)
+	}
+	if h.config.ShowFlag {
+		addLanguageFlag(embed, targetLang)
+	}
+	addDisclaimer(embed, h.qualityWarning(targetLang))
+	addDisclaimer(embed, h.guildConfig(r.GuildID).Disclaimer)
+
+	channelID, sent, err := h.postReactionEmbed(s, r, msg, embed)
+	if err != nil {
+		log.Printf("Error sending translation: %v", err)
+		return
+	}
+	h.countDemand(s, r, targetLang, channelID, sent, embed)
+}
+
+// postReactionEmbed delivers what a reaction on msg asked for where the
+// guild wants it: by DM in ephemeral-only guilds, otherwise as a reply or
+// in the guild's translations channel. Nothing is posted, and channelID and
+// sent are empty, when it goes by DM.
+func (h *DiscordHandler) postReactionEmbed(s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message, embed *discordgo.MessageEmbed) (channelID string, sent *discordgo.Message, err error) {
+	jump := &discordgo.MessageEmbedField{
+		Name:  "Original message",
+		Value: fmt.Sprintf("[Jump to message](%s)", messageLink(r.GuildID, r.ChannelID, r.MessageID)),
+	}
+
+	// Privacy-focused servers get translations by DM only. Reactions carry no
+	// interaction, so an ephemeral reply isn't possible.
+	if h.guildConfig(r.GuildID).ephemeralOnly() {
+		embed.Fields = append(embed.Fields, jump)
+		embed.Description = truncate(embed.Description, descriptionRoom(embed, 0))
+		send := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}
+		if err := sendPrivately(s, r.UserID, send); err != nil {
+			return "", nil, fmt.Errorf("error sending by DM: %v", err)
+		}
+		return "", nil, nil
+	}
+
+	channelID, reference, elsewhere := h.translationDestination(r, msg)
+	if elsewhere {
+		embed.Fields = append(embed.Fields, jump)
+	}
+	sent, err = h.sendPaged(s, channelID, embed, reference)
+	if err != nil {
+		return "", nil, err
+	}
+	h.posted.record(channelID, sent.ID)
+	return channelID, sent, nil
+}
+
+// translationDestination picks where a reaction translation of msg is
+// posted: as a reply to it, or to the guild's translations channel, in
+// which case elsewhere is set and reference is nil
+func (h *DiscordHandler) translationDestination(r *discordgo.MessageReactionAdd, msg *discordgo.Message) (channelID string, reference *discordgo.MessageReference, elsewhere bool) {
+	if target := h.guildConfig(r.GuildID).TranslationChannel; target != "" && r.GuildID != "" {
+		return target, nil, true
+	}
+	return r.ChannelID, msg.SoftReference(), false
+}
+
+// Languages written right to left
+var rtlLanguages = map[string]bool{
+	"arabic":  true,
+	"hebrew":  true,
+	"persian": true,
+	"urdu":    true,
+}
+
+// Right-to-left mark
+const rtlMark = "\u200F"
+
+// orientText starts each line of a right-to-left translation with a
+// right-to-left mark. Discord lays out embed lines by their first strong
+// character, so lines opening with a number, link or Latin word would
+// otherwise be shown left to right with their punctuation misplaced.
+func orientText(text, lang string) string {
+	if !rtlLanguages[strings.ToLower(lang)] {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = rtlMark + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Invisible marker in the footer of every translation embed, so translations
+// can be recognized wherever they turn up
+const translationMarker = "\u2063"
+
+// isMarkedTranslation reports whether msg carries a translation embed
+func isMarkedTranslation(msg *discordgo.Message) bool {
+	for _, embed := range msg.Embeds {
+		if embed.Footer != nil && strings.Contains(embed.Footer.Text, translationMarker) {
+			return true
+		}
+	}
+	return false
+}
+
+// addOriginalField shows the source text under the translation, shortened
+// to fit in a field
+func addOriginalField(embed *discordgo.MessageEmbed, original string) {
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  "Original",
+		Value: truncate(original, maxFieldValueLength),
+	})
+}
+
+// addSourceLanguage names the detected language of the original in a
+// translation's footer, e.g. "Translated from French to English"
+func addSourceLanguage(embed *discordgo.MessageEmbed, sourceLang string) {
+	if sourceLang == "" || embed.Footer == nil {
+		return
+	}
+	embed.Footer.Text = strings.Replace(embed.Footer.Text, "Translated to ", "Translated from "+sourceLang+" to ", 1)
+}
+
+// Separates the disclaimer from the rest of a translation's footer
+const disclaimerSeparator = " • "
+
+// Footer space kept free for the page number of a paged translation
+const pageNumberRoom = 32
+
+// addDisclaimer appends a disclaimer to a translation's footer, cut short
+// if the footer would otherwise be too long
+func addDisclaimer(embed *discordgo.MessageEmbed, disclaimer string) {
+	if disclaimer == "" || embed.Footer == nil {
+		return
+	}
+	footer := strings.TrimSuffix(embed.Footer.Text, translationMarker) + disclaimerSeparator
+	room := maxFooterLength - pageNumberRoom - len([]rune(footer)) - len([]rune(translationMarker))
+	if room < 2 {
+		return
+	}
+	embed.Footer.Text = footer + truncate(disclaimer, room) + translationMarker
+}
+
+// translationEmbed presents a translation of msg
+func translationEmbed(msg *discordgo.Message, translation, targetLang string) *discordgo.MessageEmbed {
 	embed := &discordgo.MessageEmbed{
-		Author: &discordgo.MessageEmbedAuthor{
-			Name:    msg.Author.Username,
-			IconURL: msg.Author.AvatarURL(""),
-		},
-		Description: translation,
+		Description: orientText(translation, targetLang),
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("Translated to %s", targetLang),
+			Text: fmt.Sprintf("Translated to %s", targetLang) + translationMarker,
 		},
 		Color: 0x00BFFF, // Light blue color
 	}
-
-	// Send the translation as a reply
-	_, err = s.ChannelMessageSendEmbed(r.ChannelID, embed)
-	if err != nil {
-		log.Printf("Error sending translation: %v", err)
+	if msg.Author != nil {
+		embed.Author = &discordgo.MessageEmbedAuthor{
+			Name:    msg.Author.Username,
+			IconURL: msg.Author.AvatarURL(""),
+		}
 	}
+	return embed
 }
 
 func main() {
@@ -124,72 +796,242 @@ func main() {
 	if err != nil {
 		log.Fatal("Error creating Discord session:", err)
 	}
+	// Don't let a slow REST call hang a handler forever
+	dg.Client.Timeout = c.DiscordTimeout
 
-	// Register reaction handlers
-	handler := &DiscordHandler{config: &c}
-	dg.AddHandler(handler.reactionAdd)
-
-	// Open connection to Discord
-	err = dg.Open()
+	// Set up a translator for each configured model
+	extraHeaders, err := parseExtraHeaders(c.OpenAIExtraHeaders)
 	if err != nil {
-		log.Fatal("Error opening connection:", err)
+		log.Fatal("Error reading OPENAI_EXTRA_HEADERS:", err)
+	}
+	var spend *spendTracker
+	if c.MonthlyBudget > 0 {
+		spend = newSpendTracker(c.CostPer1KTokens, c.MonthlyBudget, c.CostWarnThreshold, budgetWarning(dg, c.LogChannels))
+	}
+	debug := debugMirror(dg, c.DebugChannelID, c.DebugContent, []string{c.OpenAIToken, c.DiscordToken})
+	semaphores := make(map[string]semaphore)
+	newTranslator := func(model string) *OpenAITranslator {
+		t := NewOpenAITranslator(c.OpenAIToken, model)
+		sem, ok := semaphores[model]
+		if !ok {
+			limit := c.DefaultConcurrency
+			if n, set := c.ProviderConcurrency[model]; set {
+				limit = n
+			}
+			sem = newSemaphore(limit)
+			semaphores[model] = sem
+		}
+		t.sem = sem
+		t.baseURL = c.OpenAIBaseURL
+		if spend != nil {
+			t.onUsage = spend.recordUsage
+		}
+		t.extraHeaders = extraHeaders
+		t.overrideHeaders = c.OpenAIExtraHeadersOverride
+		t.retryEmpty = c.RetryEmpty
+		t.onExchange = debug
+		if c.CompressRequests {
+			t.compressAbove = max(c.CompressThreshold, 1)
+		}
+		return t
+	}
+	var translators []*OpenAITranslator
+	for _, model := range c.OpenAIModels {
+		translators = append(translators, newTranslator(model))
+	}
+	if len(translators) == 0 {
+		log.Fatal("OPENAI_MODELS must list at least one model")
 	}
-	defer dg.Close()
-
-	fmt.Println("Bot is running. Press CTRL-C to exit.")
-	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM)
-	<-sc
-}
-
-func translateWithOpenAI(text, targetLang, openAIToken string) (string, error) {
-	log.Printf("Translating text: %s", text)
-	log.Printf("Target language: %s", targetLang)
-	prompt := fmt.Sprintf("Translate the following text to %s. Only respond with the translation, nothing else: %s", targetLang, text)
 
-	requestBody := OpenAIRequest{
-		// Model: "gpt-4o-mini",
-		Model: "gpt-3.5-turbo",
-		Messages: []Message{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
+	// Make sure translations work before going online
+	if err := selfTest(context.Background(), translators[0], c.StrictStartup); err != nil {
+		log.Fatal("Startup self-test failed:", err)
 	}
 
-	jsonData, err := json.Marshal(requestBody)
+	// Register reaction and command handlers
+	handler := &DiscordHandler{
+		config:     &c,
+		completer:  translators[0],
+		posted:     newPostedTranslations(),
+		routes:     make(map[string]Translator),
+		events:     noopSink{},
+		messageCap: newMessageCap(c.MaxTranslationsPerMessage, c.MessageCapWindow),
+		limiter:    newRateLimiter(c.UserRateLimitPerMinute, c.GuildRateLimitPerMinute),
+		notices:    newNoticeThrottle(),
+	}
+	handler.postProcess, err = newPostChain(c.PostProcessors)
 	if err != nil {
-		return "", fmt.Errorf("error marshaling request: %v", err)
+		log.Fatal("Error reading POST_PROCESSORS:", err)
 	}
-
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	if c.UnreliablePolicy != unreliableWarn && c.UnreliablePolicy != unreliableBlock {
+		log.Fatal("UNRELIABLE_POLICY must be warn or block")
+	}
+	if c.MassMentions != massMentionsEscape && c.MassMentions != massMentionsRemove {
+		log.Fatal("MASS_MENTIONS must be escape or remove")
+	}
+	if c.TruncateWithLink && (c.TruncateLength < 2 || c.TruncateLength > maxDescriptionLength) {
+		log.Fatalf("TRUNCATE_LENGTH must be between 2 and %d", maxDescriptionLength)
+	}
+	if c.Pagination {
+		handler.pages = newPaginator(c.PageWraparound)
+	}
+	if c.Cache {
+		handler.cache = newTranslationCache(c.CacheTTL, c.CacheSize, c.CacheNormalization, c.CacheHomoglyphs)
+	}
+	if c.MessageCache {
+		handler.messages = newMessageCache(c.CacheTTL, c.CacheSize)
+	}
+	if c.SerializePerMessage {
+		handler.messageLocks = newKeyedMutex()
+	}
+	handler.registers, err = parseLanguageRegisters(c.LanguageRegister)
 	if err != nil {
-		return "", fmt.Errorf("error creating request: %v", err)
+		log.Fatal("Error reading LANGUAGE_REGISTER:", err)
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+openAIToken)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	if c.PopularThreshold > 0 {
+		if c.PopularAction != popularPin && c.PopularAction != popularHighlight {
+			log.Fatal("POPULAR_ACTION must be pin or highlight")
+		}
+		handler.demand = newDemandTracker(c.PopularThreshold)
+	}
+	if c.HistorySize > 0 {
+		handler.history = newTranslationHistory(c.HistorySize, len(c.DigestChannels) > 0)
+	}
+	if c.AbuseThrottleScore > 0 || c.AbuseRefuseScore > 0 {
+		handler.abuse = newAbuseGuard(c.AbuseThrottleScore, c.AbuseRefuseScore, c.AbuseWindow)
+	}
+	handler.keys, err = newGuildKeys(c.GuildKeysFile, c.GuildKeysSecret)
 	if err != nil {
-		return "", fmt.Errorf("error making request: %v", err)
+		log.Fatal("Error loading guild keys:", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	handler.guildConfigs, err = newGuildConfigs(c.GuildConfigFile)
+	if err != nil {
+		log.Fatal("Error loading guild configs:", err)
+	}
+	if c.ConversationSize > 0 {
+		handler.conversations = newConversationBuffer(c.ConversationSize, c.ConversationTTL)
+	}
+	for _, t := range translators {
+		handler.translators = append(handler.translators, t)
+	}
+	if c.FallbackModel != "" {
+		handler.translators[0] = &adaptiveTranslator{
+			primary:        translators[0],
+			fallback:       newTranslator(c.FallbackModel),
+			limitThreshold: c.DowngradeAfter,
+			limitWindow:    c.DowngradeWindow,
+			cooldown:       c.DowngradeCooldown,
+			restoreAfter:   c.RestoreAfter,
+		}
+	}
+	if c.EscalationModel != "" {
+		handler.escalation = newTranslator(c.EscalationModel)
+	}
+	for lang, model := range c.LanguageRoutes {
+		handler.routes[strings.ToLower(lang)] = newTranslator(model)
 	}
 
-	var response OpenAIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("error decoding response: %v", err)
+	// Flags always trigger translations; the number menu is optional
+	handler.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+	if c.NumberMenu && len(c.NumberLanguages) > 0 {
+		numbers, err := numberTrigger(c.NumberLanguages)
+		if err != nil {
+			log.Fatal("Error reading NUMBER_LANGUAGES:", err)
+		}
+		handler.triggers = append(handler.triggers, numbers)
+	}
+	dg.AddHandler(handler.reactionAdd)
+	dg.AddHandler(handler.reactionRemove)
+	dg.AddHandler(handler.interactionCreate)
+	if c.MessageCache {
+		dg.AddHandler(handler.messageUpdate)
+	}
+	if c.TextCommands {
+		dg.Identify.Intents |= discordgo.IntentsMessageContent
+		dg.AddHandler(handler.messageCreate)
+	}
+	if c.QuietHours != "" {
+		if _, err := parseQuietHours(c.QuietHours, c.QuietTimezone); err != nil {
+			log.Fatal("Error reading QUIET_HOURS:", err)
+		}
+	}
+	if len(c.MirrorChannels) > 0 {
+		handler.mirrors, err = parseMirrors(c.MirrorChannels)
+		if err != nil {
+			log.Fatal("Error reading MIRROR_CHANNELS:", err)
+		}
+		handler.mirrorHooks = &mirrorWebhooks{webhooks: make(map[string]*discordgo.Webhook)}
+		dg.Identify.Intents |= discordgo.IntentsMessageContent
+		dg.AddHandler(handler.mirrorMessage)
 	}
 
-	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("no translation returned")
+	// Background components are started in order and stopped in reverse
+	lifecycle := newLifecycle(c.ShutdownTimeout)
+	handler.ctx = lifecycle.Context()
+	lifecycle.Add("Discord session", func(ctx context.Context) error {
+		if err := dg.Open(); err != nil {
+			return err
+		}
+		handler.ready.Store(true)
+		return nil
+	}, func(ctx context.Context) error {
+		return dg.Close()
+	})
+	// Slash commands are registered globally once the session is open. A
+	// failure closes the session again before exiting.
+	lifecycle.Add("slash commands", func(ctx context.Context) error {
+		_, err := dg.ApplicationCommandBulkOverwrite(dg.State.User.ID, "", c.enabledCommands())
+		return err
+	}, nil)
+	if c.EventWebhookURL != "" {
+		sink := newHTTPSink(c.EventWebhookURL)
+		handler.events = sink
+		lifecycle.AddLoop("event sink", sink.run)
+	}
+	if c.GlossarySuggestInterval > 0 {
+		handler.terms = newTermTracker()
+		lifecycle.AddLoop("glossary suggestions", func(ctx context.Context) {
+			handler.runGlossarySuggestions(ctx, dg, c.GlossarySuggestInterval, c.GlossarySuggestMin)
+		})
+	}
+	if len(c.DigestChannels) > 0 {
+		if c.HistorySize <= 0 {
+			log.Fatal("DIGEST_CHANNELS needs HISTORY_SIZE")
+		}
+		minute, err := parseClock(c.DigestTime)
+		if err != nil {
+			log.Fatal("Error reading DIGEST_TIME:", err)
+		}
+		loc, err := time.LoadLocation(c.DigestTimezone)
+		if err != nil {
+			log.Fatal("Error reading DIGEST_TIMEZONE:", err)
+		}
+		lifecycle.AddLoop("translation digest", func(ctx context.Context) {
+			handler.runDigest(ctx, dg, minute, loc, c.DigestSize)
+		})
+	}
+	if c.LatencyLogInterval > 0 {
+		handler.latencies = newLatencyWindow(c.LatencyWindow)
+		lifecycle.AddLoop("latency log", func(ctx context.Context) {
+			handler.runLatencyLog(ctx, c.LatencyLogInterval)
+		})
 	}
+	if c.HealthCheck {
+		lifecycle.AddLoop("health check", func(ctx context.Context) {
+			handler.runHealthCheck(ctx, dg, c.HealthCheckInterval, c.HealthCheckFailures)
+		})
+	}
+	if err := lifecycle.Start(context.Background()); err != nil {
+		log.Fatal("Error starting up:", err)
+	}
+	defer func() {
+		if err := lifecycle.Shutdown(context.Background()); err != nil {
+			log.Printf("Error shutting down: %v", err)
+		}
+	}()
 
-	return response.Choices[0].Message.Content, nil
+	fmt.Println("Bot is running. Press CTRL-C to exit.")
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM)
+	<-sc
 }
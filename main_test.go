@@ -0,0 +1,792 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeRequest is one call the bot made to the Discord API
+type fakeRequest struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// fakeDiscord stands in for the Discord API. It records every request and
+// answers with the reply registered for the longest matching path suffix,
+// or an empty JSON object, and with the status registered the same way, or
+// 200 OK.
+type fakeDiscord struct {
+	mu       sync.Mutex
+	requests []fakeRequest
+	replies  map[string]string
+	statuses map[string]int
+}
+
+func (f *fakeDiscord) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, fakeRequest{Method: req.Method, Path: req.URL.Path, Body: string(body)})
+
+	reply, match := "{}", ""
+	for suffix, r := range f.replies {
+		if strings.HasSuffix(req.URL.Path, suffix) && len(suffix) > len(match) {
+			reply, match = r, suffix
+		}
+	}
+	status, match := http.StatusOK, ""
+	for suffix, code := range f.statuses {
+		if strings.HasSuffix(req.URL.Path, suffix) && len(suffix) > len(match) {
+			status, match = code, suffix
+		}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(reply)),
+		Request:    req,
+	}, nil
+}
+
+// sent returns the requests whose path ends in suffix
+func (f *fakeDiscord) sent(suffix string) []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var found []fakeRequest
+	for _, r := range f.requests {
+		if strings.HasSuffix(r.Path, suffix) {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+// newTestSession returns a session whose REST calls go to a fakeDiscord.
+// DM channels open as "dm".
+func newTestSession(t *testing.T) (*discordgo.Session, *fakeDiscord) {
+	t.Helper()
+	fake := &fakeDiscord{replies: map[string]string{
+		"/users/@me/channels": `{"id":"dm","type":1}`,
+	}}
+	s, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Client = &http.Client{Transport: fake}
+	s.State.User = &discordgo.User{ID: "bot"}
+	return s, fake
+}
+
+// fakeTranslator answers every request with reply, or by prefixing the
+// text with "translated: ", and records its calls
+type fakeTranslator struct {
+	mu    sync.Mutex
+	reply func(req TranslateRequest) (string, error)
+	calls []TranslateRequest
+}
+
+func (f *fakeTranslator) Name() string { return "fake" }
+
+func (f *fakeTranslator) Translate(ctx context.Context, req TranslateRequest) (TranslateResult, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, req)
+	f.mu.Unlock()
+	text := "translated: " + req.Text
+	if f.reply != nil {
+		var err error
+		if text, err = f.reply(req); err != nil {
+			return TranslateResult{}, err
+		}
+	}
+	return TranslateResult{Text: text, Provider: f.Name()}, nil
+}
+
+// count returns how many requests the translator got
+func (f *fakeTranslator) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.calls)
+}
+
+// fakeCompleter answers every prompt with reply and records the prompts
+type fakeCompleter struct {
+	mu      sync.Mutex
+	reply   string
+	prompts []string
+}
+
+func (f *fakeCompleter) Complete(ctx context.Context, prompt string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.prompts = append(f.prompts, prompt)
+	return f.reply, nil
+}
+
+func (f *fakeCompleter) CompleteJSON(ctx context.Context, prompt string) (string, error) {
+	return f.Complete(ctx, prompt)
+}
+
+// count returns how many prompts the completer got
+func (f *fakeCompleter) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.prompts)
+}
+
+// testMessage is a message by "author" in channel c1 of guild g1
+func testMessage(content string) *discordgo.Message {
+	return &discordgo.Message{
+		ID:        "m1",
+		ChannelID: "c1",
+		GuildID:   "g1",
+		Content:   content,
+		Author:    &discordgo.User{ID: "author", Username: "author"},
+	}
+}
+
+// testReaction is userID reacting to testMessage with emoji
+func testReaction(userID, emoji string) *discordgo.MessageReactionAdd {
+	return &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+		UserID:    userID,
+		MessageID: "m1",
+		ChannelID: "c1",
+		GuildID:   "g1",
+		Emoji:     discordgo.Emoji{Name: emoji},
+	}}
+}
+
+// newTestHandler builds a handler the way main does, with only what c
+// turns on, translating with translator
+func newTestHandler(t *testing.T, c Config, translator Translator) *DiscordHandler {
+	t.Helper()
+	if c.MassMentions == "" {
+		c.MassMentions = massMentionsEscape
+	}
+	h := &DiscordHandler{
+		config:      &c,
+		translators: []Translator{translator},
+		routes:      make(map[string]Translator),
+		posted:      newPostedTranslations(),
+		events:      noopSink{},
+		messageCap:  newMessageCap(c.MaxTranslationsPerMessage, c.MessageCapWindow),
+		limiter:     newRateLimiter(c.UserRateLimitPerMinute, c.GuildRateLimitPerMinute),
+		notices:     newNoticeThrottle(),
+		ctx:         context.Background(),
+	}
+	var err error
+	h.guildConfigs, err = newGuildConfigs("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// commandInteraction is a slash command run by user in guild g1 with
+// string options
+func commandInteraction(name, userID string, options map[string]string) *discordgo.InteractionCreate {
+	data := discordgo.ApplicationCommandInteractionData{Name: name}
+	for key, value := range options {
+		data.Options = append(data.Options, &discordgo.ApplicationCommandInteractionDataOption{
+			Name:  key,
+			Type:  discordgo.ApplicationCommandOptionString,
+			Value: value,
+		})
+	}
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "interaction",
+		AppID:     "app",
+		Token:     "token",
+		Type:      discordgo.InteractionApplicationCommand,
+		GuildID:   "g1",
+		ChannelID: "c1",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: userID}},
+		Data:      data,
+	}}
+}
+
+// ephemeralReplies returns the content of every ephemeral interaction reply
+func ephemeralReplies(t *testing.T, fake *fakeDiscord) []string {
+	t.Helper()
+	var replies []string
+	for _, r := range fake.sent("/callback") {
+		var response discordgo.InteractionResponse
+		if err := json.Unmarshal([]byte(r.Body), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Data != nil && response.Data.Flags&discordgo.MessageFlagsEphemeral != 0 && response.Data.Content != "" {
+			replies = append(replies, response.Data.Content)
+		}
+	}
+	return replies
+}
+
+func TestAdmitCommandRateLimited(t *testing.T) {
+	s, fake := newTestSession(t)
+	translator := &fakeTranslator{}
+	h := newTestHandler(t, Config{UserRateLimitPerMinute: 1}, translator)
+	compare := commandInteraction("compare", "u1", map[string]string{"text": "hello", "language": "French"})
+
+	h.compareCommand(s, compare)
+	h.compareCommand(s, compare)
+
+	if n := translator.count(); n != 1 {
+		t.Errorf("translator called %d times, want 1", n)
+	}
+	replies := ephemeralReplies(t, fake)
+	if len(replies) != 1 || replies[0] != rateLimitNotice(tierUser) {
+		t.Errorf("ephemeral replies = %q, want the user rate limit notice", replies)
+	}
+}
+
+func TestAdmit(t *testing.T) {
+	capped := Config{MaxTranslationsPerMessage: 1, MessageCapWindow: time.Hour}
+	tests := []struct {
+		name        string
+		config      Config
+		maintenance bool
+		messageID   string
+		// Who makes each request, in order; the last one is checked
+		users []string
+		want  string
+	}{
+		{name: "allowed", users: []string{"u1"}, want: ""},
+		{name: "maintenance", maintenance: true, users: []string{"u1"}, want: noticeMaintenance},
+		{name: "gated", config: Config{RequiredRoleID: "r1"}, users: []string{"u1"}, want: noticeGated},
+		{name: "user limit", config: Config{UserRateLimitPerMinute: 1}, users: []string{"u1", "u1"}, want: noticeRateLimited},
+		{name: "guild limit", config: Config{GuildRateLimitPerMinute: 1}, users: []string{"u1", "u2"}, want: noticeRateLimited},
+		{name: "message cap", config: capped, messageID: "m1", users: []string{"u1", "u2"}, want: noticeMessageCap},
+		{name: "no cap without a message", config: capped, users: []string{"u1", "u2"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, tt.config, &fakeTranslator{})
+			h.maintenance.set(tt.maintenance, "")
+			var got string
+			for _, userID := range tt.users {
+				got, _ = h.admit(userID, "g1", &discordgo.Member{}, tt.messageID)
+			}
+			if got != tt.want {
+				t.Errorf("admit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdmitCommandGated(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		roles  []string
+		userID string
+		want   bool
+	}{
+		{"role present", Config{RequiredRoleID: "r1"}, []string{"r1"}, "1", true},
+		{"role absent", Config{RequiredRoleID: "r1"}, nil, "1", false},
+		{"account too young", Config{MinAccountAge: time.Hour}, nil, snowflakeAt(time.Now()), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, tt.config, translator)
+			explain := commandInteraction("explain", tt.userID, map[string]string{"text": "hello", "language": "French"})
+			explain.Member.Roles = tt.roles
+
+			if got := h.admitCommand(s, explain); got != tt.want {
+				t.Errorf("admitCommand() = %v, want %v", got, tt.want)
+			}
+			replies := ephemeralReplies(t, fake)
+			if !tt.want && (len(replies) != 1 || replies[0] != gatedNotice) {
+				t.Errorf("ephemeral replies = %q, want the gated notice", replies)
+			}
+		})
+	}
+}
+
+// sentMessages decodes the messages posted to channelID
+func sentMessages(t *testing.T, fake *fakeDiscord, channelID string) []discordgo.MessageSend {
+	t.Helper()
+	var messages []discordgo.MessageSend
+	for _, r := range fake.sent("/channels/" + channelID + "/messages") {
+		var send discordgo.MessageSend
+		if err := json.Unmarshal([]byte(r.Body), &send); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, send)
+	}
+	return messages
+}
+
+func TestPostReactionEmbed(t *testing.T) {
+	const jump = "[Jump to message](https://discord.com/channels/g1/c1/m1)"
+	tests := []struct {
+		name        string
+		config      Config
+		wantChannel string
+		wantReply   bool
+		wantJump    bool
+	}{
+		{name: "reply in place", wantChannel: "c1", wantReply: true},
+		{name: "translations channel", config: Config{TranslationChannels: map[string]string{"g1": "t1"}}, wantChannel: "t1", wantJump: true},
+		{name: "ephemeral only", config: Config{EphemeralOnlyGuilds: []string{"g1"}}, wantChannel: "dm", wantJump: true},
+		{
+			name:        "ephemeral only wins over the translations channel",
+			config:      Config{EphemeralOnlyGuilds: []string{"g1"}, TranslationChannels: map[string]string{"g1": "t1"}},
+			wantChannel: "dm",
+			wantJump:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			h := newTestHandler(t, tt.config, &fakeTranslator{})
+			embed := &discordgo.MessageEmbed{Description: "bonjour"}
+
+			if _, _, err := h.postReactionEmbed(s, testReaction("u1", "🇫🇷"), testMessage("hello"), embed); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, channelID := range []string{"c1", "t1", "dm"} {
+				messages := sentMessages(t, fake, channelID)
+				if channelID != tt.wantChannel {
+					if len(messages) != 0 {
+						t.Errorf("posted %d messages to %s, want none", len(messages), channelID)
+					}
+					continue
+				}
+				if len(messages) != 1 {
+					t.Fatalf("posted %d messages to %s, want 1", len(messages), channelID)
+				}
+				sent := messages[0]
+				if got := sent.Reference != nil; got != tt.wantReply {
+					t.Errorf("reply = %v, want %v", got, tt.wantReply)
+				}
+				gotJump := len(sent.Embeds[0].Fields) == 1 && sent.Embeds[0].Fields[0].Value == jump
+				if gotJump != tt.wantJump {
+					t.Errorf("fields = %v, want jump link %v", sent.Embeds[0].Fields, tt.wantJump)
+				}
+			}
+		})
+	}
+}
+
+func TestEphemeralOnlyReactions(t *testing.T) {
+	tests := []struct {
+		name  string
+		react func(h *DiscordHandler, s *discordgo.Session)
+	}{
+		{"translate", func(h *DiscordHandler, s *discordgo.Session) {
+			h.translateReaction(s, testReaction("u1", "🇫🇷"), testMessage("hello"), "French")
+		}},
+		{"summarize", func(h *DiscordHandler, s *discordgo.Session) {
+			h.summarizeReaction(s, testReaction("u1", "📝"), testMessage("hello"))
+		}},
+		{"clarify", func(h *DiscordHandler, s *discordgo.Session) {
+			h.clarifyReaction(s, testReaction("u1", "🤔"), testMessage("hello"))
+		}},
+		{"describe", func(h *DiscordHandler, s *discordgo.Session) {
+			msg := testMessage("hello")
+			msg.Reactions = []*discordgo.MessageReactions{{Count: 2, Emoji: &discordgo.Emoji{Name: "👍"}}}
+			h.describeReactions(s, testReaction("u1", "👀"), msg)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			h := newTestHandler(t, Config{EphemeralOnlyGuilds: []string{"g1"}, DescribeReactionsEmoji: "👀", SummaryLang: "English"}, &fakeTranslator{})
+			h.completer = &fakeCompleter{reply: "- a point"}
+
+			tt.react(h, s)
+
+			if posted := sentMessages(t, fake, "c1"); len(posted) != 0 {
+				t.Errorf("posted %d messages publicly", len(posted))
+			}
+			if dms := sentMessages(t, fake, "dm"); len(dms) != 1 || len(dms[0].Embeds) != 1 {
+				t.Errorf("sent %d DMs, want 1 with an embed", len(dms))
+			}
+		})
+	}
+}
+
+func TestReactionRouting(t *testing.T) {
+	tests := []struct {
+		name          string
+		author        string
+		emoji         string
+		wantFetch     bool
+		wantTranslate bool
+		wantPin       bool
+	}{
+		{name: "flag on a user message translates", author: "author", emoji: "🇫🇷", wantFetch: true, wantTranslate: true},
+		{name: "flag on a translation is ignored", author: "bot", emoji: "🇫🇷", wantFetch: true},
+		{name: "control on a translation acts", author: "bot", emoji: pinEmoji, wantFetch: true, wantPin: true},
+		{name: "control on a user message is ignored", author: "author", emoji: pinEmoji, wantFetch: true},
+		{name: "other emoji on a user message", author: "author", emoji: "👍"},
+		{name: "other emoji on a translation", author: "bot", emoji: "👍"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			addPinState(t, s)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"` + tt.author + `"}}`
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{}, translator)
+			h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+
+			h.reactionAdd(s, testReaction("mod", tt.emoji))
+
+			fetched := false
+			for _, r := range fake.sent("/channels/c1/messages/m1") {
+				fetched = fetched || r.Method == http.MethodGet
+			}
+			if fetched != tt.wantFetch {
+				t.Errorf("fetched the message = %v, want %v", fetched, tt.wantFetch)
+			}
+			if translated := translator.count() > 0; translated != tt.wantTranslate {
+				t.Errorf("translated = %v, want %v", translated, tt.wantTranslate)
+			}
+			if pinned := len(fake.sent("/pins/m1")) > 0; pinned != tt.wantPin {
+				t.Errorf("pinned = %v, want %v", pinned, tt.wantPin)
+			}
+		})
+	}
+}
+
+func TestFlagLanguages(t *testing.T) {
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{"🇵🇭", "Filipino"},
+		{"🇻🇳", "Vietnamese"},
+		{"🇹🇭", "Thai"},
+		{"🇸🇦", "Arabic"},
+		{"🇪🇬", "Arabic"},
+		{"🇮🇳", "Hindi"},
+		{"🇮🇩", "Indonesian"},
+		{"🇲🇾", "Malay"},
+		{"🇳🇱", "Dutch"},
+		{"🇵🇱", "Polish"},
+		{"🇹🇷", "Turkish"},
+		{"🇺🇦", "Ukrainian"},
+		{"🇬🇷", "Greek"},
+		{"🇸🇪", "Swedish"},
+		{"🇮🇱", "Hebrew"},
+		{"🇮🇷", "Persian"},
+	}
+	trigger := emojiTrigger(flagToLang)
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got, ok := trigger.Language(tt.flag); !ok || got != tt.want {
+				t.Errorf("Language(%s) = %q, %v, want %q", tt.flag, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrientText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		lang string
+		want string
+	}{
+		{name: "left to right unchanged", text: "Hola\n2 amigos", lang: "Spanish", want: "Hola\n2 amigos"},
+		{name: "right to left marked", text: "مرحبا", lang: "Arabic", want: "\u200fمرحبا"},
+		{name: "every line marked", text: "שלום\n3 חברים", lang: "hebrew", want: "\u200fשלום\n\u200f3 חברים"},
+		{name: "blank lines left alone", text: "سلام\n\nhttps://example.com", lang: "Persian", want: "\u200fسلام\n\n\u200fhttps://example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := orientText(tt.text, tt.lang)
+			if got != tt.want {
+				t.Errorf("orientText() = %q, want %q", got, tt.want)
+			}
+			// The marks are the only change; the text itself is intact
+			if stripped := strings.ReplaceAll(got, rtlMark, ""); stripped != tt.text {
+				t.Errorf("orientText() without marks = %q, want %q", stripped, tt.text)
+			}
+		})
+	}
+}
+
+func TestRightToLeftTranslation(t *testing.T) {
+	s, fake := newTestSession(t)
+	fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"welcome, 3 friends","author":{"id":"author"}}`
+	translator := &fakeTranslator{reply: func(TranslateRequest) (string, error) { return "أهلا، 3 أصدقاء", nil }}
+	h := newTestHandler(t, Config{}, translator)
+	h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+
+	h.reactionAdd(s, testReaction("user", "🇸🇦"))
+
+	messages := sentMessages(t, fake, "c1")
+	if len(messages) != 1 || len(messages[0].Embeds) != 1 {
+		t.Fatalf("sent %v, want one translation embed", messages)
+	}
+	if got, want := messages[0].Embeds[0].Description, "\u200fأهلا، 3 أصدقاء"; got != want {
+		t.Errorf("description = %q, want %q", got, want)
+	}
+}
+
+func TestAddOriginalField(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		want     string
+	}{
+		{name: "short", original: "hello there", want: "hello there"},
+		{name: "at the limit", original: strings.Repeat("a", maxFieldValueLength), want: strings.Repeat("a", maxFieldValueLength)},
+		{
+			name:     "long cut with an ellipsis",
+			original: strings.Repeat("a", maxFieldValueLength+10),
+			want:     strings.Repeat("a", maxFieldValueLength-1) + "…",
+		},
+		{
+			name:     "counted in characters",
+			original: strings.Repeat("é", maxFieldValueLength+1),
+			want:     strings.Repeat("é", maxFieldValueLength-1) + "…",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embed := translationEmbed(testMessage(tt.original), "translation", "French")
+			addOriginalField(embed, tt.original)
+			if len(embed.Fields) != 1 || embed.Fields[0].Name != "Original" {
+				t.Fatalf("fields = %+v, want one Original field", embed.Fields)
+			}
+			if got := embed.Fields[0].Value; got != tt.want {
+				t.Errorf("original = %q, want %q", got, tt.want)
+			}
+			if embed.Description != "translation" {
+				t.Errorf("description = %q, want the translation", embed.Description)
+			}
+		})
+	}
+}
+
+func TestShowOriginal(t *testing.T) {
+	tests := []struct {
+		name string
+		show bool
+	}{
+		{name: "off", show: false},
+		{name: "on", show: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"author"}}`
+			h := newTestHandler(t, Config{ShowOriginal: tt.show}, &fakeTranslator{})
+			h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+
+			h.reactionAdd(s, testReaction("user", "🇫🇷"))
+
+			messages := sentMessages(t, fake, "c1")
+			if len(messages) != 1 || len(messages[0].Embeds) != 1 {
+				t.Fatalf("sent %v, want one translation embed", messages)
+			}
+			embed := messages[0].Embeds[0]
+			shown := len(embed.Fields) == 1 && embed.Fields[0].Name == "Original" && embed.Fields[0].Value == "hello"
+			if shown != tt.show {
+				t.Errorf("fields = %+v, want the original shown = %v", embed.Fields, tt.show)
+			}
+			if embed.Description != "translated: hello" {
+				t.Errorf("description = %q, want the translation", embed.Description)
+			}
+		})
+	}
+}
+
+func TestIsMarkedTranslation(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *discordgo.Message
+		want bool
+	}{
+		{name: "plain message", msg: testMessage("hello")},
+		{name: "our translation", msg: &discordgo.Message{Embeds: []*discordgo.MessageEmbed{translationEmbed(testMessage("hello"), "bonjour", "French")}}, want: true},
+		{name: "other embed", msg: &discordgo.Message{Embeds: []*discordgo.MessageEmbed{{Footer: &discordgo.MessageEmbedFooter{Text: "Translated to French"}}}}},
+		{name: "embed without footer", msg: &discordgo.Message{Embeds: []*discordgo.MessageEmbed{{Description: "hi"}}}},
+		{name: "marker in a later embed", msg: &discordgo.Message{Embeds: []*discordgo.MessageEmbed{
+			{Description: "link preview"},
+			{Footer: &discordgo.MessageEmbedFooter{Text: "Explained in English" + translationMarker}},
+		}}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMarkedTranslation(tt.msg); got != tt.want {
+				t.Errorf("isMarkedTranslation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslationOfTranslationRefused(t *testing.T) {
+	marked := `"embeds":[{"description":"bonjour","footer":{"text":"Translated to French` + translationMarker + `"}}]`
+	tests := []struct {
+		name          string
+		message       string
+		wantTranslate bool
+	}{
+		{name: "normal message translates", message: `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"author"}}`, wantTranslate: true},
+		{name: "another instance's translation", message: `{"id":"m1","channel_id":"c1","author":{"id":"other-bot"},` + marked + `}`},
+		{name: "forwarded translation", message: `{"id":"m1","channel_id":"c1","author":{"id":"author"},"message_snapshots":[{"message":{` + marked + `}}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = tt.message
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{}, translator)
+			h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+
+			h.reactionAdd(s, testReaction("user", "🇪🇸"))
+
+			if translated := translator.count() > 0; translated != tt.wantTranslate {
+				t.Fatalf("translated = %v, want %v", translated, tt.wantTranslate)
+			}
+			notices := sentMessages(t, fake, "dm")
+			if tt.wantTranslate {
+				if len(notices) != 0 {
+					t.Errorf("sent %d notices, want none", len(notices))
+				}
+				return
+			}
+			if len(notices) != 1 || !strings.Contains(notices[0].Content, "already a translation") {
+				t.Errorf("notices = %+v, want one saying it's already a translation", notices)
+			}
+		})
+	}
+}
+
+func TestAddDisclaimer(t *testing.T) {
+	long := strings.Repeat("x", maxFooterLength)
+	tests := []struct {
+		name       string
+		disclaimer string
+		want       string
+	}{
+		{name: "without a disclaimer", want: "Translated to French"},
+		{name: "with a disclaimer", disclaimer: "Automated translation, may contain errors.", want: "Translated to French • Automated translation, may contain errors."},
+		{name: "too long", disclaimer: long},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embed := translationEmbed(testMessage("bonjour"), "hello", "French")
+			addDisclaimer(embed, tt.disclaimer)
+
+			footer := embed.Footer.Text
+			if !strings.HasSuffix(footer, translationMarker) {
+				t.Errorf("footer = %q, want it to keep the translation marker", footer)
+			}
+			if l := len([]rune(footer)) + pageNumberRoom; l > maxFooterLength {
+				t.Errorf("footer is %d characters with the page number, over %d", l, maxFooterLength)
+			}
+			if tt.want != "" {
+				if got := strings.TrimSuffix(footer, translationMarker); got != tt.want {
+					t.Errorf("footer = %q, want %q", got, tt.want)
+				}
+			} else if !strings.HasPrefix(footer, "Translated to French • xxx") || !strings.Contains(footer, "…") {
+				t.Errorf("footer = %q, want the disclaimer cut short", footer)
+			}
+		})
+	}
+}
+
+func TestDisclaimerPerGuild(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   string
+		guild string
+		want  string
+	}{
+		{name: "none", want: "Translated to French"},
+		{name: "from the environment", env: "Machine translated", want: "Translated to French • Machine translated"},
+		{name: "guild override", env: "Machine translated", guild: "Check with a human", want: "Translated to French • Check with a human"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"author"}}`
+			h := newTestHandler(t, Config{DisclaimerText: tt.env}, &fakeTranslator{})
+			h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+			if err := h.guildConfigs.set("g1", GuildConfig{Version: guildConfigVersion, Disclaimer: tt.guild}); err != nil {
+				t.Fatal(err)
+			}
+
+			h.reactionAdd(s, testReaction("user", "🇫🇷"))
+
+			messages := sentMessages(t, fake, "c1")
+			if len(messages) != 1 || len(messages[0].Embeds) != 1 {
+				t.Fatalf("sent %v, want one translation embed", messages)
+			}
+			if got := strings.TrimSuffix(messages[0].Embeds[0].Footer.Text, translationMarker); got != tt.want {
+				t.Errorf("footer = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageLink(t *testing.T) {
+	tests := []struct {
+		guildID, channelID, messageID string
+		want                          string
+	}{
+		{"g1", "c1", "m1", "https://discord.com/channels/g1/c1/m1"},
+		{"@me", "dm", "m2", "https://discord.com/channels/@me/dm/m2"},
+	}
+	for _, tt := range tests {
+		if got := messageLink(tt.guildID, tt.channelID, tt.messageID); got != tt.want {
+			t.Errorf("messageLink(%s, %s, %s) = %q, want %q", tt.guildID, tt.channelID, tt.messageID, got, tt.want)
+		}
+	}
+}
+
+func TestTranslationChannelRouting(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		guild       string
+		wantChannel string
+	}{
+		{name: "in place", wantChannel: "c1"},
+		{name: "from the environment", env: map[string]string{"g1": "t1"}, wantChannel: "t1"},
+		{name: "guild config wins", env: map[string]string{"g1": "t1"}, guild: "t2", wantChannel: "t2"},
+		{name: "other guild's channel", env: map[string]string{"g2": "t1"}, wantChannel: "c1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"author"}}`
+			h := newTestHandler(t, Config{TranslationChannels: tt.env}, &fakeTranslator{})
+			h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+			if err := h.guildConfigs.set("g1", GuildConfig{Version: guildConfigVersion, TranslationChannel: tt.guild}); err != nil {
+				t.Fatal(err)
+			}
+
+			h.reactionAdd(s, testReaction("user", "🇫🇷"))
+
+			for _, channelID := range []string{"c1", "t1", "t2"} {
+				want := 0
+				if channelID == tt.wantChannel {
+					want = 1
+				}
+				if got := len(sentMessages(t, fake, channelID)); got != want {
+					t.Errorf("posted %d translations to %s, want %d", got, channelID, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"log"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const defaultMaintenanceMessage = "Translations are paused for maintenance. Please try again later."
+
+// maintenanceMode pauses all translations with a message for users
+type maintenanceMode struct {
+	mu      sync.RWMutex
+	on      bool
+	message string
+}
+
+func (m *maintenanceMode) set(on bool, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if message == "" {
+		message = defaultMaintenanceMessage
+	}
+	m.on = on
+	m.message = message
+}
+
+// active reports whether maintenance is on and what to tell users
+func (m *maintenanceMode) active() (bool, string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.on, m.message
+}
+
+// interactionUser returns who triggered an interaction, in a guild or a DM
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// inMaintenance tells the user about maintenance and reports whether the
+// command should stop here
+func (h *DiscordHandler) inMaintenance(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	on, message := h.maintenance.active()
+	if on {
+		respondEphemeral(s, i, message)
+	}
+	return on
+}
+
+func (h *DiscordHandler) maintenanceCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if h.config.OwnerID == "" || interactionUser(i).ID != h.config.OwnerID {
+		respondEphemeral(s, i, "Only the bot owner can change maintenance mode.")
+		return
+	}
+
+	opts := commandOptions(i)
+	on := opts["state"].StringValue() == "on"
+	var message string
+	if opt, ok := opts["message"]; ok {
+		message = opt.StringValue()
+	}
+	h.maintenance.set(on, message)
+
+	if on {
+		log.Printf("Maintenance mode enabled")
+		respondEphemeral(s, i, "Maintenance mode is on.")
+	} else {
+		log.Printf("Maintenance mode disabled")
+		respondEphemeral(s, i, "Maintenance mode is off.")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import "testing"
+
+func TestMaintenanceMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		on          bool
+		message     string
+		wantMessage string
+	}{
+		{"on with message", true, "Back at 5pm UTC.", "Back at 5pm UTC."},
+		{"on with default message", true, "", defaultMaintenanceMessage},
+		{"off", false, "", defaultMaintenanceMessage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m maintenanceMode
+			m.set(tt.on, tt.message)
+			on, message := m.active()
+			if on != tt.on || message != tt.wantMessage {
+				t.Errorf("active() = %v, %q, want %v, %q", on, message, tt.on, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestMaintenanceCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		userID    string
+		options   map[string]string
+		wantOn    bool
+		wantReply string
+	}{
+		{"owner turns on", "owner", map[string]string{"state": "on", "message": "Back soon"}, true, "Maintenance mode is on."},
+		{"owner turns off", "owner", map[string]string{"state": "off"}, false, "Maintenance mode is off."},
+		{"someone else", "u1", map[string]string{"state": "on"}, false, "Only the bot owner can change maintenance mode."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			h := newTestHandler(t, Config{OwnerID: "owner"}, &fakeTranslator{})
+			h.maintenanceCommand(s, commandInteraction("maintenance", tt.userID, tt.options))
+			if on, _ := h.maintenance.active(); on != tt.wantOn {
+				t.Errorf("maintenance on = %v, want %v", on, tt.wantOn)
+			}
+			if got := ephemeralReplies(t, fake); len(got) != 1 || got[0] != tt.wantReply {
+				t.Errorf("replies = %q, want %q", got, tt.wantReply)
+			}
+		})
+	}
+}
+
+func TestMaintenancePausesTranslations(t *testing.T) {
+	const message = "Back at 5pm UTC."
+	tests := []struct {
+		name string
+		on   bool
+	}{
+		{"on", true},
+		{"off", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{}, translator)
+			h.maintenance.set(tt.on, message)
+
+			h.translateReaction(s, testReaction("u1", "🇫🇷"), testMessage("hello"), "French")
+			h.compareCommand(s, commandInteraction("compare", "u2", map[string]string{"text": "hello", "language": "French"}))
+
+			wantCalls, wantNotices := 2, 0
+			if tt.on {
+				wantCalls, wantNotices = 0, 1
+			}
+			if translator.count() != wantCalls {
+				t.Errorf("translator called %d times, want %d", translator.count(), wantCalls)
+			}
+			dms := sentMessages(t, fake, "dm")
+			replies := ephemeralReplies(t, fake)
+			if len(dms) != wantNotices || len(replies) != wantNotices {
+				t.Fatalf("sent %d DMs and %d ephemeral replies, want %d of each", len(dms), len(replies), wantNotices)
+			}
+			if tt.on && (dms[0].Content != message || replies[0] != message) {
+				t.Errorf("notices = %q, %q, want %q", dms[0].Content, replies[0], message)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Mentions that ping a whole server or channel
+var massMentionPattern = regexp.MustCompile(`@(everyone|here)\b`)
+
+// How mass mentions are neutralized in translations
+const (
+	// Keep the text but break the mention with a zero-width space
+	massMentionsEscape = "escape"
+	// Drop the @ so only the word is left
+	massMentionsRemove = "remove"
+)
+
+// neutralizeMention makes one mass mention inert
+func neutralizeMention(mention, mode string) string {
+	if mode == massMentionsRemove {
+		return strings.TrimPrefix(mention, "@")
+	}
+	return "@\u200b" + strings.TrimPrefix(mention, "@")
+}
+
+// neutralizeMassMentions makes every @everyone and @here in text inert,
+// including ones the model wrote itself. Allowed mentions already stop
+// them pinging; this keeps a copied translation from pinging either.
+func neutralizeMassMentions(text, mode string) string {
+	return massMentionPattern.ReplaceAllStringFunc(text, func(m string) string {
+		return neutralizeMention(m, mode)
+	})
+}
+
+// massMentionRule keeps mass mentions away from the model and puts them
+// back neutralized
+func massMentionRule(mode string) tokenRule {
+	return tokenRule{
+		pattern: massMentionPattern,
+		split: func(m []string) []tokenPart {
+			return []tokenPart{keep(neutralizeMention(m[0], mode))}
+		},
+	}
+}
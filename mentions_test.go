@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestNeutralizeMassMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		mode string
+		want string
+	}{
+		{"everyone escaped", "hi @everyone", massMentionsEscape, "hi @\u200beveryone"},
+		{"here escaped", "@here look", massMentionsEscape, "@\u200bhere look"},
+		{"removed", "@everyone and @here", massMentionsRemove, "everyone and here"},
+		{"longer words left alone", "@everyones @hereafter", massMentionsEscape, "@everyones @hereafter"},
+		{"user mentions left alone", "<@123> said", massMentionsEscape, "<@123> said"},
+		{"already escaped", "@\u200beveryone", massMentionsEscape, "@\u200beveryone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := neutralizeMassMentions(tt.text, tt.mode); got != tt.want {
+				t.Errorf("neutralizeMassMentions(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// messageSnapshots holds the copies of the original message that Discord
+// attaches to a forwarded message. discordgo doesn't decode these yet.
+type messageSnapshots struct {
+	MessageSnapshots []struct {
+		Message struct {
+			Content string                    `json:"content"`
+			Embeds  []*discordgo.MessageEmbed `json:"embeds"`
+		} `json:"message"`
+	} `json:"message_snapshots"`
+}
+
+// fetchMessage gets a message like s.ChannelMessage, but for forwarded
+// messages it fills in the content and embeds of the forwarded original
+func fetchMessage(s *discordgo.Session, channelID, messageID string) (*discordgo.Message, error) {
+	var body []byte
+	err := retryRead("message fetch", func() error {
+		var err error
+		body, err = s.RequestWithBucketID("GET", discordgo.EndpointChannelMessage(channelID, messageID), nil, discordgo.EndpointChannelMessage(channelID, ""))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var msg discordgo.Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("error decoding message: %v", err)
+	}
+
+	var snapshots messageSnapshots
+	if err := json.Unmarshal(body, &snapshots); err != nil {
+		return nil, fmt.Errorf("error decoding message snapshots: %v", err)
+	}
+	applySnapshots(&msg, snapshots)
+
+	return &msg, nil
+}
+
+// applySnapshots copies a forwarded original's content and embeds onto msg
+func applySnapshots(msg *discordgo.Message, snapshots messageSnapshots) {
+	if len(snapshots.MessageSnapshots) == 0 || msg.Content != "" {
+		return
+	}
+	original := snapshots.MessageSnapshots[0].Message
+	msg.Content = original.Content
+	msg.Embeds = append(msg.Embeds, original.Embeds...)
+}
+
+// messageText returns the text to translate from a message, falling back
+// to its embeds' titles and descriptions when it has no content
+func messageText(msg *discordgo.Message) string {
+	if msg.Content != "" {
+		return msg.Content
+	}
+
+	var parts []string
+	for _, embed := range msg.Embeds {
+		if embed.Title != "" {
+			parts = append(parts, embed.Title)
+		}
+		if embed.Description != "" {
+			parts = append(parts, embed.Description)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// messageLink builds a jump link to a guild message
+func messageLink(guildID, channelID, messageID string) string {
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, messageID)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"log"
+	"maps"
+	"sync"
+)
+
+// DroppedReason is why a translation request was given up on
+type DroppedReason string
+
+const (
+	// Over the user's requests-per-minute limit
+	DroppedUserRateLimit DroppedReason = "user-rate-limit"
+	// Over the guild's requests-per-minute limit
+	DroppedGuildRateLimit DroppedReason = "guild-rate-limit"
+	// The message was already translated MAX_TRANSLATIONS_PER_MESSAGE times
+	DroppedMessageCap DroppedReason = "message-cap"
+	// No provider slot came free in time
+	DroppedBusy DroppedReason = "provider-busy"
+	// Still rate limited by the provider after MAX_QUEUE_DELAY
+	DroppedExpired DroppedReason = "queue-expired"
+)
+
+// MetricsSnapshot is a point-in-time copy of the bot's translation counters
+type MetricsSnapshot struct {
+	Translations int
+	Failures     int
+	CacheHits    int
+	CacheMisses  int
+	// Fraction of cache lookups that hit, 0 when there were none
+	CacheHitRate float64
+	// Successful translations per target language
+	PerLanguage map[string]int
+	// Requests given up on before a translation was made, by reason
+	Dropped map[DroppedReason]int
+	// Ready is false until the session opens and while it fails health
+	// checks
+	Ready bool
+}
+
+// metrics counts translations as they happen
+type metrics struct {
+	mu           sync.Mutex
+	translations int
+	failures     int
+	cacheHits    int
+	cacheMisses  int
+	perLanguage  map[string]int
+	dropped      map[DroppedReason]int
+}
+
+func (m *metrics) recordCacheLookup(hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if hit {
+		m.cacheHits++
+	} else {
+		m.cacheMisses++
+	}
+}
+
+func (m *metrics) recordTranslation(targetLang string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.failures++
+		return
+	}
+	m.translations++
+	if m.perLanguage == nil {
+		m.perLanguage = make(map[string]int)
+	}
+	m.perLanguage[targetLang]++
+}
+
+func (m *metrics) recordDropped(reason DroppedReason) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.dropped == nil {
+		m.dropped = make(map[DroppedReason]int)
+	}
+	m.dropped[reason]++
+}
+
+func (m *metrics) snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snap := MetricsSnapshot{
+		Translations: m.translations,
+		Failures:     m.failures,
+		CacheHits:    m.cacheHits,
+		CacheMisses:  m.cacheMisses,
+		PerLanguage:  maps.Clone(m.perLanguage),
+		Dropped:      maps.Clone(m.dropped),
+	}
+	if lookups := m.cacheHits + m.cacheMisses; lookups > 0 {
+		snap.CacheHitRate = float64(m.cacheHits) / float64(lookups)
+	}
+	return snap
+}
+
+// Metrics returns the current translation counters. It is safe to call
+// from any goroutine.
+func (h *DiscordHandler) Metrics() MetricsSnapshot {
+	snap := h.metrics.snapshot()
+	snap.Ready = h.ready.Load()
+	return snap
+}
+
+// recordDropped writes a request given up on to the log, the dead letters,
+// and counts it by reason
+func (h *DiscordHandler) recordDropped(reason DroppedReason, guildID, userID string) {
+	log.Printf("Dropped translation for user %s in guild %s: %s", userID, guildID, reason)
+	h.metrics.recordDropped(reason)
+}
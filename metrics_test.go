@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDropReasons(t *testing.T) {
+	busy := func(TranslateRequest) (string, error) {
+		return "", fmt.Errorf("error waiting for fake: %w", errBusy)
+	}
+	limited := func(TranslateRequest) (string, error) {
+		return "", &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Minute}
+	}
+	admitTwice := func(users ...string) func(*DiscordHandler, *discordgo.Session) {
+		return func(h *DiscordHandler, s *discordgo.Session) {
+			for _, user := range users {
+				h.admitByDM(s, user, "g1", nil, "m1")
+			}
+		}
+	}
+	deliver := func(h *DiscordHandler, s *discordgo.Session) {
+		h.deliverTranslation(s, testReaction("u1", "🇫🇷"), testMessage("hello"), "hello", nil, "French")
+	}
+	tests := []struct {
+		name   string
+		config Config
+		reply  func(TranslateRequest) (string, error)
+		run    func(*DiscordHandler, *discordgo.Session)
+		want   map[DroppedReason]int
+	}{
+		{
+			name:   "user rate limit",
+			config: Config{UserRateLimitPerMinute: 1},
+			run:    admitTwice("u1", "u1"),
+			want:   map[DroppedReason]int{DroppedUserRateLimit: 1},
+		},
+		{
+			name:   "guild rate limit",
+			config: Config{GuildRateLimitPerMinute: 1},
+			run:    admitTwice("u1", "u2"),
+			want:   map[DroppedReason]int{DroppedGuildRateLimit: 1},
+		},
+		{
+			name:   "message cap",
+			config: Config{MaxTranslationsPerMessage: 1, MessageCapWindow: time.Hour},
+			run:    admitTwice("u1", "u2"),
+			want:   map[DroppedReason]int{DroppedMessageCap: 1},
+		},
+		{
+			name:  "provider busy",
+			reply: busy,
+			run:   deliver,
+			want:  map[DroppedReason]int{DroppedBusy: 1},
+		},
+		{
+			name:   "queue expired",
+			config: Config{QueueRateLimited: true, MaxQueueDelay: 10 * time.Millisecond},
+			reply:  limited,
+			run:    deliver,
+			want:   map[DroppedReason]int{DroppedExpired: 1},
+		},
+		{
+			name: "translated",
+			run:  deliver,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestSession(t)
+			h := newTestHandler(t, tt.config, &fakeTranslator{reply: tt.reply})
+			tt.run(h, s)
+			if got := h.Metrics().Dropped; !maps.Equal(got, tt.want) {
+				t.Errorf("Dropped = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSemaphoreWaitIsBusy(t *testing.T) {
+	translator := NewOpenAITranslator("token", "model")
+	translator.sem = newSemaphore(1)
+	if err := translator.sem.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer translator.sem.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := translator.Translate(ctx, TranslateRequest{Text: "hello", TargetLang: "French"})
+	if !errors.Is(err, errBusy) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Translate() error = %v, want errBusy after the deadline", err)
+	}
+}
+
+func TestMetricsSnapshot(t *testing.T) {
+	errDown := errors.New("down")
+	var m metrics
+	m.recordCacheLookup(false)
+	m.recordTranslation("French", nil)
+	m.recordCacheLookup(true)
+	m.recordTranslation("French", nil)
+	m.recordCacheLookup(false)
+	m.recordTranslation("Spanish", errDown)
+	m.recordCacheLookup(false)
+	m.recordTranslation("Spanish", nil)
+
+	want := MetricsSnapshot{
+		Translations: 3,
+		Failures:     1,
+		CacheHits:    1,
+		CacheMisses:  3,
+		CacheHitRate: 0.25,
+		PerLanguage:  map[string]int{"French": 2, "Spanish": 1},
+	}
+	if got := m.snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMetricsSnapshotIsACopy(t *testing.T) {
+	var m metrics
+	m.recordTranslation("French", nil)
+	snap := m.snapshot()
+	snap.PerLanguage["French"] = 100
+	if got := m.snapshot().PerLanguage["French"]; got != 1 {
+		t.Errorf("French count = %d after changing a snapshot, want 1", got)
+	}
+	if rate := (&metrics{}).snapshot().CacheHitRate; rate != 0 {
+		t.Errorf("hit rate with no lookups = %v, want 0", rate)
+	}
+}
+
+func TestMetricsConcurrentTranslations(t *testing.T) {
+	h := newTestHandler(t, Config{}, &fakeTranslator{})
+	var wg sync.WaitGroup
+	for n := range 50 {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			lang := "French"
+			if n%2 == 1 {
+				lang = "Spanish"
+			}
+			if _, err := h.translateDetailed(context.Background(), "g1", "c1", "hello", lang, ""); err != nil {
+				t.Error(err)
+			}
+			h.Metrics()
+		}(n)
+	}
+	wg.Wait()
+
+	snap := h.Metrics()
+	if snap.Translations != 50 || snap.PerLanguage["French"] != 25 || snap.PerLanguage["Spanish"] != 25 {
+		t.Errorf("Metrics() = %+v, want 50 translations split evenly", snap)
+	}
+}
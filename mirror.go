@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Name of the webhooks mirrored messages are posted through
+const mirrorWebhookName = "Salin mirror"
+
+// mirrorRule copies every message in one channel, translated, to another
+type mirrorRule struct {
+	target string
+	lang   string
+}
+
+// parseMirrors reads MIRROR_CHANNELS, source channel ID to target/Language.
+// Rules that would mirror a channel back into itself, directly or through
+// other rules, are rejected.
+func parseMirrors(config map[string]string) (map[string]mirrorRule, error) {
+	rules := make(map[string]mirrorRule, len(config))
+	for source, value := range config {
+		target, lang, ok := strings.Cut(value, "/")
+		target, lang = strings.TrimSpace(target), strings.TrimSpace(lang)
+		if !ok || !snowflakePattern.MatchString(target) || lang == "" {
+			return nil, fmt.Errorf("mirror for %s must look like channelID/Language", source)
+		}
+		rules[source] = mirrorRule{target: target, lang: lang}
+	}
+	for source := range rules {
+		seen := map[string]bool{source: true}
+		for next, ok := rules[source]; ok; next, ok = rules[next.target] {
+			if seen[next.target] {
+				return nil, fmt.Errorf("mirror from %s loops back to %s", source, next.target)
+			}
+			seen[next.target] = true
+		}
+	}
+	return rules, nil
+}
+
+// mirrorWebhooks remembers the webhook used to post into each target
+// channel
+type mirrorWebhooks struct {
+	mu       sync.Mutex
+	webhooks map[string]*discordgo.Webhook
+}
+
+// get returns the bot's mirror webhook in a channel, creating it if needed
+func (w *mirrorWebhooks) get(s *discordgo.Session, channelID string) (*discordgo.Webhook, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if hook, ok := w.webhooks[channelID]; ok {
+		return hook, nil
+	}
+	hooks, err := s.ChannelWebhooks(channelID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhooks: %v", err)
+	}
+	var hook *discordgo.Webhook
+	for _, candidate := range hooks {
+		if candidate.Name == mirrorWebhookName && candidate.User != nil && candidate.User.ID == s.State.User.ID {
+			hook = candidate
+			break
+		}
+	}
+	if hook == nil {
+		hook, err = s.WebhookCreate(channelID, mirrorWebhookName, "")
+		if err != nil {
+			return nil, fmt.Errorf("error creating webhook: %v", err)
+		}
+	}
+	w.webhooks[channelID] = hook
+	return hook, nil
+}
+
+// forget drops a webhook that stopped working, e.g. because it was deleted
+func (w *mirrorWebhooks) forget(channelID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.webhooks, channelID)
+}
+
+// mirrorMessage posts a translation of each new message in a mirrored
+// channel to its target, under the original author's name and avatar.
+// Messages from bots and webhooks, including earlier mirrored ones, are
+// never mirrored, so rules can't feed each other.
+func (h *DiscordHandler) mirrorMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	rule, ok := h.mirrors[m.ChannelID]
+	if !ok || m.WebhookID != "" || m.Author == nil || m.Author.Bot {
+		return
+	}
+	text := messageText(m.Message)
+	if text == "" {
+		return
+	}
+	if on, _ := h.maintenance.active(); on || h.inQuietHours(m.GuildID, time.Now()) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	translation, err := h.translate(ctx, m.GuildID, m.ChannelID, text, rule.lang)
+	h.emitTranslation(m.GuildID, rule.lang, err)
+	if err != nil {
+		log.Printf("Error translating mirrored message: %v", err)
+		return
+	}
+
+	hook, err := h.mirrorHooks.get(s, rule.target)
+	if err != nil {
+		log.Printf("Error getting mirror webhook: %v", err)
+		return
+	}
+	name := m.Author.Username
+	if m.Member != nil && m.Member.Nick != "" {
+		name = m.Member.Nick
+	}
+	for _, chunk := range splitPlainMessage(orientText(translation, rule.lang)) {
+		_, err := s.WebhookExecute(hook.ID, hook.Token, false, &discordgo.WebhookParams{
+			Content:         chunk,
+			Username:        name,
+			AvatarURL:       m.Author.AvatarURL(""),
+			AllowedMentions: &discordgo.MessageAllowedMentions{Parse: []discordgo.AllowedMentionType{}},
+		})
+		if err != nil {
+			log.Printf("Error posting mirrored message: %v", err)
+			h.mirrorHooks.forget(rule.target)
+			return
+		}
+	}
+}
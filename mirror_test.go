@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseMirrors(t *testing.T) {
+	const (
+		a = "111111111111111111"
+		b = "222222222222222222"
+		c = "333333333333333333"
+	)
+	tests := []struct {
+		name    string
+		config  map[string]string
+		want    map[string]mirrorRule
+		wantErr bool
+	}{
+		{
+			name:   "one way",
+			config: map[string]string{a: b + " / French"},
+			want:   map[string]mirrorRule{a: {target: b, lang: "French"}},
+		},
+		{
+			name:   "chain",
+			config: map[string]string{a: b + "/French", b: c + "/German"},
+			want:   map[string]mirrorRule{a: {target: b, lang: "French"}, b: {target: c, lang: "German"}},
+		},
+		{name: "no language", config: map[string]string{a: b}, wantErr: true},
+		{name: "channel name", config: map[string]string{a: "#french/French"}, wantErr: true},
+		{name: "into itself", config: map[string]string{a: a + "/French"}, wantErr: true},
+		{name: "back and forth", config: map[string]string{a: b + "/French", b: a + "/English"}, wantErr: true},
+		{name: "loop through another", config: map[string]string{a: b + "/French", b: c + "/German", c: a + "/English"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMirrors(tt.config)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseMirrors() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMirrors() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMirrorWebhooksReuse(t *testing.T) {
+	s, fake := newTestSession(t)
+	fake.replies["/channels/c2/webhooks"] = `[
+		{"id":"w1","token":"other","name":"Salin mirror","user":{"id":"someone"}},
+		{"id":"w2","token":"ours","name":"Salin mirror","user":{"id":"bot"}}
+	]`
+	hooks := &mirrorWebhooks{webhooks: make(map[string]*discordgo.Webhook)}
+	for n := 0; n < 2; n++ {
+		hook, err := hooks.get(s, "c2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hook.ID != "w2" {
+			t.Errorf("webhook = %s, want the bot's own", hook.ID)
+		}
+	}
+	if got := len(fake.sent("/channels/c2/webhooks")); got != 1 {
+		t.Errorf("listed webhooks %d times, want once", got)
+	}
+}
+
+func TestMirrorMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  *discordgo.Message
+		wantName string
+	}{
+		{
+			name:     "mirrored under the author's name",
+			message:  &discordgo.Message{ChannelID: "c1", Content: "hello", Author: &discordgo.User{ID: "u1", Username: "ana"}},
+			wantName: "ana",
+		},
+		{
+			name: "nickname preferred",
+			message: &discordgo.Message{ChannelID: "c1", Content: "hello", Author: &discordgo.User{ID: "u1", Username: "ana"},
+				Member: &discordgo.Member{Nick: "Ana B"}},
+			wantName: "Ana B",
+		},
+		{
+			name:    "channel without a mirror",
+			message: &discordgo.Message{ChannelID: "c3", Content: "hello", Author: &discordgo.User{ID: "u1", Username: "ana"}},
+		},
+		{
+			name:    "mirrored message not mirrored again",
+			message: &discordgo.Message{ChannelID: "c1", Content: "hello", WebhookID: "w1", Author: &discordgo.User{ID: "w1", Username: "ana", Bot: true}},
+		},
+		{
+			name:    "bot message",
+			message: &discordgo.Message{ChannelID: "c1", Content: "hello", Author: &discordgo.User{ID: "b1", Username: "other bot", Bot: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{}, translator)
+			h.mirrors = map[string]mirrorRule{"c1": {target: "c2", lang: "French"}}
+			h.mirrorHooks = &mirrorWebhooks{webhooks: map[string]*discordgo.Webhook{"c2": {ID: "w1", Token: "tok"}}}
+
+			h.mirrorMessage(s, &discordgo.MessageCreate{Message: tt.message})
+
+			posted := fake.sent("/webhooks/w1/tok")
+			if tt.wantName == "" {
+				if translator.count() != 0 || len(posted) != 0 {
+					t.Errorf("translated %d times and posted %d, want neither", translator.count(), len(posted))
+				}
+				return
+			}
+			if translator.count() != 1 || translator.calls[0].TargetLang != "French" {
+				t.Fatalf("translated %+v, want once to French", translator.calls)
+			}
+			if len(posted) != 1 {
+				t.Fatalf("posted %d messages, want 1 through the target's webhook", len(posted))
+			}
+			var params discordgo.WebhookParams
+			if err := json.Unmarshal([]byte(posted[0].Body), &params); err != nil {
+				t.Fatal(err)
+			}
+			if params.Content != "translated: hello" || params.Username != tt.wantName {
+				t.Errorf("posted %q as %q, want the translation as %q", params.Content, params.Username, tt.wantName)
+			}
+			if params.AllowedMentions == nil || len(params.AllowedMentions.Parse) != 0 {
+				t.Errorf("allowed mentions = %+v, want nobody pinged", params.AllowedMentions)
+			}
+		})
+	}
+}
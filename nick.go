@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// memberName returns the name a member goes by in the server: their
+// nickname, else their display name, else their username
+func memberName(m *discordgo.Member) (name string, nickname bool) {
+	if m.Nick != "" {
+		return m.Nick, true
+	}
+	if m.User == nil {
+		return "", false
+	}
+	if m.User.GlobalName != "" {
+		return m.User.GlobalName, false
+	}
+	return m.User.Username, false
+}
+
+// nickReply describes the translation of a member's name for moderators
+func nickReply(m *discordgo.Member, translation, targetLang string) string {
+	name, nickname := memberName(m)
+	kind := "Nickname"
+	if !nickname {
+		kind = "Display name (no server nickname set)"
+	}
+	return fmt.Sprintf("%s of <@%s>: %s\nTranslated to %s: %s", kind, m.User.ID, name, targetLang, translation)
+}
+
+func (h *DiscordHandler) translateNickCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := commandOptions(i)
+	userID := opts["user"].UserValue(nil).ID
+	targetLang := opts["language"].StringValue()
+	if !h.admitCommand(s, i) {
+		return
+	}
+
+	if err := deferEphemeral(s, i); err != nil {
+		log.Printf("Error deferring nickname response: %v", err)
+		return
+	}
+
+	member, err := s.GuildMember(i.GuildID, userID)
+	if err != nil {
+		log.Printf("Error fetching member %s: %v", userID, err)
+		editResponseText(s, i, "I couldn't find that member in this server.")
+		return
+	}
+	name, _ := memberName(member)
+	if name == "" {
+		editResponseText(s, i, "That member has no name to translate.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	translation, err := h.translate(ctx, i.GuildID, i.ChannelID, name, targetLang)
+	if err != nil {
+		log.Printf("Error translating nickname: %v", err)
+		editResponseText(s, i, "Sorry, I couldn't translate that name.")
+		return
+	}
+	editResponseText(s, i, nickReply(member, translation, targetLang))
+}
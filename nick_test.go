@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestMemberName(t *testing.T) {
+	tests := []struct {
+		name         string
+		member       *discordgo.Member
+		want         string
+		wantNickname bool
+	}{
+		{
+			name:         "nickname",
+			member:       &discordgo.Member{Nick: "Hiro", User: &discordgo.User{ID: "u1", Username: "hiro99", GlobalName: "ヒロ"}},
+			want:         "Hiro",
+			wantNickname: true,
+		},
+		{
+			name:   "display name",
+			member: &discordgo.Member{User: &discordgo.User{ID: "u1", Username: "hiro99", GlobalName: "ヒロ"}},
+			want:   "ヒロ",
+		},
+		{
+			name:   "username",
+			member: &discordgo.Member{User: &discordgo.User{ID: "u1", Username: "hiro99"}},
+			want:   "hiro99",
+		},
+		{name: "no user", member: &discordgo.Member{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, nickname := memberName(tt.member)
+			if got != tt.want || nickname != tt.wantNickname {
+				t.Errorf("memberName() = %q, %v, want %q, %v", got, nickname, tt.want, tt.wantNickname)
+			}
+		})
+	}
+}
+
+func TestNickReply(t *testing.T) {
+	tests := []struct {
+		name   string
+		member *discordgo.Member
+		want   string
+	}{
+		{
+			name:   "with a nickname",
+			member: &discordgo.Member{Nick: "夜の猫", User: &discordgo.User{ID: "u1", Username: "cat"}},
+			want:   "Nickname of <@u1>: 夜の猫\nTranslated to English: Night cat",
+		},
+		{
+			name:   "without a nickname",
+			member: &discordgo.Member{User: &discordgo.User{ID: "u1", Username: "cat", GlobalName: "夜の猫"}},
+			want:   "Display name (no server nickname set) of <@u1>: 夜の猫\nTranslated to English: Night cat",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nickReply(tt.member, "Night cat", "English"); got != tt.want {
+				t.Errorf("nickReply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateNickCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		member       string
+		status       int
+		wantReply    string
+		wantSentName string
+	}{
+		{
+			name:         "nickname",
+			member:       `{"nick":"夜の猫","user":{"id":"u2","username":"cat"}}`,
+			wantReply:    "Nickname of <@u2>: 夜の猫\nTranslated to English: translated: 夜の猫",
+			wantSentName: "夜の猫",
+		},
+		{
+			name:         "no nickname",
+			member:       `{"user":{"id":"u2","username":"neko"}}`,
+			wantReply:    "Display name (no server nickname set) of <@u2>: neko\nTranslated to English: translated: neko",
+			wantSentName: "neko",
+		},
+		{name: "not a member", status: http.StatusNotFound, wantReply: "I couldn't find that member in this server."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/guilds/g1/members/u2"] = tt.member
+			if tt.status != 0 {
+				fake.statuses = map[string]int{"/guilds/g1/members/u2": tt.status}
+			}
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{}, translator)
+
+			i := commandInteraction("translate-nick", "mod", map[string]string{"user": "u2", "language": "English"})
+			for _, opt := range i.ApplicationCommandData().Options {
+				if opt.Name == "user" {
+					opt.Type = discordgo.ApplicationCommandOptionUser
+				}
+			}
+			h.translateNickCommand(s, i)
+
+			edits := responseEdits(t, fake)
+			if len(edits) != 1 || edits[0].Content == nil || *edits[0].Content != tt.wantReply {
+				t.Errorf("response edits = %+v, want %q", edits, tt.wantReply)
+			}
+			if tt.wantSentName == "" {
+				if translator.count() != 0 {
+					t.Errorf("translated %d times, want none", translator.count())
+				}
+				return
+			}
+			if translator.count() != 1 || translator.calls[0].Text != tt.wantSentName {
+				t.Errorf("translated %+v, want %q", translator.calls, tt.wantSentName)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// notifyUser sends a user a direct message. Reaction events have no
+// interaction to reply to ephemerally, so DMs are the only private channel.
+func notifyUser(s *discordgo.Session, userID, content string) {
+	channel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		log.Printf("Error opening DM channel: %v", err)
+		return
+	}
+	for _, chunk := range splitPlainMessage(content) {
+		if _, err := s.ChannelMessageSend(channel.ID, chunk); err != nil {
+			log.Printf("Error sending DM: %v", err)
+			return
+		}
+	}
+}
+
+// sendPrivately delivers a message to a user by DM, for servers that keep
+// translations out of their channels
+func sendPrivately(s *discordgo.Session, userID string, send *discordgo.MessageSend) error {
+	channel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		return fmt.Errorf("error opening DM channel: %v", err)
+	}
+	_, err = s.ChannelMessageSendComplex(channel.ID, send)
+	return err
+}
+
+// Kinds of notice, each throttled separately
+const (
+	noticeMissingAccess = "missing-access"
+	noticeGated         = "gated"
+	noticeMaintenance   = "maintenance"
+	noticeRateLimited   = "rate-limited"
+	noticeMessageCap    = "message-cap"
+	noticePinLimit      = "pin-limit"
+	noticeUnmappedFlag  = "unmapped-flag"
+	noticeTranslated    = "translated"
+	noticeRestricted    = "restricted"
+	noticeAbuse         = "abuse"
+	noticeQuietHours    = "quiet-hours"
+	noticeUnreliable    = "unreliable"
+)
+
+// How often a user can get the same kind of notice
+const noticeInterval = time.Minute
+
+// noticeThrottle remembers when each user last got each kind of notice
+type noticeThrottle struct {
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+func newNoticeThrottle() *noticeThrottle {
+	return &noticeThrottle{sent: make(map[string]time.Time)}
+}
+
+// allow reports whether a notice may be sent now, and if so records it
+func (t *noticeThrottle) allow(userID, noticeType string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, at := range t.sent {
+		if now.Sub(at) >= noticeInterval {
+			delete(t.sent, key)
+		}
+	}
+
+	key := userID + "\x00" + noticeType
+	if _, ok := t.sent[key]; ok {
+		return false
+	}
+	t.sent[key] = now
+	return true
+}
+
+// noticeThrottle reports whether userID may be sent a noticeType notice now
+func (h *DiscordHandler) noticeThrottle(userID, noticeType string) bool {
+	return h.notices.allow(userID, noticeType, time.Now())
+}
+
+// notify DMs a user a notice, at most once per noticeInterval for each
+// kind so rapid reactions don't turn into a stream of DMs
+func (h *DiscordHandler) notify(s *discordgo.Session, userID, noticeType, content string) {
+	if !h.noticeThrottle(userID, noticeType) {
+		return
+	}
+	notifyUser(s, userID, content)
+}
+
+// restStatus returns the HTTP status of a Discord REST error, or 0 if err
+// didn't come from a Discord API response
+func restStatus(err error) int {
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil {
+		return restErr.Response.StatusCode
+	}
+	return 0
+}
+
+// handleFetchError deals with failing to fetch the message a user reacted to
+func (h *DiscordHandler) handleFetchError(s *discordgo.Session, r *discordgo.MessageReactionAdd, err error) {
+	switch restStatus(err) {
+	case http.StatusNotFound:
+		// The message was deleted before we got to it
+		return
+	case http.StatusForbidden:
+		log.Printf("Missing access to messages in channel %s", r.ChannelID)
+		if h.config.NotifyMissingAccess {
+			h.notify(s, r.UserID, noticeMissingAccess, "I can't read the message you reacted to. I need the Read Message History permission in that channel to translate it.")
+		}
+	default:
+		log.Printf("Error fetching message: %v", err)
+	}
+}
+
+// skip logs why a reaction was ignored and marks the message with the skip
+// reaction so the user knows the bot saw it
+func (h *DiscordHandler) skip(s *discordgo.Session, r *discordgo.MessageReactionAdd, reason string) {
+	log.Printf("Skipping %s reaction on message %s: %s", r.Emoji.Name, r.MessageID, reason)
+	if h.config.SkipReaction == "" {
+		return
+	}
+	if err := s.MessageReactionAdd(r.ChannelID, r.MessageID, h.config.SkipReaction); err != nil {
+		log.Printf("Error adding skip reaction: %v", err)
+	}
+}
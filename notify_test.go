@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestRestStatus(t *testing.T) {
+	s, fake := newTestSession(t)
+	fake.statuses = map[string]int{"/messages/gone": http.StatusNotFound}
+	_, err := s.ChannelMessage("c1", "gone")
+	if got := restStatus(err); got != http.StatusNotFound {
+		t.Errorf("restStatus(%v) = %d, want 404", err, got)
+	}
+	if got := restStatus(errors.New("dial tcp: timeout")); got != 0 {
+		t.Errorf("restStatus of a network error = %d, want 0", got)
+	}
+}
+
+func TestHandleFetchError(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		notifyMissing bool
+		wantDMs       int
+	}{
+		{name: "deleted message is skipped silently", status: http.StatusNotFound, notifyMissing: true},
+		{name: "missing access notifies", status: http.StatusForbidden, notifyMissing: true, wantDMs: 1},
+		{name: "missing access without notices", status: http.StatusForbidden},
+		{name: "other errors are only logged", status: http.StatusBadRequest, notifyMissing: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.statuses = map[string]int{"/channels/c1/messages/m1": tt.status}
+			h := newTestHandler(t, Config{NotifyMissingAccess: tt.notifyMissing}, &fakeTranslator{})
+
+			_, err := s.ChannelMessage("c1", "m1")
+			if err == nil {
+				t.Fatal("ChannelMessage() succeeded, want the fake's error")
+			}
+			h.handleFetchError(s, testReaction("u1", "🇫🇷"), err)
+
+			if got := len(sentMessages(t, fake, "dm")); got != tt.wantDMs {
+				t.Errorf("sent %d DMs, want %d", got, tt.wantDMs)
+			}
+		})
+	}
+}
+
+func TestNoticeThrottleAllow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	type notice struct {
+		user, kind string
+		at         time.Duration
+		want       bool
+	}
+	tests := []struct {
+		name    string
+		notices []notice
+	}{
+		{"first notice", []notice{{"u1", noticeRateLimited, 0, true}}},
+		{"repeat within interval", []notice{
+			{"u1", noticeRateLimited, 0, true},
+			{"u1", noticeRateLimited, 30 * time.Second, false},
+		}},
+		{"repeat after interval", []notice{
+			{"u1", noticeRateLimited, 0, true},
+			{"u1", noticeRateLimited, noticeInterval, true},
+		}},
+		{"kinds are separate", []notice{
+			{"u1", noticeRateLimited, 0, true},
+			{"u1", noticeMessageCap, time.Second, true},
+		}},
+		{"users are separate", []notice{
+			{"u1", noticeRateLimited, 0, true},
+			{"u2", noticeRateLimited, time.Second, true},
+		}},
+		{"refused notice doesn't extend the wait", []notice{
+			{"u1", noticeRateLimited, 0, true},
+			{"u1", noticeRateLimited, 50 * time.Second, false},
+			{"u1", noticeRateLimited, 61 * time.Second, true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := newNoticeThrottle()
+			for n, notice := range tt.notices {
+				if got := throttle.allow(notice.user, notice.kind, start.Add(notice.at)); got != notice.want {
+					t.Errorf("notice %d: allow(%q, %q) = %v, want %v", n, notice.user, notice.kind, got, notice.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNoticeThrottleForgetsOldNotices(t *testing.T) {
+	throttle := newNoticeThrottle()
+	start := time.Now()
+	for _, user := range []string{"u1", "u2", "u3"} {
+		throttle.allow(user, noticeGated, start)
+	}
+	throttle.allow("u4", noticeGated, start.Add(noticeInterval))
+	if len(throttle.sent) != 1 {
+		t.Errorf("throttle holds %d notices, want only the recent one", len(throttle.sent))
+	}
+}
+
+func TestSkipPaths(t *testing.T) {
+	type reactionHandler func(h *DiscordHandler, s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message)
+	tests := []struct {
+		name    string
+		handle  reactionHandler
+		content string
+		pair    string
+		reason  string
+	}{
+		{name: "translate without text", handle: func(h *DiscordHandler, s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message) {
+			h.translateReaction(s, r, msg, "French")
+		}, reason: "message has no text"},
+		{name: "channel without a default", handle: (*DiscordHandler).channelDefaultReaction, content: "hello", reason: "channel has no default language"},
+		{name: "flip without a pair", handle: (*DiscordHandler).flipReaction, content: "hello", reason: "no language pair configured"},
+		{name: "flip without text", handle: (*DiscordHandler).flipReaction, pair: "English/Spanish", reason: "message has no text"},
+		{name: "clarify without text", handle: (*DiscordHandler).clarifyReaction, reason: "message has no text"},
+		{name: "summarize without text", handle: (*DiscordHandler).summarizeReaction, reason: "message has no text"},
+		{name: "describe without reactions", handle: (*DiscordHandler).describeReactions, content: "hello", reason: "message has no other reactions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			s, fake := newTestSession(t)
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{SkipReaction: "\u2139\ufe0f"}, translator)
+			h.completer = &fakeCompleter{reply: "English"}
+			if tt.pair != "" {
+				if err := h.guildConfigs.set("g1", GuildConfig{LanguagePair: tt.pair}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tt.handle(h, s, testReaction("user", "🔁"), testMessage(tt.content))
+
+			if !strings.Contains(logs.String(), ": "+tt.reason) {
+				t.Errorf("logs = %q, want the reason %q", logs.String(), tt.reason)
+			}
+			var skipped int
+			for _, r := range fake.sent("/@me") {
+				if r.Method == http.MethodPut && strings.Contains(r.Path, "/messages/m1/reactions/") {
+					skipped++
+				}
+			}
+			if skipped != 1 {
+				t.Errorf("added the skip reaction %d times, want 1", skipped)
+			}
+			if translator.count() != 0 {
+				t.Errorf("translated %d times, want none", translator.count())
+			}
+		})
+	}
+}
+
+func TestSkipWithoutReaction(t *testing.T) {
+	s, fake := newTestSession(t)
+	h := newTestHandler(t, Config{}, &fakeTranslator{})
+	h.skip(s, testReaction("user", "🇫🇷"), "message has no text")
+	if len(fake.requests) != 0 {
+		t.Errorf("made %d requests with no skip reaction set, want none", len(fake.requests))
+	}
+}
+
+func TestImageOnlyMessageSkipped(t *testing.T) {
+	tests := []struct {
+		name        string
+		attachments string
+	}{
+		{name: "image only", attachments: `[{"id":"a1","filename":"meme.png","content_type":"image/png","url":"https://cdn.example/meme.png"}]`},
+		{name: "image and video", attachments: `[{"id":"a1","filename":"photo.jpg","content_type":"image/jpeg","url":"https://cdn.example/photo.jpg"},{"id":"a2","filename":"clip.mp4","content_type":"video/mp4","url":"https://cdn.example/clip.mp4"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"","attachments":` + tt.attachments + `,"author":{"id":"author"}}`
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{SkipReaction: "\u2139\ufe0f"}, translator)
+			h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+
+			h.reactionAdd(s, testReaction("user", "🇫🇷"))
+
+			if translator.count() != 0 {
+				t.Errorf("translated %d times, want none", translator.count())
+			}
+			if posted := sentMessages(t, fake, "c1"); len(posted) != 0 {
+				t.Errorf("posted %+v, want no empty translation", posted)
+			}
+			var skipped int
+			for _, r := range fake.sent("/@me") {
+				if r.Method == http.MethodPut && strings.Contains(r.Path, "/messages/m1/reactions/") {
+					skipped++
+				}
+			}
+			if skipped != 1 {
+				t.Errorf("added the skip reaction %d times, want 1", skipped)
+			}
+		})
+	}
+}
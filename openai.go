@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type OpenAIRequest struct {
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// ResponseFormat constrains the shape of the model's reply
+type ResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// OpenAIResponse holds the parts of a chat completion we use. Fields we
+// don't know are ignored, so providers can add to the body freely.
+type OpenAIResponse struct {
+	Choices []struct {
+		Message *struct {
+			// A pointer so a missing content can be told from an empty one
+			Content *string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// StatusError is returned when the API answers with an unexpected status
+type StatusError struct {
+	StatusCode int
+	// RetryAfter is how long the API asked us to wait, if it said
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// isRateLimited reports whether err is the provider turning a request away
+// for going over its rate limit
+func isRateLimited(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests
+}
+
+// retryAfter returns the wait the API asked for along with err, or 0
+func retryAfter(err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds
+func parseRetryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(header))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Headers an extra header may only replace when overriding is allowed
+var criticalHeaders = []string{"Authorization", "Content-Type"}
+
+// Used unless OPENAI_BASE_URL points elsewhere
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAITranslator translates text using an OpenAI chat completion model
+type OpenAITranslator struct {
+	token  string
+	model  string
+	client *http.Client
+
+	// API root that /chat/completions is appended to
+	baseURL string
+
+	// Static headers added to every request, e.g. for an auth proxy
+	extraHeaders    http.Header
+	overrideHeaders bool
+
+	// Ask once more, with a firmer prompt, when the model replies with
+	// nothing but whitespace
+	retryEmpty bool
+
+	// Bounds concurrent requests to the model; shared by every translator
+	// for the same model
+	sem semaphore
+
+	// Called with the tokens used by every successful request
+	onUsage func(ctx context.Context, usage Usage)
+
+	// Gzip request bodies of at least this many bytes; 0 never does
+	compressAbove int
+
+	// Called with the prompt and reply of every successful request
+	onExchange func(ctx context.Context, model, prompt, reply string)
+}
+
+func NewOpenAITranslator(token, model string) *OpenAITranslator {
+	return &OpenAITranslator{
+		token:   token,
+		model:   model,
+		client:  &http.Client{},
+		baseURL: defaultOpenAIBaseURL,
+	}
+}
+
+// parseExtraHeaders reads a comma-separated list of Name=value pairs
+func parseExtraHeaders(s string) (http.Header, error) {
+	headers := http.Header{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid header %q, want Name=value", pair)
+		}
+		headers.Add(name, strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// applyExtraHeaders copies extra onto h, leaving the critical headers alone
+// unless override is set
+func applyExtraHeaders(h, extra http.Header, override bool) {
+	for name, values := range extra {
+		name = http.CanonicalHeaderKey(name)
+		if !override && slices.Contains(criticalHeaders, name) {
+			continue
+		}
+		h[name] = values
+	}
+}
+
+// encodeBody gzips a request body when it's at least threshold bytes.
+// Smaller bodies are sent as they are, since compressing them saves little.
+func encodeBody(data []byte, threshold int) ([]byte, bool, error) {
+	if threshold <= 0 || len(data) < threshold {
+		return data, false, nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, false, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, false, err
+	}
+	return buf.Bytes(), true, nil
+}
+
+func (t *OpenAITranslator) Name() string {
+	return "openai/" + t.model
+}
+
+func (t *OpenAITranslator) Translate(ctx context.Context, req TranslateRequest) (TranslateResult, error) {
+	log.Printf("Translating text: %s", req.Text)
+	log.Printf("Target language: %s", req.TargetLang)
+
+	prompt := translationPrompt(req)
+	text, usage, err := t.complete(ctx, prompt, nil)
+	if err != nil {
+		return TranslateResult{}, err
+	}
+	if strings.TrimSpace(text) == "" && t.retryEmpty {
+		log.Printf("Empty translation from %s, retrying", t.Name())
+		var retryUsage Usage
+		text, retryUsage, err = t.complete(ctx, prompt+emptyRetryInstruction, nil)
+		if err != nil {
+			return TranslateResult{}, err
+		}
+		usage.PromptTokens += retryUsage.PromptTokens
+		usage.CompletionTokens += retryUsage.CompletionTokens
+	}
+	if strings.TrimSpace(text) == "" {
+		return TranslateResult{}, fmt.Errorf("empty translation returned")
+	}
+	return TranslateResult{Text: text, Provider: t.Name(), Usage: usage}, nil
+}
+
+// Appended to the prompt when retrying after an empty reply
+const emptyRetryInstruction = "\n\nYour previous reply was empty. The text above is not empty; " +
+	"reply with its translation even if it is very short or is already in the target language."
+
+// Prompt instructions emulating DeepL's formality parameter
+var formalityInstructions = map[Formality]string{
+	FormalityFormal:   "Use a formal, polite register.",
+	FormalityInformal: "Use an informal, casual register.",
+}
+
+// translationPrompt renders the plain translation prompt, adding the
+// source language, register, pair instructions, requester's context and
+// conversation when known
+func translationPrompt(req TranslateRequest) string {
+	instruction := fmt.Sprintf("Translate the following text to %s.", req.TargetLang)
+	if req.SourceLang != "" {
+		instruction = fmt.Sprintf("Translate the following text from %s to %s.", req.SourceLang, req.TargetLang)
+	}
+	if f := formalityInstruction(req.TargetLang, req.Formality); f != "" {
+		instruction += " " + f
+	}
+	if req.Instructions != "" {
+		instruction += " " + req.Instructions
+	}
+	if req.Context != "" {
+		instruction += fmt.Sprintf(" Use this context from the requester to resolve ambiguity, but don't translate it: \"%s\".", req.Context)
+	}
+	if len(req.History) > 0 {
+		var b strings.Builder
+		b.WriteString(instruction)
+		b.WriteString(" It continues a conversation; keep tone and terms consistent with these earlier messages and their translations:\n")
+		for _, e := range req.History {
+			fmt.Fprintf(&b, "Original: %s\nTranslation: %s\n", e.Original, e.Translation)
+		}
+		instruction = b.String()
+	}
+	return fmt.Sprintf("%s Only respond with the translation, nothing else: %s", instruction, req.Text)
+}
+
+// Complete sends a single-message prompt and returns the model's reply
+func (t *OpenAITranslator) Complete(ctx context.Context, prompt string) (string, error) {
+	reply, _, err := t.complete(ctx, prompt, nil)
+	return reply, err
+}
+
+// CompleteJSON is like Complete but forces the model to reply with a JSON
+// object. The prompt itself must still mention JSON and describe the fields.
+func (t *OpenAITranslator) CompleteJSON(ctx context.Context, prompt string) (string, error) {
+	reply, _, err := t.complete(ctx, prompt, &ResponseFormat{Type: "json_object"})
+	return reply, err
+}
+
+func (t *OpenAITranslator) complete(ctx context.Context, prompt string, format *ResponseFormat) (string, Usage, error) {
+	requestBody := OpenAIRequest{
+		Model: t.model,
+		Messages: []Message{
+			{
+				Role:    "user",
+				Content: prompt,
+			},
+		},
+		ResponseFormat: format,
+	}
+
+	if err := t.sem.acquire(ctx); err != nil {
+		return "", Usage{}, fmt.Errorf("error waiting for %s: %w", t.Name(), err)
+	}
+	defer t.sem.release()
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	body, compressed, err := encodeBody(jsonData, t.compressAbove)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("error compressing request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(t.baseURL, "/")+"/chat/completions", bytes.NewBuffer(body))
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("error creating request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey(ctx, t.token))
+	applyExtraHeaders(req.Header, t.extraHeaders, t.overrideHeaders)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("error making request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", Usage{}, &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	response, err := decodeCompletion(resp.Body)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	usage := Usage{
+		PromptTokens:     response.Usage.PromptTokens,
+		CompletionTokens: response.Usage.CompletionTokens,
+	}
+	if t.onUsage != nil {
+		t.onUsage(ctx, usage)
+	}
+	reply := *response.Choices[0].Message.Content
+	if t.onExchange != nil {
+		t.onExchange(ctx, t.model, prompt, reply)
+	}
+	return reply, usage, nil
+}
+
+// decodeCompletion reads a chat completion body, tolerating fields we don't
+// know but rejecting one without the shape we need: a first choice with a
+// message and its content. Empty content is left for the caller, which may
+// retry it.
+func decodeCompletion(body io.Reader) (*OpenAIResponse, error) {
+	var response OpenAIResponse
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no completion returned")
+	}
+	message := response.Choices[0].Message
+	if message == nil {
+		return nil, fmt.Errorf("malformed response: choices[0] has no message")
+	}
+	if message.Content == nil {
+		return nil, fmt.Errorf("malformed response: choices[0].message has no content")
+	}
+	return &response, nil
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeCompletion(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantContent string
+		wantErr     string
+	}{
+		{"content", `{"choices":[{"message":{"content":"bonjour"}}]}`, "bonjour", ""},
+		{"empty content", `{"choices":[{"message":{"content":""}}]}`, "", ""},
+		{"unknown fields", `{"id":"x","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3}}`, "hi", ""},
+		{"no choices", `{"choices":[]}`, "", "no completion returned"},
+		{"no message", `{"choices":[{"index":0}]}`, "", "has no message"},
+		{"null content", `{"choices":[{"message":{"content":null}}]}`, "", "has no content"},
+		{"missing content", `{"choices":[{"message":{"role":"assistant"}}]}`, "", "has no content"},
+		{"not json", `<html>busy</html>`, "", "error decoding response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := decodeCompletion(strings.NewReader(tt.body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeCompletion() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeCompletion() error = %v", err)
+			}
+			if got := *response.Choices[0].Message.Content; got != tt.wantContent {
+				t.Errorf("content = %q, want %q", got, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestParseExtraHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    http.Header
+		wantErr bool
+	}{
+		{name: "empty", s: "", want: http.Header{}},
+		{name: "one", s: "X-Proxy-Auth=secret", want: http.Header{"X-Proxy-Auth": {"secret"}}},
+		{name: "several with spaces", s: " X-A = 1 , x-b=2,", want: http.Header{"X-A": {"1"}, "X-B": {"2"}}},
+		{name: "value with equals", s: "X-Token=a=b", want: http.Header{"X-Token": {"a=b"}}},
+		{name: "empty value", s: "X-Empty=", want: http.Header{"X-Empty": {""}}},
+		{name: "duplicate key keeps both", s: "X-Tag=a,x-tag=b", want: http.Header{"X-Tag": {"a", "b"}}},
+		{name: "missing equals", s: "X-A=1,broken", wantErr: true},
+		{name: "missing name", s: "=value", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExtraHeaders(tt.s)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseExtraHeaders(%q) = %v, want an error", tt.s, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseExtraHeaders(%q) error = %v", tt.s, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseExtraHeaders(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyExtraHeaders(t *testing.T) {
+	base := func() http.Header {
+		return http.Header{"Authorization": {"Bearer token"}, "Content-Type": {"application/json"}}
+	}
+	tests := []struct {
+		name     string
+		extra    http.Header
+		override bool
+		want     http.Header
+	}{
+		{
+			name:  "adds headers",
+			extra: http.Header{"X-Proxy-Auth": {"secret"}},
+			want:  http.Header{"Authorization": {"Bearer token"}, "Content-Type": {"application/json"}, "X-Proxy-Auth": {"secret"}},
+		},
+		{
+			name:  "keeps critical headers",
+			extra: http.Header{"Authorization": {"Basic proxy"}, "content-type": {"text/plain"}},
+			want:  base(),
+		},
+		{
+			name:     "override replaces critical headers",
+			extra:    http.Header{"Authorization": {"Basic proxy"}, "content-type": {"text/plain"}},
+			override: true,
+			want:     http.Header{"Authorization": {"Basic proxy"}, "Content-Type": {"text/plain"}},
+		},
+		{
+			name:  "replaces other headers",
+			extra: http.Header{"Content-Encoding": {"identity"}},
+			want:  http.Header{"Authorization": {"Bearer token"}, "Content-Type": {"application/json"}, "Content-Encoding": {"identity"}},
+		},
+		{
+			name: "nothing extra",
+			want: base(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := base()
+			applyExtraHeaders(h, tt.extra, tt.override)
+			if !reflect.DeepEqual(h, tt.want) {
+				t.Errorf("headers = %v, want %v", h, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteUsesBaseURL(t *testing.T) {
+	var gotPath, gotAuth, gotProxy string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		gotProxy = r.Header.Get("X-Proxy-Auth")
+		w.Write([]byte(`{"choices":[{"message":{"content":"bonjour"}}]}`))
+	}))
+	defer server.Close()
+
+	translator := NewOpenAITranslator("token", "model")
+	translator.baseURL = server.URL + "/proxy/v1/"
+	translator.extraHeaders = http.Header{"X-Proxy-Auth": {"secret"}, "Authorization": {"Basic proxy"}}
+	reply, err := translator.Complete(context.Background(), "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply != "bonjour" {
+		t.Errorf("Complete() = %q, want bonjour", reply)
+	}
+	if gotPath != "/proxy/v1/chat/completions" {
+		t.Errorf("request path = %q, want /proxy/v1/chat/completions", gotPath)
+	}
+	if gotAuth != "Bearer token" || gotProxy != "secret" {
+		t.Errorf("Authorization = %q, X-Proxy-Auth = %q, want the token and the proxy header", gotAuth, gotProxy)
+	}
+}
+
+func TestCompleteJSONMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		json       bool
+		wantFormat *ResponseFormat
+	}{
+		{"plain text", false, nil},
+		{"json object", true, &ResponseFormat{Type: "json_object"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got OpenAIRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Error(err)
+				}
+				w.Write([]byte(`{"choices":[{"message":{"content":"{\"translation\":\"hola\",\"notes\":\"n\"}"}}]}`))
+			}))
+			defer server.Close()
+
+			translator := NewOpenAITranslator("token", "model")
+			translator.baseURL = server.URL
+			complete := translator.Complete
+			if tt.json {
+				complete = translator.CompleteJSON
+			}
+			reply, err := complete(context.Background(), "explain this as JSON")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.ResponseFormat, tt.wantFormat) {
+				t.Errorf("response_format = %+v, want %+v", got.ResponseFormat, tt.wantFormat)
+			}
+			// The structured reply decodes straight into the typed result
+			if e, err := parseExplanation(reply); err != nil || e.Translation != "hola" {
+				t.Errorf("parseExplanation(%q) = %+v, %v", reply, e, err)
+			}
+		})
+	}
+}
+
+func TestTranslationPromptSourceHint(t *testing.T) {
+	tests := []struct {
+		name string
+		req  TranslateRequest
+		want string
+		not  string
+	}{
+		{
+			name: "without a source",
+			req:  TranslateRequest{Text: "hola amigo, how are you?", TargetLang: "French"},
+			want: "Translate the following text to French.",
+			not:  " from ",
+		},
+		{
+			name: "with a source",
+			req:  TranslateRequest{Text: "hola amigo, how are you?", TargetLang: "French", SourceLang: "Spanish"},
+			want: "Translate the following text from Spanish to French.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translationPrompt(tt.req)
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("translationPrompt() = %q, want it to start with %q", got, tt.want)
+			}
+			if tt.not != "" && strings.Contains(got, tt.not) {
+				t.Errorf("translationPrompt() = %q, should not contain %q", got, tt.not)
+			}
+			if !strings.HasSuffix(got, tt.req.Text) {
+				t.Errorf("translationPrompt() = %q, want it to end with the text", got)
+			}
+		})
+	}
+}
+
+func TestTranslationPromptFields(t *testing.T) {
+	base := TranslateRequest{Text: "see you tomorrow", TargetLang: "German"}
+	tests := []struct {
+		name string
+		edit func(req *TranslateRequest)
+		want []string
+	}{
+		{
+			name: "text and target only",
+			want: []string{"Translate the following text to German.", "nothing else: see you tomorrow"},
+		},
+		{
+			name: "formality",
+			edit: func(req *TranslateRequest) { req.Formality = FormalityFormal },
+			want: []string{formalityInstruction("German", FormalityFormal)},
+		},
+		{
+			name: "pair instructions",
+			edit: func(req *TranslateRequest) { req.Instructions = "Use Swiss spelling." },
+			want: []string{"Use Swiss spelling."},
+		},
+		{
+			name: "requester context",
+			edit: func(req *TranslateRequest) { req.Context = "said to a coworker" },
+			want: []string{`don't translate it: "said to a coworker".`},
+		},
+		{
+			name: "conversation history",
+			edit: func(req *TranslateRequest) {
+				req.History = []Exchange{{Original: "hi", Translation: "hallo"}}
+			},
+			want: []string{"continues a conversation", "Original: hi\nTranslation: hallo\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := base
+			if tt.edit != nil {
+				tt.edit(&req)
+			}
+			got := translationPrompt(req)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("translationPrompt() = %q, want it to contain %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTranslateResult(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"content":"bis morgen"}}],"usage":{"prompt_tokens":12,"completion_tokens":3}}`))
+	}))
+	defer server.Close()
+
+	translator := NewOpenAITranslator("token", "model")
+	translator.baseURL = server.URL
+	got, err := translator.Translate(context.Background(), TranslateRequest{Text: "see you tomorrow", TargetLang: "German"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := TranslateResult{Text: "bis morgen", Provider: "openai/model", Usage: Usage{PromptTokens: 12, CompletionTokens: 3}}
+	if got != want {
+		t.Errorf("Translate() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTranslateRetriesEmpty(t *testing.T) {
+	tests := []struct {
+		name      string
+		retry     bool
+		replies   []string
+		want      string
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "empty then valid", retry: true, replies: []string{"  ", "hola"}, want: "hola", wantCalls: 2},
+		{name: "persistently empty", retry: true, replies: []string{"", "\n"}, wantCalls: 2, wantErr: true},
+		{name: "valid first time", retry: true, replies: []string{"hola"}, want: "hola", wantCalls: 1},
+		{name: "retry disabled", replies: []string{"", "hola"}, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompts []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req OpenAIRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Error(err)
+				}
+				prompts = append(prompts, req.Messages[len(req.Messages)-1].Content)
+				reply, _ := json.Marshal(tt.replies[len(prompts)-1])
+				w.Write([]byte(`{"choices":[{"message":{"content":` + string(reply) + `}}],"usage":{"prompt_tokens":10,"completion_tokens":1}}`))
+			}))
+			defer server.Close()
+
+			translator := NewOpenAITranslator("token", "model")
+			translator.baseURL = server.URL
+			translator.retryEmpty = tt.retry
+			got, err := translator.Translate(context.Background(), TranslateRequest{Text: "hi", TargetLang: "Spanish"})
+			if len(prompts) != tt.wantCalls {
+				t.Fatalf("got %d requests, want %d", len(prompts), tt.wantCalls)
+			}
+			if tt.wantCalls > 1 && !strings.HasSuffix(prompts[1], emptyRetryInstruction) {
+				t.Errorf("retry prompt = %q, want the adjusted prompt", prompts[1])
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Translate() = %q, want an error", got.Text)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Text != tt.want {
+				t.Errorf("Translate() = %q, want %q", got.Text, tt.want)
+			}
+			if want := 10 * tt.wantCalls; got.Usage.PromptTokens != want {
+				t.Errorf("prompt tokens = %d, want %d across the calls", got.Usage.PromptTokens, want)
+			}
+		})
+	}
+}
+
+func TestEncodeBody(t *testing.T) {
+	data := []byte(strings.Repeat(`{"text":"hello"}`, 8))
+	tests := []struct {
+		name      string
+		threshold int
+		want      bool
+	}{
+		{name: "off", threshold: 0},
+		{name: "below the threshold", threshold: len(data) + 1},
+		{name: "at the threshold", threshold: len(data), want: true},
+		{name: "above the threshold", threshold: 1, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, compressed, err := encodeBody(data, tt.threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if compressed != tt.want {
+				t.Fatalf("compressed = %v, want %v", compressed, tt.want)
+			}
+			if compressed {
+				zr, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if body, err = io.ReadAll(zr); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !bytes.Equal(body, data) {
+				t.Errorf("body = %q, want %q", body, data)
+			}
+		})
+	}
+}
+
+func TestCompleteCompression(t *testing.T) {
+	tests := []struct {
+		name         string
+		threshold    int
+		wantEncoding string
+	}{
+		{name: "small prompt", threshold: 1 << 20},
+		{name: "large prompt", threshold: 1, wantEncoding: "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var encoding string
+			var got OpenAIRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				encoding = r.Header.Get("Content-Encoding")
+				var body io.Reader = r.Body
+				if encoding == "gzip" {
+					zr, err := gzip.NewReader(r.Body)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					body = zr
+				}
+				if err := json.NewDecoder(body).Decode(&got); err != nil {
+					t.Error(err)
+				}
+				w.Write([]byte(`{"choices":[{"message":{"content":"bonjour"}}]}`))
+			}))
+			defer server.Close()
+
+			translator := NewOpenAITranslator("token", "model")
+			translator.baseURL = server.URL
+			translator.compressAbove = tt.threshold
+			if _, err := translator.Complete(context.Background(), "hello"); err != nil {
+				t.Fatal(err)
+			}
+			if encoding != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", encoding, tt.wantEncoding)
+			}
+			if len(got.Messages) == 0 || got.Messages[len(got.Messages)-1].Content != "hello" {
+				t.Errorf("request = %+v, want the prompt", got)
+			}
+		})
+	}
+}
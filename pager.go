@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Discord rejects embed descriptions longer than this
+	maxDescriptionLength = 4096
+
+	// Discord rejects message content longer than this
+	maxContentLength = 2000
+
+	// Discord allows at most this many action rows per message, and this
+	// many components in each
+	maxActionRows    = 5
+	maxRowComponents = 5
+
+	// How long page buttons keep working after a translation is posted
+	pageTTL = 30 * time.Minute
+
+	prevPageID = "page:prev"
+	nextPageID = "page:next"
+)
+
+// splitText breaks s into chunks of at most max runes, preferring to cut at
+// paragraph, line and then word boundaries
+func splitText(s string, max int) []string {
+	chunks, _ := splitTextKeepingBreaks(s, max)
+	return chunks
+}
+
+// splitTextKeepingBreaks is splitText that also returns the whitespace cut
+// after each chunk, so joining every chunk with its break rebuilds s
+func splitTextKeepingBreaks(s string, max int) (chunks, breaks []string) {
+	for {
+		runes := []rune(s)
+		if len(runes) <= max {
+			if strings.TrimSpace(s) != "" {
+				chunks = append(chunks, s)
+			} else if len(breaks) > 0 {
+				breaks[len(breaks)-1] += s
+			}
+			return chunks, breaks
+		}
+
+		head := string(runes[:max])
+		cut := len(head)
+		for _, sep := range []string{"\n\n", "\n", " "} {
+			if i := strings.LastIndex(head, sep); i > 0 {
+				cut = i
+				break
+			}
+		}
+
+		chunk := strings.TrimRight(head[:cut], " \n")
+		rest := strings.TrimLeft(s[cut:], " \n")
+		chunks = append(chunks, chunk)
+		breaks = append(breaks, s[len(chunk):len(s)-len(rest)])
+		s = rest
+	}
+}
+
+// splitPlainMessage breaks plain message content into messages Discord
+// accepts, cutting between words where it can
+func splitPlainMessage(s string) []string {
+	return splitText(s, maxContentLength)
+}
+
+// sendPlain posts content as however many plain messages it takes
+func (h *DiscordHandler) sendPlain(s *discordgo.Session, channelID, content string) error {
+	for _, chunk := range splitPlainMessage(content) {
+		_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Content:         chunk,
+			AllowedMentions: h.allowedMentions(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// limitComponents drops action rows and components past Discord's limits,
+// which would otherwise fail the whole message
+func limitComponents(components []discordgo.MessageComponent) []discordgo.MessageComponent {
+	if len(components) > maxActionRows {
+		log.Printf("Dropping %d action rows past Discord's limit", len(components)-maxActionRows)
+		components = components[:maxActionRows]
+	}
+	for i, component := range components {
+		row, ok := component.(discordgo.ActionsRow)
+		if ok && len(row.Components) > maxRowComponents {
+			log.Printf("Dropping %d components past Discord's per-row limit", len(row.Components)-maxRowComponents)
+			row.Components = row.Components[:maxRowComponents]
+			components[i] = row
+		}
+	}
+	return components
+}
+
+type pageState struct {
+	embed   discordgo.MessageEmbed
+	pages   []string
+	index   int
+	expires time.Time
+}
+
+// paginator remembers the pages of posted translations, keyed by message ID
+type paginator struct {
+	mu     sync.Mutex
+	wrap   bool
+	states map[string]*pageState
+}
+
+func newPaginator(wrap bool) *paginator {
+	return &paginator{wrap: wrap, states: make(map[string]*pageState)}
+}
+
+// add starts tracking the pages of a posted message, dropping expired entries
+func (p *paginator) add(messageID string, embed *discordgo.MessageEmbed, pages []string, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, st := range p.states {
+		if now.After(st.expires) {
+			delete(p.states, id)
+		}
+	}
+	p.states[messageID] = &pageState{
+		embed:   *embed,
+		pages:   pages,
+		expires: now.Add(pageTTL),
+	}
+}
+
+// turn moves a message delta pages and returns the embed for the new page.
+// It returns false if the message isn't tracked or has expired.
+func (p *paginator) turn(messageID string, delta int, now time.Time) (*discordgo.MessageEmbed, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	st, ok := p.states[messageID]
+	if !ok || now.After(st.expires) {
+		delete(p.states, messageID)
+		return nil, false
+	}
+
+	st.index = nextPage(st.index, delta, len(st.pages), p.wrap)
+	return pageEmbed(st.embed, st.pages, st.index), true
+}
+
+// nextPage moves from index by delta, either wrapping around or stopping at
+// the first and last page
+func nextPage(index, delta, count int, wrap bool) int {
+	index += delta
+	if wrap {
+		return ((index % count) + count) % count
+	}
+	return max(0, min(index, count-1))
+}
+
+// pageEmbed renders page index of a paged translation
+func pageEmbed(base discordgo.MessageEmbed, pages []string, index int) *discordgo.MessageEmbed {
+	embed := base
+	embed.Description = pages[index]
+	if base.Footer != nil {
+		footer := *base.Footer
+		footer.Text = fmt.Sprintf("%s • Page %d/%d", footer.Text, index+1, len(pages))
+		embed.Footer = &footer
+	}
+	return &embed
+}
+
+func pageButtons() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "◀️"}, Style: discordgo.SecondaryButton, CustomID: prevPageID},
+				discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "▶️"}, Style: discordgo.SecondaryButton, CustomID: nextPageID},
+			},
+		},
+	}
+}
+
+// sendPaged posts an embed, splitting a long description across pages that
+// can be flipped through with buttons. Without pagination the description
+// is truncated instead. TRUNCATE_WITH_LINK replaces both with a preview
+// linking to the full text. With a reference the embed is posted as a reply.
+func (h *DiscordHandler) sendPaged(s *discordgo.Session, channelID string, embed *discordgo.MessageEmbed, reference *discordgo.MessageReference) (*discordgo.Message, error) {
+	if h.config.TruncateWithLink && len([]rune(embed.Description)) > h.config.TruncateLength {
+		return h.sendWithLink(s, channelID, embed, reference)
+	}
+
+	send := &discordgo.MessageSend{
+		Reference:       reference,
+		AllowedMentions: h.allowedMentions(),
+	}
+	// The original and the footer count towards the embed's total length,
+	// so the description gets what they leave
+	room := descriptionRoom(embed, pageNumberRoom)
+	pages := splitText(embed.Description, room)
+	if len(pages) <= 1 || h.pages == nil {
+		embed.Description = truncate(embed.Description, room)
+		send.Embeds = []*discordgo.MessageEmbed{embed}
+		return s.ChannelMessageSendComplex(channelID, send)
+	}
+
+	send.Embeds = []*discordgo.MessageEmbed{pageEmbed(*embed, pages, 0)}
+	send.Components = limitComponents(pageButtons())
+	msg, err := s.ChannelMessageSendComplex(channelID, send)
+	if err != nil {
+		return nil, err
+	}
+	h.pages.add(msg.ID, embed, pages, time.Now())
+	return msg, nil
+}
+
+// allowedMentions stops replies from pinging the original author unless
+// PING_AUTHOR is set, and stops translated text from pinging anyone. An
+// empty Parse list rules out @everyone and @here as well as users and roles.
+func (h *DiscordHandler) allowedMentions() *discordgo.MessageAllowedMentions {
+	return &discordgo.MessageAllowedMentions{
+		Parse:       []discordgo.AllowedMentionType{},
+		RepliedUser: h.config.PingAuthor,
+	}
+}
+
+func (h *DiscordHandler) pageButton(s *discordgo.Session, i *discordgo.InteractionCreate, delta int) {
+	var embed *discordgo.MessageEmbed
+	ok := false
+	if h.pages != nil {
+		embed, ok = h.pages.turn(i.Message.ID, delta, time.Now())
+	}
+	if !ok {
+		respondEphemeral(s, i, "This translation can no longer be paged.")
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: limitComponents(pageButtons()),
+		},
+	})
+	if err != nil {
+		log.Printf("Error turning page: %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		max  int
+		want []string
+	}{
+		{"fits", "hello world", 20, []string{"hello world"}},
+		{"blank", "  \n", 20, nil},
+		{"paragraph break first", "one two\n\nthree four", 14, []string{"one two", "three four"}},
+		{"line break next", "one two\nthree four", 14, []string{"one two", "three four"}},
+		{"between words", "one two three", 9, []string{"one two", "three"}},
+		{"hard cut", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"counts runes", "ääää ööö", 5, []string{"ääää", "ööö"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitText(tt.s, tt.max); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitText(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTextKeepingBreaks(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		max  int
+	}{
+		{"fits", "hello world", 20},
+		{"paragraph break", "one two\n\nthree four", 14},
+		{"line break", "one two\nthree four", 14},
+		{"spaces around break", "one two  \n  three four", 14},
+		{"trailing blank", "one two\n\nthree four\n\n", 14},
+		{"hard cut", "abcdefghij", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, breaks := splitTextKeepingBreaks(tt.s, tt.max)
+			if !reflect.DeepEqual(chunks, splitText(tt.s, tt.max)) {
+				t.Errorf("chunks = %q, want splitText's %q", chunks, splitText(tt.s, tt.max))
+			}
+			var rebuilt strings.Builder
+			for n, chunk := range chunks {
+				rebuilt.WriteString(chunk)
+				if n < len(breaks) {
+					rebuilt.WriteString(breaks[n])
+				}
+			}
+			if rebuilt.String() != tt.s {
+				t.Errorf("rebuilt %q, want %q", rebuilt.String(), tt.s)
+			}
+		})
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	tests := []struct {
+		name                string
+		index, delta, count int
+		wrap                bool
+		want                int
+	}{
+		{"forward", 0, 1, 3, false, 1},
+		{"stops at last", 2, 1, 3, false, 2},
+		{"stops at first", 0, -1, 3, false, 0},
+		{"wraps forward", 2, 1, 3, true, 0},
+		{"wraps back", 0, -1, 3, true, 2},
+		{"single page", 0, 1, 1, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextPage(tt.index, tt.delta, tt.count, tt.wrap); got != tt.want {
+				t.Errorf("nextPage(%d, %d, %d, %v) = %d, want %d", tt.index, tt.delta, tt.count, tt.wrap, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplyPingSuppressed(t *testing.T) {
+	tests := []struct {
+		name string
+		ping bool
+	}{
+		{name: "default", ping: false},
+		{name: "PING_AUTHOR set", ping: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello @everyone","author":{"id":"author"}}`
+			h := newTestHandler(t, Config{PingAuthor: tt.ping}, &fakeTranslator{})
+			h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+
+			h.reactionAdd(s, testReaction("user", "🇫🇷"))
+
+			sent := fake.sent("/channels/c1/messages")
+			if len(sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(sent))
+			}
+			var payload struct {
+				AllowedMentions *struct {
+					Parse       []string `json:"parse"`
+					RepliedUser bool     `json:"replied_user"`
+				} `json:"allowed_mentions"`
+				MessageReference *discordgo.MessageReference `json:"message_reference"`
+			}
+			if err := json.Unmarshal([]byte(sent[0].Body), &payload); err != nil {
+				t.Fatal(err)
+			}
+			if payload.MessageReference == nil || payload.MessageReference.MessageID != "m1" {
+				t.Errorf("message reference = %+v, want a reply to m1", payload.MessageReference)
+			}
+			mentions := payload.AllowedMentions
+			if mentions == nil {
+				t.Fatalf("no allowed_mentions in %s", sent[0].Body)
+			}
+			if mentions.Parse == nil || len(mentions.Parse) != 0 {
+				t.Errorf("parse = %v, want an empty list so nobody is pinged", mentions.Parse)
+			}
+			if mentions.RepliedUser != tt.ping {
+				t.Errorf("replied_user = %v, want %v", mentions.RepliedUser, tt.ping)
+			}
+		})
+	}
+}
+
+func TestSplitPlainMessage(t *testing.T) {
+	words := strings.Repeat("word ", 500)
+	tests := []struct {
+		name       string
+		s          string
+		wantChunks int
+		wholeWords bool
+	}{
+		{name: "fits", s: "hello world", wantChunks: 1},
+		{name: "exactly the limit", s: strings.Repeat("a", maxContentLength), wantChunks: 1},
+		{name: "between words", s: words, wantChunks: 2, wholeWords: true},
+		{name: "one long word", s: strings.Repeat("a", maxContentLength+1), wantChunks: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := splitPlainMessage(tt.s)
+			if len(chunks) != tt.wantChunks {
+				t.Fatalf("got %d chunks, want %d", len(chunks), tt.wantChunks)
+			}
+			for n, chunk := range chunks {
+				if l := len([]rune(chunk)); l > maxContentLength {
+					t.Errorf("chunk %d is %d characters, over %d", n, l, maxContentLength)
+				}
+				for _, word := range strings.Fields(chunk) {
+					if tt.wholeWords && word != "word" {
+						t.Errorf("chunk %d breaks a word into %q", n, word)
+					}
+				}
+			}
+			if got := strings.Join(chunks, ""); strings.ReplaceAll(got, " ", "") != strings.ReplaceAll(tt.s, " ", "") {
+				t.Errorf("chunks lost text")
+			}
+		})
+	}
+}
+
+func TestLimitComponents(t *testing.T) {
+	row := func(buttons int) discordgo.ActionsRow {
+		var r discordgo.ActionsRow
+		for n := 0; n < buttons; n++ {
+			r.Components = append(r.Components, discordgo.Button{CustomID: string(rune('a' + n))})
+		}
+		return r
+	}
+	rows := func(n, buttons int) []discordgo.MessageComponent {
+		var components []discordgo.MessageComponent
+		for ; n > 0; n-- {
+			components = append(components, row(buttons))
+		}
+		return components
+	}
+	tests := []struct {
+		name       string
+		components []discordgo.MessageComponent
+		wantRows   int
+		wantPerRow int
+	}{
+		{name: "within limits", components: rows(2, 3), wantRows: 2, wantPerRow: 3},
+		{name: "at the limits", components: rows(maxActionRows, maxRowComponents), wantRows: maxActionRows, wantPerRow: maxRowComponents},
+		{name: "too many rows", components: rows(maxActionRows+2, 1), wantRows: maxActionRows, wantPerRow: 1},
+		{name: "too many in a row", components: rows(1, maxRowComponents+3), wantRows: 1, wantPerRow: maxRowComponents},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := limitComponents(tt.components)
+			if len(got) != tt.wantRows {
+				t.Fatalf("got %d rows, want %d", len(got), tt.wantRows)
+			}
+			for n, component := range got {
+				if buttons := len(component.(discordgo.ActionsRow).Components); buttons != tt.wantPerRow {
+					t.Errorf("row %d has %d components, want %d", n, buttons, tt.wantPerRow)
+				}
+			}
+		})
+	}
+}
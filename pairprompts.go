@@ -0,0 +1,44 @@
+package main
+
+import "strings"
+
+// Matches any source language in a PAIR_PROMPTS key, e.g. *>English
+const anySourceLang = "*"
+
+// splitPair reads a source>target PAIR_PROMPTS key
+func splitPair(key string) (source, target string, ok bool) {
+	source, target, ok = strings.Cut(key, ">")
+	return strings.TrimSpace(source), strings.TrimSpace(target), ok
+}
+
+// pairPrompt returns the extra instruction configured for translating from
+// source to target. An exact pair wins over a *>target entry; an unknown
+// source only matches the latter.
+func pairPrompt(prompts map[string]string, source, target string) string {
+	var fallback string
+	for key, prompt := range prompts {
+		from, to, ok := splitPair(key)
+		if !ok || !strings.EqualFold(to, target) {
+			continue
+		}
+		if source != "" && strings.EqualFold(from, source) {
+			return prompt
+		}
+		if from == anySourceLang {
+			fallback = prompt
+		}
+	}
+	return fallback
+}
+
+// needsSourceFor reports whether any pair prompt for target depends on the
+// source language, in which case it has to be detected first
+func needsSourceFor(prompts map[string]string, target string) bool {
+	for key := range prompts {
+		from, to, ok := splitPair(key)
+		if ok && from != anySourceLang && strings.EqualFold(to, target) {
+			return true
+		}
+	}
+	return false
+}
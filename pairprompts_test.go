@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPairPrompt(t *testing.T) {
+	prompts := map[string]string{
+		"Japanese>English": "Preserve honorifics.",
+		"*>English":        "Use plain English.",
+		"German > French":  "Keep the formal register.",
+		"malformed":        "Never used.",
+	}
+	tests := []struct {
+		name   string
+		source string
+		target string
+		want   string
+	}{
+		{name: "exact pair", source: "Japanese", target: "English", want: "Preserve honorifics."},
+		{name: "any case", source: "japanese", target: "english", want: "Preserve honorifics."},
+		{name: "any source", source: "Spanish", target: "English", want: "Use plain English."},
+		{name: "unknown source", target: "English", want: "Use plain English."},
+		{name: "spacing around the arrow", source: "German", target: "French", want: "Keep the formal register."},
+		{name: "no override", source: "Japanese", target: "French"},
+		{name: "unknown source without a fallback", target: "French"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pairPrompt(prompts, tt.source, tt.target); got != tt.want {
+				t.Errorf("pairPrompt(%q, %q) = %q, want %q", tt.source, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsSourceFor(t *testing.T) {
+	prompts := map[string]string{
+		"Japanese>English": "Preserve honorifics.",
+		"*>French":         "Use plain French.",
+	}
+	tests := []struct {
+		target string
+		want   bool
+	}{
+		{"English", true},
+		{"French", false},
+		{"German", false},
+	}
+	for _, tt := range tests {
+		if got := needsSourceFor(prompts, tt.target); got != tt.want {
+			t.Errorf("needsSourceFor(%q) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestTranslatePairInstructions(t *testing.T) {
+	prompts := map[string]string{"Japanese>English": "Preserve honorifics."}
+	tests := []struct {
+		name     string
+		detected string
+		target   string
+		want     string
+	}{
+		{name: "pair with an override", detected: "Japanese", target: "English", want: "Preserve honorifics."},
+		{name: "other source", detected: "Korean", target: "English"},
+		{name: "other target", detected: "Japanese", target: "French"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{PairPrompts: prompts}, translator)
+			h.completer = &fakeCompleter{reply: tt.detected}
+
+			if _, err := h.translateDetailed(context.Background(), "g1", "c1", "田中さん、ありがとう", tt.target, ""); err != nil {
+				t.Fatal(err)
+			}
+			if translator.count() != 1 {
+				t.Fatalf("translated %d times, want 1", translator.count())
+			}
+			if got := translator.calls[0].Instructions; got != tt.want {
+				t.Errorf("instructions = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
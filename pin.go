@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const pinEmoji = "📌"
+
+// canManageMessages reports whether a user has Manage Messages in a channel
+func canManageMessages(s *discordgo.Session, userID, channelID string) bool {
+	perms, err := s.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		log.Printf("Error checking permissions: %v", err)
+		return false
+	}
+	return perms&discordgo.PermissionManageMessages != 0
+}
+
+// isPinLimitError reports whether Discord refused a pin because the channel
+// already has the maximum number of pins
+func isPinLimitError(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeMaximumPinsReached
+}
+
+// ownTranslation fetches a message and reports whether the bot posted it
+func ownTranslation(s *discordgo.Session, channelID, messageID string) bool {
+	msg, err := s.ChannelMessage(channelID, messageID)
+	if err != nil {
+		log.Printf("Error fetching message: %v", err)
+		return false
+	}
+	return msg.Author != nil && msg.Author.ID == s.State.User.ID
+}
+
+// pinReaction pins one of the bot's translations for moderators
+func (h *DiscordHandler) pinReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message) {
+	if !canManageMessages(s, r.UserID, r.ChannelID) {
+		return
+	}
+
+	err := s.ChannelMessagePin(r.ChannelID, r.MessageID)
+	if isPinLimitError(err) {
+		h.notify(s, r.UserID, noticePinLimit, "I couldn't pin that translation because the channel has reached Discord's pin limit.")
+		return
+	}
+	if err != nil {
+		log.Printf("Error pinning translation: %v", err)
+	}
+}
+
+// reactionRemove unpins a translation when a moderator removes their 📌
+func (h *DiscordHandler) reactionRemove(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	if r.Emoji.Name != pinEmoji || r.UserID == s.State.User.ID {
+		return
+	}
+	if !canManageMessages(s, r.UserID, r.ChannelID) || !ownTranslation(s, r.ChannelID, r.MessageID) {
+		return
+	}
+
+	if err := s.ChannelMessageUnpin(r.ChannelID, r.MessageID); err != nil {
+		log.Printf("Error unpinning translation: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// addPinState puts guild g1 and channel c1 in s's state, with "mod" holding
+// Manage Messages and "member" holding no permissions
+func addPinState(t *testing.T, s *discordgo.Session) {
+	t.Helper()
+	guild := &discordgo.Guild{
+		ID:      "g1",
+		OwnerID: "owner",
+		Roles: []*discordgo.Role{
+			{ID: "g1"},
+			{ID: "moderators", Permissions: discordgo.PermissionManageMessages},
+		},
+	}
+	if err := s.State.GuildAdd(guild); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.State.ChannelAdd(&discordgo.Channel{ID: "c1", GuildID: "g1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []*discordgo.Member{
+		{GuildID: "g1", User: &discordgo.User{ID: "mod"}, Roles: []string{"moderators"}},
+		{GuildID: "g1", User: &discordgo.User{ID: "member"}},
+	} {
+		if err := s.State.MemberAdd(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPinReaction(t *testing.T) {
+	tests := []struct {
+		name        string
+		userID      string
+		pinLimit    bool
+		wantPin     bool
+		wantNotices int
+	}{
+		{name: "moderator pins", userID: "mod", wantPin: true},
+		{name: "member can't pin", userID: "member"},
+		{name: "pin limit", userID: "mod", pinLimit: true, wantPin: true, wantNotices: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			addPinState(t, s)
+			if tt.pinLimit {
+				fake.statuses = map[string]int{"/pins/m1": http.StatusBadRequest}
+				fake.replies["/pins/m1"] = `{"code":30003,"message":"Maximum number of pins reached (50)"}`
+			}
+			h := newTestHandler(t, Config{}, &fakeTranslator{})
+			h.pinReaction(s, testReaction(tt.userID, pinEmoji), testMessage("translated"))
+
+			if pinned := len(fake.sent("/pins/m1")) == 1; pinned != tt.wantPin {
+				t.Errorf("pinned = %v, want %v", pinned, tt.wantPin)
+			}
+			if got := len(sentMessages(t, fake, "dm")); got != tt.wantNotices {
+				t.Errorf("sent %d notices, want %d", got, tt.wantNotices)
+			}
+		})
+	}
+}
+
+func TestIsPinLimitError(t *testing.T) {
+	s, fake := newTestSession(t)
+	fake.statuses = map[string]int{"/pins/full": http.StatusBadRequest, "/pins/other": http.StatusBadRequest}
+	fake.replies["/pins/full"] = `{"code":30003,"message":"Maximum number of pins reached (50)"}`
+	fake.replies["/pins/other"] = `{"code":50013,"message":"Missing Permissions"}`
+	tests := []struct {
+		messageID string
+		want      bool
+	}{
+		{"full", true},
+		{"other", false},
+		{"fine", false},
+	}
+	for _, tt := range tests {
+		err := s.ChannelMessagePin("c1", tt.messageID)
+		if got := isPinLimitError(err); got != tt.want {
+			t.Errorf("isPinLimitError(%v) = %v, want %v", err, got, tt.want)
+		}
+	}
+}
+
+func TestUnpinReaction(t *testing.T) {
+	tests := []struct {
+		name      string
+		userID    string
+		author    string
+		wantUnpin bool
+	}{
+		{"moderator unpins a translation", "mod", "bot", true},
+		{"member can't unpin", "member", "bot", false},
+		{"other messages stay pinned", "mod", "someone", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			addPinState(t, s)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","author":{"id":"` + tt.author + `"}}`
+			h := newTestHandler(t, Config{}, &fakeTranslator{})
+			h.reactionRemove(s, &discordgo.MessageReactionRemove{MessageReaction: &discordgo.MessageReaction{
+				UserID: tt.userID, MessageID: "m1", ChannelID: "c1", GuildID: "g1", Emoji: discordgo.Emoji{Name: pinEmoji},
+			}})
+			var unpinned bool
+			for _, r := range fake.sent("/pins/m1") {
+				unpinned = unpinned || r.Method == http.MethodDelete
+			}
+			if unpinned != tt.wantUnpin {
+				t.Errorf("unpinned = %v, want %v", unpinned, tt.wantUnpin)
+			}
+		})
+	}
+}
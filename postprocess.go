@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PostProcessor transforms a translation before it is sent
+type PostProcessor interface {
+	Process(text string) (string, error)
+}
+
+// PostProcessorFunc adapts a function to a PostProcessor
+type PostProcessorFunc func(text string) (string, error)
+
+func (f PostProcessorFunc) Process(text string) (string, error) {
+	return f(text)
+}
+
+// postChain runs processors in order, stopping at the first error
+type postChain []PostProcessor
+
+func (c postChain) Process(text string) (string, error) {
+	for _, p := range c {
+		var err error
+		text, err = p.Process(text)
+		if err != nil {
+			return "", err
+		}
+	}
+	return text, nil
+}
+
+// Processors that can be named in POST_PROCESSORS
+var postProcessors = map[string]PostProcessor{
+	"trim":     PostProcessorFunc(trimProcessor),
+	"markdown": PostProcessorFunc(markdownProcessor),
+}
+
+// newPostChain builds a chain from processor names, in the given order
+func newPostChain(names []string) (postChain, error) {
+	var chain postChain
+	for _, name := range names {
+		p, ok := postProcessors[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown post-processor %q", name)
+		}
+		chain = append(chain, p)
+	}
+	return chain, nil
+}
+
+// tokenRestorer puts protected spans back into a translation
+type tokenRestorer []protectedToken
+
+func (r tokenRestorer) Process(text string) (string, error) {
+	return restoreTokens(text, r), nil
+}
+
+func trimProcessor(text string) (string, error) {
+	return strings.TrimSpace(text), nil
+}
+
+// markdownProcessor closes a code block the model left open, which would
+// otherwise swallow the rest of the embed
+func markdownProcessor(text string) (string, error) {
+	if strings.Count(text, "```")%2 == 1 {
+		text += "\n```"
+	}
+	return text, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPostChain(t *testing.T) {
+	errMask := errors.New("mask failed")
+	appending := func(s string) PostProcessor {
+		return PostProcessorFunc(func(text string) (string, error) { return text + s, nil })
+	}
+	failing := PostProcessorFunc(func(string) (string, error) { return "", errMask })
+
+	tests := []struct {
+		name    string
+		chain   postChain
+		text    string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "empty chain leaves text alone",
+			text: "hola",
+			want: "hola",
+		},
+		{
+			name:  "processors run in order",
+			chain: postChain{appending("a"), appending("b"), appending("c")},
+			text:  "hola ",
+			want:  "hola abc",
+		},
+		{
+			name:  "each processor sees the previous output",
+			chain: postChain{appending("  "), PostProcessorFunc(trimProcessor)},
+			text:  " hola",
+			want:  "hola",
+		},
+		{
+			name:    "first error stops the chain",
+			chain:   postChain{appending("a"), failing, appending("b")},
+			text:    "hola",
+			wantErr: errMask,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.chain.Process(tt.text)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Process() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Process() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostChainStopsAfterError(t *testing.T) {
+	ran := false
+	chain := postChain{
+		PostProcessorFunc(func(string) (string, error) { return "", errors.New("down") }),
+		PostProcessorFunc(func(text string) (string, error) { ran = true; return text, nil }),
+	}
+	if _, err := chain.Process("hola"); err == nil {
+		t.Fatal("Process() succeeded, want an error")
+	}
+	if ran {
+		t.Error("processor after the failing one ran")
+	}
+}
+
+func TestNewPostChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "no names",
+			text: " hola ",
+			want: " hola ",
+		},
+		{
+			name:  "trim then markdown",
+			names: []string{"trim", "markdown"},
+			text:  " ```go\nx := 1 ",
+			want:  "```go\nx := 1\n```",
+		},
+		{
+			name:  "markdown then trim",
+			names: []string{"markdown", "trim"},
+			text:  " ```go\nx := 1 ",
+			want:  "```go\nx := 1 \n```",
+		},
+		{
+			name:  "names are trimmed",
+			names: []string{" trim "},
+			text:  " hola ",
+			want:  "hola",
+		},
+		{
+			name:    "unknown name",
+			names:   []string{"trim", "shout"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := newPostChain(tt.names)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("newPostChain() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newPostChain() error = %v", err)
+			}
+			got, err := chain.Process(tt.text)
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Process() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Stands in for the detected language, since previews don't call the provider
+const previewSourceLang = "<detected language>"
+
+// previewPrompts renders the prompts a translation of req would send: one
+// for the text with protected spans masked, then one per span translated on
+// its own. Prompts that would be skipped are left out.
+func previewPrompts(req TranslateRequest, opts tokenOptions) []string {
+	masked, tokens := protectTokens(req.Text, opts)
+
+	var prompts []string
+	if !onlyPlaceholders(masked) {
+		prompts = append(prompts, translationPrompt(req.with(masked)))
+	}
+	for _, token := range tokens {
+		for _, part := range token.parts {
+			if part.translate && !onlyPlaceholders(part.text) {
+				prompts = append(prompts, translationPrompt(req.with(part.text)))
+			}
+		}
+	}
+	return prompts
+}
+
+// previewPromptCommand shows the bot owner the prompts a translation would
+// send, without calling the provider
+func (h *DiscordHandler) previewPromptCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if h.config.OwnerID == "" || interactionUser(i).ID != h.config.OwnerID {
+		respondEphemeral(s, i, "Only the bot owner can preview prompts.")
+		return
+	}
+
+	opts := commandOptions(i)
+	targetLang := opts["language"].StringValue()
+	req := TranslateRequest{
+		Text:       opts["text"].StringValue(),
+		TargetLang: targetLang,
+		Formality:  h.formalityFor(i.GuildID, targetLang),
+	}
+	if h.detectsSource() || needsSourceFor(h.config.PairPrompts, targetLang) {
+		req.SourceLang = previewSourceLang
+	}
+	req.Instructions = pairPrompt(h.config.PairPrompts, req.SourceLang, targetLang)
+	if h.conversations != nil {
+		req.History = h.conversations.recent(conversationKey(i.ChannelID, req.SourceLang, targetLang), time.Now())
+	}
+
+	tokens := h.tokenOptions()
+	tokens.terms = h.guildConfig(i.GuildID).glossaryFor(targetLang)
+	prompts := previewPrompts(req, tokens)
+	if len(prompts) == 0 {
+		respondEphemeral(s, i, "Nothing in that text would be sent to the provider.")
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Prompts for %s:\n", h.routeFor(targetLang).Name())
+	for _, prompt := range prompts {
+		// Keep the prompt from closing the code block early
+		fmt.Fprintf(&b, "```\n%s\n```\n", strings.ReplaceAll(prompt, "```", "`\u200b``"))
+	}
+	log.Printf("Previewed translation prompt for %s", targetLang)
+	respondEphemeral(s, i, truncate(b.String(), maxContentLength))
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPreviewMatchesSentPrompts(t *testing.T) {
+	tests := []struct {
+		name string
+		req  TranslateRequest
+		opts tokenOptions
+		want int
+	}{
+		{
+			name: "plain text",
+			req:  TranslateRequest{Text: "hello there", TargetLang: "French"},
+			want: 1,
+		},
+		{
+			name: "hints and register",
+			req: TranslateRequest{
+				Text:         "hello there",
+				TargetLang:   "German",
+				SourceLang:   "English",
+				Formality:    FormalityFormal,
+				Instructions: "Use Swiss spelling.",
+			},
+			want: 1,
+		},
+		{
+			name: "spoiler translated on its own",
+			req:  TranslateRequest{Text: "the end is ||a twist||", TargetLang: "French"},
+			want: 2,
+		},
+		{
+			name: "glossary",
+			req:  TranslateRequest{Text: "join the Salin server", TargetLang: "Spanish"},
+			opts: tokenOptions{terms: map[string]string{"Salin": "Salin"}},
+			want: 1,
+		},
+		{
+			name: "nothing to send",
+			req:  TranslateRequest{Text: "<t:1700000000:R>", TargetLang: "French"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := &fakeTranslator{}
+			if _, err := translateProtected(context.Background(), translator, tt.req, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			var sent []string
+			for _, req := range translator.calls {
+				sent = append(sent, translationPrompt(req))
+			}
+
+			preview := previewPrompts(tt.req, tt.opts)
+			if len(preview) != tt.want {
+				t.Fatalf("previewed %d prompts, want %d", len(preview), tt.want)
+			}
+			slices.Sort(sent)
+			slices.Sort(preview)
+			if !slices.Equal(preview, sent) {
+				t.Errorf("previewPrompts() = %q, want the prompts sent, %q", preview, sent)
+			}
+		})
+	}
+}
+
+func TestPreviewPromptCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   string
+		text   string
+		want   []string
+		wantNo string
+	}{
+		{
+			name:   "owner sees the prompt",
+			user:   "owner",
+			text:   "see ```code``` here",
+			want:   []string{"Prompts for fake:", "Translate the following text to French.", "see `\u200b``code`\u200b`` here"},
+			wantNo: "see ```code",
+		},
+		{name: "others can't", user: "member", text: "hello", want: []string{"Only the bot owner"}},
+		{name: "nothing to send", user: "owner", text: "<t:1700000000>", want: []string{"Nothing in that text"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{OwnerID: "owner"}, translator)
+
+			h.previewPromptCommand(s, commandInteraction("preview-prompt", tt.user, map[string]string{"text": tt.text, "language": "French"}))
+
+			replies := ephemeralReplies(t, fake)
+			if len(replies) != 1 {
+				t.Fatalf("got %d replies, want 1", len(replies))
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(replies[0], want) {
+					t.Errorf("reply = %q, want it to contain %q", replies[0], want)
+				}
+			}
+			if tt.wantNo != "" && strings.Contains(replies[0], tt.wantNo) {
+				t.Errorf("reply = %q, should not contain %q", replies[0], tt.wantNo)
+			}
+			if translator.count() != 0 {
+				t.Errorf("called the provider %d times, want none", translator.count())
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// qualityScore is the structured reply expected from a quality check
+type qualityScore struct {
+	Score int `json:"score"`
+}
+
+// qualityPrompt asks the model to rate how faithful and natural a
+// translation is
+func qualityPrompt(original, translation, targetLang string) string {
+	return fmt.Sprintf("Rate how accurate and natural this %s translation of the original text is, "+
+		"from 1 (wrong or unusable) to 10 (perfect). Respond with a JSON object with one integer field "+
+		"\"score\".\n\nOriginal: %s\n\nTranslation: %s", targetLang, original, translation)
+}
+
+// parseQualityScore decodes a quality check reply
+func parseQualityScore(reply string) (int, error) {
+	var q qualityScore
+	if err := json.Unmarshal([]byte(reply), &q); err != nil {
+		return 0, fmt.Errorf("error decoding quality score: %v", err)
+	}
+	if q.Score < 1 || q.Score > 10 {
+		return 0, fmt.Errorf("quality score %d is out of range", q.Score)
+	}
+	return q.Score, nil
+}
+
+// checkQuality scores translation and, below QUALITY_THRESHOLD, translates
+// again with the escalation model. The first translation is kept when the
+// check or the retry fails.
+func (h *DiscordHandler) checkQuality(ctx context.Context, text, translation, targetLang string, run func(Translator) (string, error)) string {
+	reply, err := h.completer.CompleteJSON(ctx, qualityPrompt(text, translation, targetLang))
+	if err != nil {
+		log.Printf("Error checking translation quality: %v", err)
+		return translation
+	}
+	score, err := parseQualityScore(reply)
+	if err != nil {
+		log.Printf("Error checking translation quality: %v", err)
+		return translation
+	}
+	if score >= h.config.QualityThreshold {
+		return translation
+	}
+
+	log.Printf("Translation scored %d, retrying with %s", score, h.escalation.Name())
+	escalated, err := run(h.escalation)
+	if err != nil {
+		log.Printf("Error retranslating with %s: %v", h.escalation.Name(), err)
+		return translation
+	}
+	return escalated
+}
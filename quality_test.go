@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseQualityScore(t *testing.T) {
+	tests := []struct {
+		reply   string
+		want    int
+		wantErr bool
+	}{
+		{reply: `{"score":8}`, want: 8},
+		{reply: `{"score":1}`, want: 1},
+		{reply: `{"score":0}`, wantErr: true},
+		{reply: `{"score":11}`, wantErr: true},
+		{reply: `8`, wantErr: true},
+		{reply: `{"score":"high"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reply, func(t *testing.T) {
+			got, err := parseQualityScore(tt.reply)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseQualityScore() = %d, %v, want %d, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQualityEscalation(t *testing.T) {
+	tests := []struct {
+		name          string
+		channel       string
+		score         string
+		escalationErr error
+		want          string
+		wantChecks    int
+		wantEscalated bool
+	}{
+		{name: "low confidence escalates", channel: "c1", score: `{"score":3}`, want: "better", wantChecks: 1, wantEscalated: true},
+		{name: "good enough kept", channel: "c1", score: `{"score":7}`, want: "translated: hello", wantChecks: 1},
+		{name: "unreadable score kept", channel: "c1", score: `great`, want: "translated: hello", wantChecks: 1},
+		{name: "failed escalation keeps the first", channel: "c1", score: `{"score":2}`, escalationErr: errors.New("down"), want: "translated: hello", wantChecks: 1, wantEscalated: true},
+		{name: "other channels unchecked", channel: "c2", score: `{"score":1}`, want: "translated: hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, Config{QualityChannels: []string{"c1"}, QualityThreshold: 7}, &fakeTranslator{})
+			completer := &fakeCompleter{reply: tt.score}
+			h.completer = completer
+			escalation := &fakeTranslator{reply: func(TranslateRequest) (string, error) { return "better", tt.escalationErr }}
+			h.escalation = escalation
+
+			got, err := h.translate(context.Background(), "g1", tt.channel, "hello", "French")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("translate() = %q, want %q", got, tt.want)
+			}
+			if completer.count() != tt.wantChecks {
+				t.Errorf("checked quality %d times, want %d", completer.count(), tt.wantChecks)
+			}
+			if escalated := escalation.count() > 0; escalated != tt.wantEscalated {
+				t.Errorf("escalated = %v, want %v", escalated, tt.wantEscalated)
+			}
+		})
+	}
+}
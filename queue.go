@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Added to a message while its translation waits out a rate limit
+	queuedEmoji = "⏳"
+
+	// Wait used when the provider doesn't say how long to back off
+	defaultRetryAfter = 5 * time.Second
+)
+
+// deferOnRateLimit retries fn, which the provider turned away with err for
+// going over its rate limit, waiting as long as the provider asks each
+// time. It gives up with the last error once waiting longer would pass
+// maxDefer, or when ctx is done.
+func deferOnRateLimit(ctx context.Context, maxDefer time.Duration, err error, fn func() (translationResult, error)) (translationResult, error) {
+	deadline := time.Now().Add(maxDefer)
+	for {
+		wait := retryAfter(err)
+		if wait <= 0 {
+			wait = defaultRetryAfter
+		}
+		if time.Now().Add(wait).After(deadline) {
+			return translationResult{}, err
+		}
+		log.Printf("Rate limited by the provider, retrying in %s", wait)
+		select {
+		case <-ctx.Done():
+			return translationResult{}, err
+		case <-time.After(wait):
+		}
+
+		var result translationResult
+		result, err = fn()
+		if !isRateLimited(err) {
+			return result, err
+		}
+	}
+}
+
+// queues reports whether a translation that failed with err waits in the
+// queue for another try
+func (h *DiscordHandler) queues(err error) bool {
+	return h.config.QueueRateLimited && isRateLimited(err)
+}
+
+// translateQueued retries a reaction translation the provider rate limited
+// with err for up to MAX_QUEUE_DELAY, with the message marked so the user
+// knows it's waiting. Shutting down ends the wait.
+func (h *DiscordHandler) translateQueued(s *discordgo.Session, r *discordgo.MessageReactionAdd, text, targetLang string, err error) (translationResult, error) {
+	if err := s.MessageReactionAdd(r.ChannelID, r.MessageID, queuedEmoji); err != nil {
+		log.Printf("Error adding queued reaction: %v", err)
+	}
+	defer func() {
+		if err := s.MessageReactionRemove(r.ChannelID, r.MessageID, queuedEmoji, "@me"); err != nil {
+			log.Printf("Error removing queued reaction: %v", err)
+		}
+	}()
+	result, err := deferOnRateLimit(h.ctx, h.config.MaxQueueDelay, err, func() (translationResult, error) {
+		return h.translateDetailed(h.ctx, r.GuildID, r.ChannelID, text, targetLang, "")
+	})
+	if isRateLimited(err) {
+		h.recordDropped(DroppedExpired, r.GuildID, r.UserID)
+	}
+	return result, err
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeferOnRateLimit(t *testing.T) {
+	limited := func(wait time.Duration) error {
+		return &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: wait}
+	}
+	errOther := errors.New("other failure")
+	tests := []struct {
+		name     string
+		maxDefer time.Duration
+		first    time.Duration
+		// What each retry returns, in order
+		results   []error
+		cancel    bool
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "completes after waiting", maxDefer: time.Second, first: time.Millisecond, results: []error{nil}, wantCalls: 1},
+		{name: "limited again then completes", maxDefer: time.Second, first: time.Millisecond, results: []error{limited(time.Millisecond), nil}, wantCalls: 2},
+		{name: "other error ends it", maxDefer: time.Second, first: time.Millisecond, results: []error{errOther}, wantCalls: 1, wantErr: errOther},
+		{name: "wait beyond max defer", maxDefer: 10 * time.Millisecond, first: time.Minute, wantCalls: 0, wantErr: limited(0)},
+		{name: "later wait beyond max defer", maxDefer: 50 * time.Millisecond, first: time.Millisecond, results: []error{limited(time.Minute)}, wantCalls: 1, wantErr: limited(0)},
+		{name: "canceled", maxDefer: time.Minute, first: 30 * time.Second, cancel: true, wantCalls: 0, wantErr: limited(0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			calls := 0
+			result, err := deferOnRateLimit(ctx, tt.maxDefer, limited(tt.first), func() (translationResult, error) {
+				err := tt.results[calls]
+				calls++
+				if err != nil {
+					return translationResult{}, err
+				}
+				return translationResult{Text: "done"}, nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("fn called %d times, want %d", calls, tt.wantCalls)
+			}
+			switch {
+			case tt.wantErr == nil && (err != nil || result.Text != "done"):
+				t.Errorf("deferOnRateLimit() = %q, %v, want done", result.Text, err)
+			case tt.wantErr != nil && isRateLimited(tt.wantErr) && !isRateLimited(err):
+				t.Errorf("deferOnRateLimit() error = %v, want a rate limit", err)
+			case tt.wantErr != nil && !isRateLimited(tt.wantErr) && !errors.Is(err, tt.wantErr):
+				t.Errorf("deferOnRateLimit() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueuedTranslationLetsOthersThrough(t *testing.T) {
+	s, fake := newTestSession(t)
+	var mu sync.Mutex
+	frenchCalls := 0
+	queued := make(chan struct{})
+	translator := &fakeTranslator{reply: func(req TranslateRequest) (string, error) {
+		if req.TargetLang != "French" {
+			return "hallo", nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		frenchCalls++
+		if frenchCalls == 1 {
+			close(queued)
+			return "", &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 200 * time.Millisecond}
+		}
+		return "bonjour", nil
+	}}
+	h := newTestHandler(t, Config{QueueRateLimited: true, MaxQueueDelay: time.Minute}, translator)
+	h.messageLocks = newKeyedMutex()
+	msg := testMessage("hello")
+
+	done := make(chan struct{})
+	go func() {
+		h.deliverTranslation(s, testReaction("u1", "🇫🇷"), msg, "hello", nil, "French")
+		close(done)
+	}()
+	<-queued
+	started := time.Now()
+	h.deliverTranslation(s, testReaction("u2", "🇩🇪"), msg, "hello", nil, "German")
+	if waited := time.Since(started); waited > 100*time.Millisecond {
+		t.Errorf("German translation waited %s behind the queued one", waited)
+	}
+	<-done
+
+	if posted := sentMessages(t, fake, "c1"); len(posted) != 2 {
+		t.Errorf("posted %d translations, want 2", len(posted))
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// quietHours is a daily window, in a guild's own timezone, during which
+// reaction and mirror translations are paused
+type quietHours struct {
+	// Minutes after midnight; a window with end before start runs past
+	// midnight
+	start, end int
+	loc        *time.Location
+}
+
+// parseClock reads a 24-hour HH:MM time as minutes after midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseQuietHours reads a window such as 22:00-07:00 in an IANA timezone
+// such as Europe/Paris; an empty timezone means UTC
+func parseQuietHours(window, timezone string) (quietHours, error) {
+	from, to, ok := strings.Cut(window, "-")
+	if !ok {
+		return quietHours{}, fmt.Errorf("quiet hours %q must look like 22:00-07:00", window)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return quietHours{}, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return quietHours{}, err
+	}
+	if start == end {
+		return quietHours{}, fmt.Errorf("quiet hours %q are empty", window)
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return quietHours{}, fmt.Errorf("unknown timezone %q", timezone)
+	}
+	return quietHours{start: start, end: end, loc: loc}, nil
+}
+
+// active reports whether now falls inside the window, taking the start as
+// inside and the end as outside
+func (q quietHours) active(now time.Time) bool {
+	local := now.In(q.loc)
+	minute := local.Hour()*60 + local.Minute()
+	if q.start < q.end {
+		return minute >= q.start && minute < q.end
+	}
+	return minute >= q.start || minute < q.end
+}
+
+// inQuietHours reports whether a guild's quiet hours are on at now
+func (h *DiscordHandler) inQuietHours(guildID string, now time.Time) bool {
+	c := h.guildConfig(guildID)
+	if c.QuietHours == "" {
+		return false
+	}
+	q, err := parseQuietHours(c.QuietHours, c.QuietTimezone)
+	if err != nil {
+		// Checked when the config was loaded
+		return false
+	}
+	return q.active(now)
+}
+
+// quietReaction turns a reaction away during quiet hours, telling the user
+// why if NOTIFY_QUIET_HOURS is set
+func (h *DiscordHandler) quietReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd) bool {
+	if !h.inQuietHours(r.GuildID, time.Now()) {
+		return false
+	}
+	if h.config.NotifyQuietHours {
+		h.notify(s, r.UserID, noticeQuietHours, "Reaction translations are paused on this server right now. Commands still work.")
+	}
+	return true
+}
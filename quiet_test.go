@@ -0,0 +1,104 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   string
+		timezone string
+		wantErr  bool
+	}{
+		{"overnight", "22:00-07:00", "", false},
+		{"daytime", "09:30-17:00", "Asia/Tokyo", false},
+		{"spaces", " 22:00 - 07:00 ", "", false},
+		{"no dash", "22:00", "", true},
+		{"bad clock", "25:00-07:00", "", true},
+		{"empty window", "08:00-08:00", "", true},
+		{"unknown timezone", "22:00-07:00", "Mars/Olympus", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseQuietHours(tt.window, tt.timezone)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseQuietHours(%q, %q) error = %v, wantErr %v", tt.window, tt.timezone, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuietHoursActive(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   string
+		timezone string
+		now      time.Time
+		want     bool
+	}{
+		{"overnight before midnight", "22:00-07:00", "", time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC), true},
+		{"overnight after midnight", "22:00-07:00", "", time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC), true},
+		{"overnight midday", "22:00-07:00", "", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), false},
+		{"start is inside", "22:00-07:00", "", time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC), true},
+		{"end is outside", "22:00-07:00", "", time.Date(2024, 1, 1, 7, 0, 0, 0, time.UTC), false},
+		{"daytime inside", "09:00-17:00", "", time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC), true},
+		{"daytime outside", "09:00-17:00", "", time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC), false},
+		// 14:00 UTC is 23:00 in Tokyo
+		{"guild timezone", "22:00-07:00", "Asia/Tokyo", time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC), true},
+		{"guild timezone outside", "22:00-07:00", "Asia/Tokyo", time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := parseQuietHours(tt.window, tt.timezone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := q.active(tt.now); got != tt.want {
+				t.Errorf("active(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietHoursPauseEveryReaction(t *testing.T) {
+	now := time.Now().UTC()
+	window := now.Add(-time.Hour).Format("15:04") + "-" + now.Add(time.Hour).Format("15:04")
+	tests := []struct {
+		name  string
+		react func(h *DiscordHandler, s *discordgo.Session)
+	}{
+		{"translate", func(h *DiscordHandler, s *discordgo.Session) {
+			h.translateReaction(s, testReaction("u1", "🇫🇷"), testMessage("hello"), "French")
+		}},
+		{"clarify", func(h *DiscordHandler, s *discordgo.Session) {
+			h.clarifyReaction(s, testReaction("u1", "🤔"), testMessage("hello"))
+		}},
+		{"describe", func(h *DiscordHandler, s *discordgo.Session) {
+			msg := testMessage("hello")
+			msg.Reactions = []*discordgo.MessageReactions{{Count: 2, Emoji: &discordgo.Emoji{Name: "👍"}}}
+			h.describeReactions(s, testReaction("u1", "👀"), msg)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			translator := &fakeTranslator{}
+			completer := &fakeCompleter{reply: "an explanation"}
+			h := newTestHandler(t, Config{QuietHours: window, NotifyQuietHours: true, DescribeReactionsEmoji: "👀"}, translator)
+			h.completer = completer
+
+			tt.react(h, s)
+
+			if translator.count() != 0 || completer.count() != 0 {
+				t.Errorf("provider called %d times during quiet hours", translator.count()+completer.count())
+			}
+			if dms := fake.sent("/channels/dm/messages"); len(dms) != 1 {
+				t.Errorf("sent %d quiet hours notices, want 1", len(dms))
+			}
+		})
+	}
+}
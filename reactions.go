@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// describeReactionsText lists the reactions on msg in plain English, one
+// per line, e.g. "3 people reacted with 👍". The describe emoji itself is
+// left out, and for custom emoji only the name is used.
+func describeReactionsText(reactions []*discordgo.MessageReactions, describeEmoji string) string {
+	var lines []string
+	for _, reaction := range reactions {
+		if reaction.Emoji == nil || reaction.Emoji.Name == describeEmoji || reaction.Count == 0 {
+			continue
+		}
+		emoji := reaction.Emoji.Name
+		if reaction.Emoji.ID != "" {
+			emoji = fmt.Sprintf("the custom emoji :%s:", reaction.Emoji.Name)
+		}
+		people := "people"
+		if reaction.Count == 1 {
+			people = "person"
+		}
+		lines = append(lines, fmt.Sprintf("%d %s reacted with %s", reaction.Count, people, emoji))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// describeReactionsPrompt asks for the description in lang with every emoji
+// spelled out, since screen readers don't always announce them well
+func describeReactionsPrompt(description, lang string) string {
+	return fmt.Sprintf("The following lines describe the emoji reactions on a chat message. "+
+		"Rewrite them in %s for a screen reader user, replacing each emoji with its name in words "+
+		"and keeping one reaction per line. Only respond with the lines, nothing else:\n%s", lang, description)
+}
+
+// describeReactions posts an embed describing the reactions on msg
+func (h *DiscordHandler) describeReactions(s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message) {
+	description := describeReactionsText(msg.Reactions, h.config.DescribeReactionsEmoji)
+	if description == "" {
+		h.skip(s, r, "message has no other reactions")
+		return
+	}
+	lang := h.config.DescribeReactionsLang
+	// Custom emoji are named by whoever added them
+	if !h.allowContent(r.UserID, description, lang) {
+		h.notify(s, r.UserID, noticeAbuse, abuseNotice)
+		return
+	}
+	if !h.admitReaction(s, r) {
+		return
+	}
+
+	reply, err := h.completer.Complete(h.guildContext(context.Background(), r.GuildID), describeReactionsPrompt(description, lang))
+	if err != nil {
+		log.Printf("Error describing reactions: %v", err)
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Reactions",
+		Description: truncate(strings.TrimSpace(reply), maxDescriptionLength),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Described in %s", lang),
+		},
+		Color: 0x00BFFF, // Light blue color
+	}
+	if _, _, err := h.postReactionEmbed(s, r, msg, embed); err != nil {
+		log.Printf("Error sending reaction description: %v", err)
+	}
+}
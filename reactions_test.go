@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDescribeReactionsText(t *testing.T) {
+	reaction := func(name, id string, count int) *discordgo.MessageReactions {
+		return &discordgo.MessageReactions{Emoji: &discordgo.Emoji{Name: name, ID: id}, Count: count}
+	}
+	tests := []struct {
+		name      string
+		reactions []*discordgo.MessageReactions
+		want      string
+	}{
+		{
+			name: "several reactions",
+			reactions: []*discordgo.MessageReactions{
+				reaction("👍", "", 3),
+				reaction("😂", "", 1),
+				reaction("partyparrot", "123", 2),
+			},
+			want: "3 people reacted with 👍\n1 person reacted with 😂\n2 people reacted with the custom emoji :partyparrot:",
+		},
+		{
+			name: "describe emoji left out",
+			reactions: []*discordgo.MessageReactions{
+				reaction("🔊", "", 1),
+				reaction("👍", "", 2),
+			},
+			want: "2 people reacted with 👍",
+		},
+		{
+			name: "empty reactions skipped",
+			reactions: []*discordgo.MessageReactions{
+				{Count: 4},
+				reaction("👍", "", 0),
+			},
+		},
+		{name: "no reactions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeReactionsText(tt.reactions, "🔊"); got != tt.want {
+				t.Errorf("describeReactionsText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeReactionsPrompt(t *testing.T) {
+	description := "3 people reacted with 👍\n1 person reacted with 😂"
+	prompt := describeReactionsPrompt(description, "Spanish")
+	if !strings.Contains(prompt, "in Spanish") {
+		t.Errorf("prompt = %q, want it to name the language", prompt)
+	}
+	if !strings.HasSuffix(prompt, ":\n"+description) {
+		t.Errorf("prompt = %q, want it to end with the description", prompt)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Longest thread name Discord accepts
+const maxThreadNameLength = 100
+
+// Embed space kept free for the read more field
+const readMoreRoom = 128
+
+// previewText cuts s down to a preview of at most max runes, at a paragraph,
+// line or word boundary where possible. ok is false if s already fits.
+func previewText(s string, max int) (preview string, ok bool) {
+	if len([]rune(s)) <= max {
+		return s, false
+	}
+	// Leave room for the ellipsis
+	return splitText(s, max-1)[0] + "…", true
+}
+
+// readMoreField links to the thread holding the full text
+func readMoreField(thread *discordgo.Channel) *discordgo.MessageEmbedField {
+	return &discordgo.MessageEmbedField{
+		Name:  "Read more",
+		Value: fmt.Sprintf("[Full translation](https://discord.com/channels/%s/%s)", thread.GuildID, thread.ID),
+	}
+}
+
+// sendWithLink posts a long translation as a preview, then posts the full
+// text in a thread started from it and links the thread from the preview.
+// The preview is left without a link if the thread can't be made.
+func (h *DiscordHandler) sendWithLink(s *discordgo.Session, channelID string, embed *discordgo.MessageEmbed, reference *discordgo.MessageReference) (*discordgo.Message, error) {
+	full := embed.Description
+	preview := *embed
+	preview.Description, _ = previewText(full, min(h.config.TruncateLength, descriptionRoom(embed, readMoreRoom)))
+	msg, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{&preview},
+		Reference:       reference,
+		AllowedMentions: h.allowedMentions(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	name := "Translation"
+	if embed.Title != "" {
+		name = embed.Title
+	}
+	thread, err := s.MessageThreadStart(channelID, msg.ID, truncate(name, maxThreadNameLength), 60)
+	if err != nil {
+		log.Printf("Error starting full translation thread: %v", err)
+		return msg, nil
+	}
+	if err := h.sendPlain(s, thread.ID, full); err != nil {
+		log.Printf("Error sending full translation: %v", err)
+		return msg, nil
+	}
+
+	preview.Fields = append(preview.Fields, readMoreField(thread))
+	if _, err := s.ChannelMessageEditEmbeds(channelID, msg.ID, []*discordgo.MessageEmbed{&preview}); err != nil {
+		log.Printf("Error linking full translation: %v", err)
+	}
+	return msg, nil
+}
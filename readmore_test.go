@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPreviewText(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		max    int
+		want   string
+		wantOK bool
+	}{
+		{name: "shorter", text: "hello", max: 10, want: "hello"},
+		{name: "exactly the limit", text: "hello", max: 5, want: "hello"},
+		{name: "one over", text: "hello world", max: 10, want: "hello…", wantOK: true},
+		{name: "at a line break", text: "first line\nsecond line", max: 15, want: "first line…", wantOK: true},
+		{name: "within a long word", text: "abcdefghij", max: 5, want: "abcd…", wantOK: true},
+		{name: "counts runes", text: "ñññññ", max: 5, want: "ñññññ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := previewText(tt.text, tt.max)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("previewText(%q, %d) = %q, %v, want %q, %v", tt.text, tt.max, got, ok, tt.want, tt.wantOK)
+			}
+			if n := len([]rune(got)); n > tt.max {
+				t.Errorf("preview is %d runes, over the limit of %d", n, tt.max)
+			}
+		})
+	}
+}
+
+func TestSendWithLink(t *testing.T) {
+	full := strings.Repeat("word ", 40)
+	tests := []struct {
+		name         string
+		description  string
+		threadStatus int
+		wantPreview  bool
+		wantLink     bool
+	}{
+		{name: "short translation", description: "hello"},
+		{name: "long translation", description: full, wantPreview: true, wantLink: true},
+		{name: "thread failing", description: full, threadStatus: http.StatusForbidden, wantPreview: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages"] = `{"id":"p1","channel_id":"c1"}`
+			fake.replies["/channels/c1/messages/p1/threads"] = `{"id":"t1","guild_id":"g1"}`
+			if tt.threadStatus != 0 {
+				fake.statuses = map[string]int{"/threads": tt.threadStatus}
+			}
+			h := newTestHandler(t, Config{TruncateWithLink: true, TruncateLength: 50}, &fakeTranslator{})
+
+			if _, err := h.sendPaged(s, "c1", &discordgo.MessageEmbed{Description: tt.description}, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			messages := sentMessages(t, fake, "c1")
+			if len(messages) != 1 || len(messages[0].Embeds) != 1 {
+				t.Fatalf("sent %+v, want one embed", messages)
+			}
+			preview := messages[0].Embeds[0].Description
+			if !tt.wantPreview {
+				if preview != tt.description || len(fake.sent("/threads")) != 0 {
+					t.Errorf("description = %q, want %q without a thread", preview, tt.description)
+				}
+				return
+			}
+			if len([]rune(preview)) > 50 || !strings.HasSuffix(preview, "…") {
+				t.Errorf("preview = %q, want at most 50 characters ending in an ellipsis", preview)
+			}
+
+			edits := fake.sent("/channels/c1/messages/p1")
+			if !tt.wantLink {
+				if len(edits) != 0 {
+					t.Errorf("edited the preview %d times, want it left alone", len(edits))
+				}
+				return
+			}
+			if thread := sentMessages(t, fake, "t1"); len(thread) != 1 || thread[0].Content != full {
+				t.Errorf("thread got %+v, want the full translation", thread)
+			}
+			if len(edits) != 1 {
+				t.Fatalf("edited the preview %d times, want 1", len(edits))
+			}
+			var edit discordgo.MessageEdit
+			if err := json.Unmarshal([]byte(edits[0].Body), &edit); err != nil {
+				t.Fatal(err)
+			}
+			fields := (*edit.Embeds)[0].Fields
+			if len(fields) != 1 || fields[0].Value != "[Full translation](https://discord.com/channels/g1/t1)" {
+				t.Errorf("fields = %+v, want a link to the thread", fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Register instructions for languages whose politeness is grammatical, used
+// in place of the generic formality instructions
+var languageRegisterInstructions = map[string]map[Formality]string{
+	"japanese": {
+		FormalityFormal:   "Use polite Japanese: desu/masu forms, with keigo where it fits.",
+		FormalityInformal: "Use casual Japanese: plain forms, no keigo.",
+	},
+	"korean": {
+		FormalityFormal:   "Use polite Korean speech (jondaetmal), such as the -yo or -seumnida endings.",
+		FormalityInformal: "Use casual Korean speech (banmal).",
+	},
+	"german": {
+		FormalityFormal:   "Address the reader formally as Sie.",
+		FormalityInformal: "Address the reader informally as du.",
+	},
+}
+
+// formalityInstruction returns the prompt instruction for a register in a
+// target language, or "" for the model's default register
+func formalityInstruction(targetLang string, f Formality) string {
+	if instructions, ok := languageRegisterInstructions[strings.ToLower(strings.TrimSpace(targetLang))]; ok {
+		if instruction, ok := instructions[f]; ok {
+			return instruction
+		}
+	}
+	return formalityInstructions[f]
+}
+
+// parseLanguageRegisters checks a LANGUAGE_REGISTER setting, which maps
+// target languages to formal or informal
+func parseLanguageRegisters(registers map[string]string) (map[string]Formality, error) {
+	parsed := make(map[string]Formality, len(registers))
+	for lang, register := range registers {
+		f := parseFormality(register)
+		if f == FormalityDefault {
+			return nil, fmt.Errorf("register %q for %s must be formal or informal", register, lang)
+		}
+		parsed[strings.ToLower(strings.TrimSpace(lang))] = f
+	}
+	return parsed, nil
+}
+
+// formalityFor returns the register to translate to targetLang in: the
+// guild's own formality if it set one, else the language's register
+func (h *DiscordHandler) formalityFor(guildID, targetLang string) Formality {
+	if f := parseFormality(h.guildConfig(guildID).Formality); f != FormalityDefault {
+		return f
+	}
+	return h.registers[strings.ToLower(strings.TrimSpace(targetLang))]
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLanguageRegisters(t *testing.T) {
+	tests := []struct {
+		name      string
+		registers map[string]string
+		want      map[string]Formality
+		wantErr   bool
+	}{
+		{
+			name:      "formal and informal",
+			registers: map[string]string{"Japanese": "polite", " Korean ": "casual", "German": "formal"},
+			want:      map[string]Formality{"japanese": FormalityFormal, "korean": FormalityInformal, "german": FormalityFormal},
+		},
+		{name: "unknown register", registers: map[string]string{"Japanese": "humble"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLanguageRegisters(tt.registers)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseLanguageRegisters() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLanguageRegisters() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterPrompt(t *testing.T) {
+	polite := languageRegisterInstructions["japanese"][FormalityFormal]
+	casual := languageRegisterInstructions["japanese"][FormalityInformal]
+	tests := []struct {
+		name      string
+		registers map[string]string
+		guild     string
+		lang      string
+		want      string
+		not       []string
+	}{
+		{name: "Japanese polite", registers: map[string]string{"Japanese": "polite"}, lang: "Japanese", want: polite, not: []string{casual}},
+		{name: "Japanese casual", registers: map[string]string{"Japanese": "casual"}, lang: "Japanese", want: casual, not: []string{polite}},
+		{name: "any case", registers: map[string]string{"japanese": "polite"}, lang: "JAPANESE", want: polite},
+		{name: "generic instruction for other languages", registers: map[string]string{"French": "formal"}, lang: "French", want: formalityInstructions[FormalityFormal]},
+		{
+			name:      "language without a register",
+			registers: map[string]string{"Japanese": "polite"},
+			lang:      "Spanish",
+			not:       []string{polite, formalityInstructions[FormalityFormal], formalityInstructions[FormalityInformal]},
+		},
+		{name: "guild formality wins", registers: map[string]string{"Japanese": "polite"}, guild: "informal", lang: "Japanese", want: casual, not: []string{polite}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{}, translator)
+			var err error
+			if h.registers, err = parseLanguageRegisters(tt.registers); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.guildConfigs.set("g1", GuildConfig{Version: guildConfigVersion, Formality: tt.guild}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := h.translate(context.Background(), "g1", "c1", "thank you", tt.lang); err != nil {
+				t.Fatal(err)
+			}
+			prompt := translationPrompt(translator.calls[0])
+			if tt.want != "" && !strings.Contains(prompt, tt.want) {
+				t.Errorf("prompt = %q, want %q", prompt, tt.want)
+			}
+			for _, not := range tt.not {
+				if strings.Contains(prompt, not) {
+					t.Errorf("prompt = %q, should not contain %q", prompt, not)
+				}
+			}
+		})
+	}
+}
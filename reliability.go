@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// What happens to translations into languages not in RELIABLE_LANGUAGES
+const (
+	unreliableWarn  = "warn"
+	unreliableBlock = "block"
+)
+
+// Added to the footer of translations into a language that isn't reliable
+const unreliableWarning = "Translation quality may be limited for this language"
+
+// errUnreliableLanguage is returned for translations into a language that
+// isn't reliable when the policy is to block them
+var errUnreliableLanguage = errors.New("target language is not in RELIABLE_LANGUAGES")
+
+// reliableLanguage reports whether the model can be trusted to translate
+// into targetLang. With no allowlist every language is.
+func (h *DiscordHandler) reliableLanguage(targetLang string) bool {
+	if len(h.config.ReliableLanguages) == 0 {
+		return true
+	}
+	for _, lang := range h.config.ReliableLanguages {
+		if strings.EqualFold(strings.TrimSpace(lang), strings.TrimSpace(targetLang)) {
+			return true
+		}
+	}
+	return false
+}
+
+// blocksLanguage reports whether translations into targetLang are refused
+func (h *DiscordHandler) blocksLanguage(targetLang string) bool {
+	return h.config.UnreliablePolicy == unreliableBlock && !h.reliableLanguage(targetLang)
+}
+
+// qualityWarning returns the footer warning for targetLang, or "" if none is
+// needed
+func (h *DiscordHandler) qualityWarning(targetLang string) string {
+	if h.config.UnreliablePolicy == unreliableWarn && !h.reliableLanguage(targetLang) {
+		return unreliableWarning
+	}
+	return ""
+}
+
+// withQualityWarning adds the warning for targetLang, if any, to a line of
+// plain text
+func (h *DiscordHandler) withQualityWarning(text, targetLang string) string {
+	if warning := h.qualityWarning(targetLang); warning != "" {
+		return text + disclaimerSeparator + warning
+	}
+	return text
+}
+
+// unreliableNotice tells a user their language was refused
+func unreliableNotice(targetLang string) string {
+	return fmt.Sprintf("I can't translate to %s reliably, so translations to it are turned off here.", targetLang)
+}
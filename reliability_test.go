@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReliabilityPolicy(t *testing.T) {
+	reliable := []string{"English", " French "}
+	tests := []struct {
+		name        string
+		reliable    []string
+		policy      string
+		lang        string
+		wantWarning bool
+		wantBlock   bool
+	}{
+		{name: "warn, allowlisted", reliable: reliable, policy: unreliableWarn, lang: "French"},
+		{name: "warn, any case", reliable: reliable, policy: unreliableWarn, lang: "english"},
+		{name: "warn, not allowlisted", reliable: reliable, policy: unreliableWarn, lang: "Tok Pisin", wantWarning: true},
+		{name: "block, allowlisted", reliable: reliable, policy: unreliableBlock, lang: "French"},
+		{name: "block, not allowlisted", reliable: reliable, policy: unreliableBlock, lang: "Tok Pisin", wantBlock: true},
+		{name: "no allowlist", policy: unreliableBlock, lang: "Tok Pisin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, Config{ReliableLanguages: tt.reliable, UnreliablePolicy: tt.policy}, &fakeTranslator{})
+			if got := h.qualityWarning(tt.lang) != ""; got != tt.wantWarning {
+				t.Errorf("qualityWarning(%s) = %q, want a warning: %v", tt.lang, h.qualityWarning(tt.lang), tt.wantWarning)
+			}
+			if got := h.blocksLanguage(tt.lang); got != tt.wantBlock {
+				t.Errorf("blocksLanguage(%s) = %v, want %v", tt.lang, got, tt.wantBlock)
+			}
+			wantLine := "Spanish"
+			if tt.wantWarning {
+				wantLine = "Spanish • " + unreliableWarning
+			}
+			if got := h.withQualityWarning("Spanish", tt.lang); got != wantLine {
+				t.Errorf("withQualityWarning() = %q, want %q", got, wantLine)
+			}
+		})
+	}
+}
+
+func TestUnreliableLanguageReaction(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		flag        string
+		wantFooter  string
+		wantBlocked bool
+	}{
+		{name: "warn, allowlisted", policy: unreliableWarn, flag: "🇫🇷", wantFooter: "Translated to French"},
+		{name: "warn, not allowlisted", policy: unreliableWarn, flag: "🇹🇭", wantFooter: "Translated to Thai • " + unreliableWarning},
+		{name: "block, allowlisted", policy: unreliableBlock, flag: "🇫🇷", wantFooter: "Translated to French"},
+		{name: "block, not allowlisted", policy: unreliableBlock, flag: "🇹🇭", wantBlocked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"author"}}`
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{ReliableLanguages: []string{"French"}, UnreliablePolicy: tt.policy}, translator)
+			h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+
+			h.reactionAdd(s, testReaction("user", tt.flag))
+
+			messages := sentMessages(t, fake, "c1")
+			if tt.wantBlocked {
+				if translator.count() != 0 || len(messages) != 0 {
+					t.Errorf("translated %d times and posted %d, want neither", translator.count(), len(messages))
+				}
+				if notices := sentMessages(t, fake, "dm"); len(notices) != 1 || notices[0].Content != unreliableNotice("Thai") {
+					t.Errorf("notices = %+v, want the refusal", notices)
+				}
+				return
+			}
+			if len(messages) != 1 || len(messages[0].Embeds) != 1 {
+				t.Fatalf("sent %+v, want one translation embed", messages)
+			}
+			if got := strings.TrimSuffix(messages[0].Embeds[0].Footer.Text, translationMarker); got != tt.wantFooter {
+				t.Errorf("footer = %q, want %q", got, tt.wantFooter)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	// How many more times an idempotent Discord read is tried after a
+	// transient failure, and how long to wait before the first retry
+	readRetries = 2
+	readBackoff = 500 * time.Millisecond
+)
+
+// isTransient reports whether err looks like a network hiccup or a Discord
+// outage rather than a real answer, so the same request may succeed later
+func isTransient(err error) bool {
+	if status := restStatus(err); status != 0 {
+		return status >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// retryRead runs read, retrying transient failures with exponential backoff.
+//
+// Only use it for reads such as fetching a message or guild: retrying them
+// is harmless. Sends, edits, reactions and deletes are not retried, since a
+// request that timed out may still have gone through and would be repeated.
+func retryRead(what string, read func() error) error {
+	backoff := readBackoff
+	err := read()
+	for attempt := 0; attempt < readRetries && err != nil && isTransient(err); attempt++ {
+		log.Printf("Retrying %s after transient error: %v", what, err)
+		time.Sleep(backoff)
+		backoff *= 2
+		err = read()
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// flakyTransport fails the first failures requests with err, or with
+// status when err is nil, then hands requests on to next
+type flakyTransport struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	status   int
+	attempts int
+	next     http.RoundTripper
+}
+
+func (f *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.attempts++
+	fail := f.attempts <= f.failures
+	f.mu.Unlock()
+	if !fail {
+		return f.next.RoundTrip(req)
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &http.Response{
+		StatusCode: f.status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"message":"unavailable"}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestIsTransient(t *testing.T) {
+	status := func(code int) error {
+		return &discordgo.RESTError{Response: &http.Response{StatusCode: code}}
+	}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server error", status(http.StatusServiceUnavailable), true},
+		{"not found", status(http.StatusNotFound), false},
+		{"forbidden", status(http.StatusForbidden), false},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"connection refused", syscall.ECONNREFUSED, true},
+		{"cut short", io.ErrUnexpectedEOF, true},
+		{"anything else", errors.New("bad request"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err); got != tt.want {
+				t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchMessageRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		status       int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "transient then success", failures: 1, err: syscall.ECONNRESET, wantAttempts: 2},
+		{name: "outage then success", failures: 1, status: http.StatusServiceUnavailable, wantAttempts: 2},
+		{name: "persistent failure", failures: 10, err: syscall.ECONNRESET, wantAttempts: 1 + readRetries, wantErr: true},
+		{name: "permanent failure not retried", failures: 10, status: http.StatusNotFound, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello"}`
+			flaky := &flakyTransport{failures: tt.failures, err: tt.err, status: tt.status, next: fake}
+			s.Client = &http.Client{Transport: flaky}
+			s.MaxRestRetries = 0
+
+			msg, err := fetchMessage(s, "c1", "m1")
+			if flaky.attempts != tt.wantAttempts {
+				t.Errorf("made %d attempts, want %d", flaky.attempts, tt.wantAttempts)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Error("fetchMessage() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg.Content != "hello" {
+				t.Errorf("content = %q, want hello", msg.Content)
+			}
+		})
+	}
+}
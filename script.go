@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Scripts detectScript can tell apart, in the order ties are broken
+var scriptTables = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Greek", unicode.Greek},
+	{"Arabic", unicode.Arabic},
+	{"Hebrew", unicode.Hebrew},
+	{"Han", unicode.Han},
+	{"Hiragana", unicode.Hiragana},
+	{"Katakana", unicode.Katakana},
+	{"Hangul", unicode.Hangul},
+	{"Thai", unicode.Thai},
+	{"Devanagari", unicode.Devanagari},
+}
+
+// Spans that stay in their own script whatever the language: links,
+// Discord markup such as mentions, and placeholders
+var scriptNoisePattern = regexp.MustCompile(`https?://\S+|<[^<>\s]+>|\{\{\d+\}\}`)
+
+// detectScript returns the script most of the letters in s are written in,
+// or "" if s has no letters in a known script
+func detectScript(s string) string {
+	s = scriptNoisePattern.ReplaceAllString(s, "")
+	counts := make([]int, len(scriptTables))
+	for _, r := range s {
+		for i, script := range scriptTables {
+			if unicode.Is(script.table, r) {
+				counts[i]++
+				break
+			}
+		}
+	}
+	best := -1
+	for i, n := range counts {
+		if n > 0 && (best < 0 || n > counts[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	return scriptTables[best].name
+}
+
+// Languages written in a script other than Latin, to the scripts their
+// text is mostly written in
+var languageScripts = map[string][]string{
+	"arabic":              {"Arabic"},
+	"persian":             {"Arabic"},
+	"urdu":                {"Arabic"},
+	"hebrew":              {"Hebrew"},
+	"russian":             {"Cyrillic"},
+	"ukrainian":           {"Cyrillic"},
+	"bulgarian":           {"Cyrillic"},
+	"greek":               {"Greek"},
+	"chinese":             {"Han"},
+	"traditional chinese": {"Han"},
+	"japanese":            {"Han", "Hiragana", "Katakana"},
+	"korean":              {"Hangul", "Han"},
+	"thai":                {"Thai"},
+	"hindi":               {"Devanagari"},
+}
+
+// scriptMismatch reports whether a translation to a non-Latin-script
+// language came back mostly in some other script, as when a model returns
+// the source unchanged. Languages without a known script are never flagged.
+func scriptMismatch(translation, targetLang string) bool {
+	want, ok := languageScripts[strings.ToLower(targetLang)]
+	if !ok {
+		return false
+	}
+	got := detectScript(translation)
+	return got != "" && !slices.Contains(want, got)
+}
+
+// scriptInstruction is added to the prompt when retrying after a mismatch
+func scriptInstruction(targetLang string) string {
+	scripts := languageScripts[strings.ToLower(targetLang)]
+	return fmt.Sprintf("The translation must be written in %s script, as %s is.", scripts[0], targetLang)
+}
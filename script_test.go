@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDetectScript(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"hello world", "Latin"},
+		{"привет мир", "Cyrillic"},
+		{"γεια σου κόσμε", "Greek"},
+		{"مرحبا بالعالم", "Arabic"},
+		{"שלום עולם", "Hebrew"},
+		{"你好世界", "Han"},
+		{"こんにちは", "Hiragana"},
+		{"コンピュータ", "Katakana"},
+		{"안녕하세요", "Hangul"},
+		{"สวัสดีชาวโลก", "Thai"},
+		{"नमस्ते दुनिया", "Devanagari"},
+		{"mostly english with один word", "Latin"},
+		{"спасибо https://example.com <@123> {{0}}", "Cyrillic"},
+		{"123 !?", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := detectScript(tt.text); got != tt.want {
+				t.Errorf("detectScript(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptMismatch(t *testing.T) {
+	tests := []struct {
+		translation string
+		lang        string
+		want        bool
+	}{
+		{"good morning", "Japanese", true},
+		{"おはようございます", "Japanese", false},
+		{"東京に行く", "Japanese", false},
+		{"good morning", "korean", true},
+		{"좋은 아침", "Korean", false},
+		{"good morning", "Russian", true},
+		{"доброе утро", "Russian", false},
+		{"good morning", "French", false},
+		{"123", "Japanese", false},
+	}
+	for _, tt := range tests {
+		if got := scriptMismatch(tt.translation, tt.lang); got != tt.want {
+			t.Errorf("scriptMismatch(%q, %s) = %v, want %v", tt.translation, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyScriptRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		verify    bool
+		lang      string
+		want      string
+		wantCalls int
+	}{
+		{name: "source sent back", verify: true, lang: "Japanese", want: "おはよう", wantCalls: 2},
+		{name: "check off", lang: "Japanese", want: "good morning", wantCalls: 1},
+		{name: "Latin script target", verify: true, lang: "French", want: "good morning", wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := &fakeTranslator{reply: func(req TranslateRequest) (string, error) {
+				if strings.Contains(req.Instructions, "must be written in") {
+					return "おはよう", nil
+				}
+				return req.Text, nil
+			}}
+			h := newTestHandler(t, Config{VerifyScript: tt.verify}, translator)
+
+			got, err := h.translate(context.Background(), "g1", "c1", "good morning", tt.lang)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("translate() = %q, want %q", got, tt.want)
+			}
+			if translator.count() != tt.wantCalls {
+				t.Fatalf("translated %d times, want %d", translator.count(), tt.wantCalls)
+			}
+			if tt.wantCalls > 1 {
+				if got := translator.calls[1].Instructions; got != scriptInstruction(tt.lang) {
+					t.Errorf("retry instructions = %q, want %q", got, scriptInstruction(tt.lang))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// statusCommand shows the bot owner whether the bot is ready and what it
+// has translated since starting
+func (h *DiscordHandler) statusCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if h.config.OwnerID == "" || interactionUser(i).ID != h.config.OwnerID {
+		respondEphemeral(s, i, "Only the bot owner can see the bot's status.")
+		return
+	}
+	respondEphemeral(s, i, statusReport(h.Metrics()))
+}
+
+// statusReport describes snap for the status command
+func statusReport(snap MetricsSnapshot) string {
+	var b strings.Builder
+	if snap.Ready {
+		b.WriteString("Ready: yes\n")
+	} else {
+		b.WriteString("Ready: no, failing health checks\n")
+	}
+	fmt.Fprintf(&b, "Translations: %d, failed: %d\n", snap.Translations, snap.Failures)
+	fmt.Fprintf(&b, "Cache: %d hits, %d misses (%.0f%%)\n", snap.CacheHits, snap.CacheMisses, snap.CacheHitRate*100)
+	if len(snap.PerLanguage) > 0 {
+		fmt.Fprintf(&b, "By language: %s\n", formatCounts(snap.PerLanguage))
+	}
+	if len(snap.Dropped) > 0 {
+		fmt.Fprintf(&b, "Dropped: %s\n", formatCounts(snap.Dropped))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// formatCounts lists counts as "key n" pairs in key order
+func formatCounts[K ~string](counts map[K]int) string {
+	keys := make([]K, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	parts := make([]string, len(keys))
+	for n, key := range keys {
+		parts[n] = fmt.Sprintf("%s %d", key, counts[key])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestStatusReport(t *testing.T) {
+	tests := []struct {
+		name string
+		snap MetricsSnapshot
+		want string
+	}{
+		{
+			name: "fresh",
+			snap: MetricsSnapshot{Ready: true},
+			want: "Ready: yes\nTranslations: 0, failed: 0\nCache: 0 hits, 0 misses (0%)",
+		},
+		{
+			name: "busy",
+			snap: MetricsSnapshot{
+				Translations: 5,
+				Failures:     1,
+				CacheHits:    1,
+				CacheMisses:  3,
+				CacheHitRate: 0.25,
+				PerLanguage:  map[string]int{"Spanish": 2, "French": 3},
+				Dropped:      map[DroppedReason]int{DroppedUserRateLimit: 2, DroppedMessageCap: 1},
+				Ready:        false,
+			},
+			want: "Ready: no, failing health checks\nTranslations: 5, failed: 1\nCache: 1 hits, 3 misses (25%)\n" +
+				"By language: French 3, Spanish 2\nDropped: message-cap 1, user-rate-limit 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusReport(tt.snap); got != tt.want {
+				t.Errorf("statusReport() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusCommandOwnerOnly(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID string
+		want   string
+	}{
+		{"owner", "owner", "Ready: no, failing health checks\nTranslations: 0, failed: 0\nCache: 0 hits, 0 misses (0%)"},
+		{"someone else", "u1", "Only the bot owner can see the bot's status."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			h := newTestHandler(t, Config{OwnerID: "owner"}, &fakeTranslator{})
+			h.statusCommand(s, commandInteraction("status", tt.userID, nil))
+			if got := ephemeralReplies(t, fake); len(got) != 1 || got[0] != tt.want {
+				t.Errorf("replies = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Most distinct terms counted per guild between suggestions
+	maxTrackedTerms = 500
+
+	// Most candidates posted at a time
+	maxSuggestions = 10
+)
+
+// A run of letters, possibly joined by apostrophes or hyphens
+var wordPattern = regexp.MustCompile(`\p{L}+(?:['’-]\p{L}+)*`)
+
+// properNounCandidates lists capitalized words from the original that the
+// model left untranslated, which are often names a glossary should pin
+// down. Words starting a sentence are skipped since they are capitalized
+// anyway.
+func properNounCandidates(original, translation string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, loc := range wordPattern.FindAllStringIndex(original, -1) {
+		word := original[loc[0]:loc[1]]
+		first, _ := utf8.DecodeRuneInString(word)
+		if !unicode.IsUpper(first) || utf8.RuneCountInString(word) < 3 || seen[word] {
+			continue
+		}
+		// A word starting a line starts a sentence too, so the newline is
+		// looked for before the spaces are trimmed
+		before := strings.TrimRight(original[:loc[0]], " \t")
+		if before == "" || strings.ContainsAny(before[len(before)-1:], ".!?:\n") {
+			continue
+		}
+		if !containsWord(translation, word) {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// containsWord reports whether word appears in s as a whole word
+func containsWord(s, word string) bool {
+	for _, found := range wordPattern.FindAllString(s, -1) {
+		if found == word {
+			return true
+		}
+	}
+	return false
+}
+
+// termCount is a glossary candidate and how often it was seen
+type termCount struct {
+	term  string
+	count int
+}
+
+// termTracker counts glossary candidates per guild
+type termTracker struct {
+	mu     sync.Mutex
+	counts map[string]map[string]int
+}
+
+func newTermTracker() *termTracker {
+	return &termTracker{counts: make(map[string]map[string]int)}
+}
+
+// observe counts the candidates in a translation. New terms are ignored
+// once a guild has maxTrackedTerms, until the next suggestions reset it.
+func (t *termTracker) observe(guildID, original, translation string) {
+	terms := properNounCandidates(original, translation)
+	if len(terms) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts, ok := t.counts[guildID]
+	if !ok {
+		counts = make(map[string]int)
+		t.counts[guildID] = counts
+	}
+	for _, term := range terms {
+		if _, ok := counts[term]; ok || len(counts) < maxTrackedTerms {
+			counts[term]++
+		}
+	}
+}
+
+// take returns and resets every guild's counts
+func (t *termTracker) take() map[string]map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := t.counts
+	t.counts = make(map[string]map[string]int)
+	return counts
+}
+
+// glossarySuggestions picks the terms seen at least minCount times that the
+// glossary doesn't already cover, most frequent first
+func glossarySuggestions(counts map[string]int, glossary map[string]map[string]string, minCount int) []termCount {
+	covered := make(map[string]bool)
+	for _, terms := range glossary {
+		for source := range terms {
+			covered[strings.ToLower(source)] = true
+		}
+	}
+
+	var candidates []termCount
+	for term, count := range counts {
+		if count >= minCount && !covered[strings.ToLower(term)] {
+			candidates = append(candidates, termCount{term: term, count: count})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].count != candidates[j].count {
+			return candidates[i].count > candidates[j].count
+		}
+		return candidates[i].term < candidates[j].term
+	})
+	return candidates[:min(len(candidates), maxSuggestions)]
+}
+
+// suggestionMessage lists glossary candidates for a guild's admins
+func suggestionMessage(candidates []termCount) string {
+	var b strings.Builder
+	b.WriteString("These names were left untranslated often lately. Consider adding them to the glossary with /config import:\n")
+	for _, c := range candidates {
+		fmt.Fprintf(&b, "• %s (%d)\n", c.term, c.count)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// runGlossarySuggestions posts each guild's glossary candidates to its log
+// channel every interval
+func (h *DiscordHandler) runGlossarySuggestions(ctx context.Context, s *discordgo.Session, interval time.Duration, minCount int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for guildID, counts := range h.terms.take() {
+			channelID, ok := h.config.LogChannels[guildID]
+			if !ok {
+				continue
+			}
+			candidates := glossarySuggestions(counts, h.guildConfig(guildID).Glossary, minCount)
+			if len(candidates) == 0 {
+				continue
+			}
+			if err := h.sendPlain(s, channelID, suggestionMessage(candidates)); err != nil {
+				log.Printf("Error sending glossary suggestions: %v", err)
+			}
+		}
+	}
+}
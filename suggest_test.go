@@ -0,0 +1,31 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProperNounCandidates(t *testing.T) {
+	tests := []struct {
+		name        string
+		original    string
+		translation string
+		want        []string
+	}{
+		{"kept name", "I met Marisol at the Bayanihan fair", "J'ai rencontré Marisol à la foire Bayanihan", []string{"Marisol", "Bayanihan"}},
+		{"translated word dropped", "We visited the Old Town", "Nous avons visité la vieille ville", nil},
+		{"sentence start skipped", "Marisol is here. Tomorrow we leave", "Marisol est ici. Tomorrow nous partons", nil},
+		{"after a newline skipped", "hello\nTagalog speakers", "bonjour\nTagalog locuteurs", nil},
+		{"short words skipped", "ask Al and Bo", "demande à Al et Bo", nil},
+		{"whole words only", "ask Ana now", "demande à Anakin", nil},
+		{"listed once", "see Jun and Jun again", "vois Jun et Jun encore", []string{"Jun"}},
+		{"hyphenated", "from Lapu-Lapu city", "de la ville de Lapu-Lapu", []string{"Lapu-Lapu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := properNounCandidates(tt.original, tt.translation); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("properNounCandidates(%q, %q) = %q, want %q", tt.original, tt.translation, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Most bullet points a summary is asked for and shown with
+const maxSummaryBullets = 5
+
+// Leading list markers the model may use: -, *, •, 1. or 1)
+var bulletMarkerPattern = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])(?:\s+|$)`)
+
+// summaryPrompt asks for a summary of text in targetLang as bullet points
+func summaryPrompt(text, targetLang string) string {
+	return fmt.Sprintf("Summarize the following text in %s as at most %d short bullet points, "+
+		"one per line, each starting with \"- \". Cover the main points only. "+
+		"Only respond with the bullet points, nothing else:\n\n%s", targetLang, maxSummaryBullets, text)
+}
+
+// parseSummary pulls the bullet points out of a summary reply, dropping
+// blank lines and list markers and keeping at most maxSummaryBullets
+func parseSummary(reply string) ([]string, error) {
+	var bullets []string
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(bulletMarkerPattern.ReplaceAllString(line, ""))
+		if line == "" {
+			continue
+		}
+		bullets = append(bullets, line)
+		if len(bullets) == maxSummaryBullets {
+			break
+		}
+	}
+	if len(bullets) == 0 {
+		return nil, fmt.Errorf("summary has no bullet points")
+	}
+	return bullets, nil
+}
+
+// summaryDescription renders bullets as an embed description, shortening
+// each so that all of them fit
+func summaryDescription(bullets []string) string {
+	perBullet := maxDescriptionLength/len(bullets) - len("• \n")
+	lines := make([]string, len(bullets))
+	for i, bullet := range bullets {
+		lines[i] = "• " + truncate(bullet, perBullet)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// summaryLanguage picks the language summaries in a channel are written
+// in: the one the translate emoji picks there, else SUMMARY_LANG
+func (h *DiscordHandler) summaryLanguage(channelID string) string {
+	if lang := h.config.ChannelLanguages[channelID]; lang != "" {
+		return lang
+	}
+	return h.config.SummaryLang
+}
+
+// summarizeReaction posts a translated summary of msg. A summary is a
+// translation, so it goes through the same checks and delivery as one.
+func (h *DiscordHandler) summarizeReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message) {
+	text := messageText(msg)
+	if text == "" {
+		h.skip(s, r, "message has no text")
+		return
+	}
+	lang := h.summaryLanguage(r.ChannelID)
+	if !h.channelAllows(r.ChannelID, lang) {
+		h.notify(s, r.UserID, noticeRestricted, restrictedNotice(h.channelLanguages(r.ChannelID)))
+		return
+	}
+	if h.blocksLanguage(lang) {
+		h.notify(s, r.UserID, noticeUnreliable, unreliableNotice(lang))
+		return
+	}
+	if !h.allowContent(r.UserID, text, lang) {
+		h.notify(s, r.UserID, noticeAbuse, abuseNotice)
+		return
+	}
+	if !h.admitTranslation(s, r, msg) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	reply, err := h.completer.Complete(h.guildContext(ctx, r.GuildID), summaryPrompt(text, lang))
+	if err != nil {
+		log.Printf("Error summarizing text: %v", err)
+		return
+	}
+	bullets, err := parseSummary(reply)
+	if err != nil {
+		log.Printf("Error parsing summary: %v", err)
+		return
+	}
+
+	embed := translationEmbed(msg, summaryDescription(bullets), lang)
+	embed.Title = "Summary"
+	embed.Footer.Text = fmt.Sprintf("Summarized in %s", lang) + translationMarker
+	addDisclaimer(embed, h.qualityWarning(lang))
+	addDisclaimer(embed, h.guildConfig(r.GuildID).Disclaimer)
+	if _, _, err := h.postReactionEmbed(s, r, msg, embed); err != nil {
+		log.Printf("Error sending summary: %v", err)
+	}
+}
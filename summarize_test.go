@@ -0,0 +1,128 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummaryPrompt(t *testing.T) {
+	prompt := summaryPrompt("The meeting moved to Friday.", "Spanish")
+	for _, want := range []string{"in Spanish", "at most 5 short bullet points", `starting with "- "`, "\n\nThe meeting moved to Friday."} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("summaryPrompt() = %q, missing %q", prompt, want)
+		}
+	}
+}
+
+func TestParseSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    []string
+		wantErr bool
+	}{
+		{"dashes", "- one\n- two", []string{"one", "two"}, false},
+		{"other markers", "* one\n• two\n1. three\n2) four", []string{"one", "two", "three", "four"}, false},
+		{"blank lines dropped", "\n- one\n\n  \n- two\n", []string{"one", "two"}, false},
+		{"no markers", "one\ntwo", []string{"one", "two"}, false},
+		{"number kept without a marker space", "- 2024 was busy", []string{"2024 was busy"}, false},
+		{"capped", "- 1\n- 2\n- 3\n- 4\n- 5\n- 6\n- 7", []string{"1", "2", "3", "4", "5"}, false},
+		{"empty", "  \n\n", nil, true},
+		{"only markers", "-\n- ", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSummary(tt.reply)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSummary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummaryDescription(t *testing.T) {
+	tests := []struct {
+		name    string
+		bullets []string
+	}{
+		{"short", []string{"one", "two"}},
+		{"one long", []string{strings.Repeat("a", 5000)}},
+		{"all long", []string{strings.Repeat("a", 2000), strings.Repeat("b", 2000), strings.Repeat("c", 2000), strings.Repeat("d", 2000), strings.Repeat("e", 2000)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summaryDescription(tt.bullets)
+			if n := len([]rune(got)); n > maxDescriptionLength {
+				t.Errorf("description is %d runes long, want at most %d", n, maxDescriptionLength)
+			}
+			lines := strings.Split(got, "\n")
+			if len(lines) != len(tt.bullets) {
+				t.Fatalf("got %d lines, want %d", len(lines), len(tt.bullets))
+			}
+			for n, line := range lines {
+				if !strings.HasPrefix(line, "• "+tt.bullets[n][:1]) {
+					t.Errorf("line %d = %.20q…, want bullet %d", n, line, n)
+				}
+			}
+		})
+	}
+	if got := summaryDescription([]string{"one", "two"}); got != "• one\n• two" {
+		t.Errorf("summaryDescription() = %q", got)
+	}
+}
+
+func TestSummarizeReaction(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		wantLang string
+		// 0 when the summary should be refused
+		wantPosts int
+	}{
+		{name: "summary language", config: Config{SummaryLang: "English"}, wantLang: "English", wantPosts: 1},
+		{name: "channel language", config: Config{SummaryLang: "English", ChannelLanguages: map[string]string{"c1": "French"}}, wantLang: "French", wantPosts: 1},
+		{name: "restricted channel", config: Config{SummaryLang: "English", ChannelAllowedLanguages: map[string]string{"c1": "French"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			completer := &fakeCompleter{reply: "- a point"}
+			h := newTestHandler(t, tt.config, &fakeTranslator{})
+			h.completer = completer
+
+			h.summarizeReaction(s, testReaction("u1", "📝"), testMessage("a long message"))
+
+			posted := sentMessages(t, fake, "c1")
+			if len(posted) != tt.wantPosts {
+				t.Fatalf("posted %d summaries, want %d", len(posted), tt.wantPosts)
+			}
+			if tt.wantPosts == 0 {
+				if completer.count() != 0 {
+					t.Error("summary was requested from the model")
+				}
+				return
+			}
+			if !strings.Contains(completer.prompts[0], "in "+tt.wantLang) {
+				t.Errorf("prompt = %q, want a summary in %s", completer.prompts[0], tt.wantLang)
+			}
+		})
+	}
+}
+
+func TestSummarizeReactionMessageCap(t *testing.T) {
+	s, fake := newTestSession(t)
+	h := newTestHandler(t, Config{SummaryLang: "English", MaxTranslationsPerMessage: 1, MessageCapWindow: time.Hour}, &fakeTranslator{})
+	h.completer = &fakeCompleter{reply: "- a point"}
+
+	h.translateReaction(s, testReaction("u1", "🇫🇷"), testMessage("a long message"), "French")
+	h.summarizeReaction(s, testReaction("u2", "📝"), testMessage("a long message"))
+
+	if posted := sentMessages(t, fake, "c1"); len(posted) != 1 {
+		t.Errorf("posted %d messages, want only the translation", len(posted))
+	}
+}
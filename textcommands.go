@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Name of the text command, after the guild's prefix, e.g. !tr
+	textCommandName = "tr"
+
+	// Longest prefix a guild can set
+	maxCommandPrefixLength = 5
+)
+
+// parseTextCommand reads "<prefix>tr <language> <text>". Languages of more
+// than one word are quoted, as in !tr "Brazilian Portuguese" hello.
+func parseTextCommand(content, prefix string) (lang, text string, ok bool) {
+	rest, found := strings.CutPrefix(strings.TrimSpace(content), prefix+textCommandName)
+	if !found || prefix == "" {
+		return "", "", false
+	}
+	// Tell !tr apart from e.g. !translate
+	if rest == "" || !strings.ContainsRune(" \t\n", rune(rest[0])) {
+		return "", "", false
+	}
+	rest = strings.TrimSpace(rest)
+
+	if quoted, ok := strings.CutPrefix(rest, `"`); ok {
+		end := strings.Index(quoted, `"`)
+		if end < 0 {
+			return "", "", false
+		}
+		lang, text = quoted[:end], quoted[end+1:]
+	} else {
+		end := strings.IndexAny(rest, " \t\n")
+		if end < 0 {
+			return "", "", false
+		}
+		lang, text = rest[:end], rest[end:]
+	}
+	lang, text = strings.TrimSpace(lang), strings.TrimSpace(text)
+	if lang == "" || text == "" {
+		return "", "", false
+	}
+	return lang, text, true
+}
+
+// messageCreate handles the legacy text command, as an alternative to the
+// slash and context menu commands for servers that prefer typing them
+func (h *DiscordHandler) messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.Author == nil || m.Author.Bot {
+		return
+	}
+	lang, text, ok := parseTextCommand(m.Content, h.guildConfig(m.GuildID).CommandPrefix)
+	if !ok {
+		return
+	}
+	targetLang := canonicalLanguage(lang)
+
+	if !h.channelAllows(m.ChannelID, targetLang) {
+		h.notify(s, m.Author.ID, noticeRestricted, restrictedNotice(h.channelLanguages(m.ChannelID)))
+		return
+	}
+	if !h.allowContent(m.Author.ID, text, targetLang) {
+		h.notify(s, m.Author.ID, noticeAbuse, abuseNotice)
+		return
+	}
+	// The command's own text is new, so there's no per-message cap
+	if !h.admitByDM(s, m.Author.ID, m.GuildID, m.Member, "") {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	result, err := h.translateDetailed(ctx, m.GuildID, m.ChannelID, text, targetLang, "")
+	h.emitTranslation(m.GuildID, targetLang, err)
+	if errors.Is(err, errUnreliableLanguage) {
+		h.notify(s, m.Author.ID, noticeUnreliable, unreliableNotice(targetLang))
+		return
+	}
+	if err != nil {
+		log.Printf("Error translating text command: %v", err)
+		return
+	}
+
+	embed := translationEmbed(m.Message, result.Text, targetLang)
+	if h.config.ShowSourceLang {
+		addSourceLanguage(embed, result.SourceLang)
+	}
+	if h.config.ShowFlag {
+		addLanguageFlag(embed, targetLang)
+	}
+	addDisclaimer(embed, h.qualityWarning(targetLang))
+	addDisclaimer(embed, h.guildConfig(m.GuildID).Disclaimer)
+	sent, err := h.sendPaged(s, m.ChannelID, embed, m.SoftReference())
+	if err != nil {
+		log.Printf("Error sending translation: %v", err)
+		return
+	}
+	h.posted.record(m.ChannelID, sent.ID)
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestParseTextCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		prefix   string
+		wantLang string
+		wantText string
+		wantOK   bool
+	}{
+		{"simple", "!tr french hello there", "!", "french", "hello there", true},
+		{"quoted language", `!tr "Brazilian Portuguese" good morning`, "!", "Brazilian Portuguese", "good morning", true},
+		{"surrounding space", "  !tr de  hallo  ", "!", "de", "hallo", true},
+		{"longer prefix", "salin.tr ja hi", "salin.", "ja", "hi", true},
+		{"other prefix", "?tr fr hi", "!", "", "", false},
+		{"longer command", "!translate fr hi", "!", "", "", false},
+		{"no text", "!tr fr", "!", "", "", false},
+		{"no language", "!tr", "!", "", "", false},
+		{"unclosed quote", `!tr "Brazilian Portuguese hi`, "!", "", "", false},
+		{"empty quoted language", `!tr "" hi`, "!", "", "", false},
+		{"no prefix set", "tr fr hi", "", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang, text, ok := parseTextCommand(tt.content, tt.prefix)
+			if lang != tt.wantLang || text != tt.wantText || ok != tt.wantOK {
+				t.Errorf("parseTextCommand(%q, %q) = %q, %q, %v, want %q, %q, %v",
+					tt.content, tt.prefix, lang, text, ok, tt.wantLang, tt.wantText, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Largest text attachment translated
+	maxTextFileSize = 64 << 10
+
+	// Plain text files are translated this many runes at a time
+	textFileChunkLength = 3000
+
+	// Subtitle lines are batched this many at a time
+	subtitleBatchSize = 50
+)
+
+// Attachment types translated as text
+var textFileExtensions = map[string]bool{
+	".txt": true,
+	".md":  true,
+	".srt": true,
+}
+
+// SRT cue timings, e.g. 00:01:02,500 --> 00:01:04,000
+var srtTimingPattern = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}[,.]\d{3}\s+-->\s+\d{2}:\d{2}:\d{2}[,.]\d{3}`)
+
+// textAttachments returns msg's attachments that are small text files
+func textAttachments(msg *discordgo.Message) []*discordgo.MessageAttachment {
+	var files []*discordgo.MessageAttachment
+	for _, a := range msg.Attachments {
+		if textFileExtensions[strings.ToLower(path.Ext(a.Filename))] && a.Size <= maxTextFileSize {
+			files = append(files, a)
+		}
+	}
+	return files
+}
+
+// translatedFilename tags a filename with the language, e.g. notes.es.txt
+// becomes notes.es.Spanish.txt
+func translatedFilename(name, targetLang string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + targetLang + ext
+}
+
+// isSubtitleText reports whether an SRT line is cue text rather than a cue
+// number, timing or blank separator
+func isSubtitleText(line string) bool {
+	// Files saved by Windows tools often start with a byte order mark
+	trimmed := strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+	if trimmed == "" || srtTimingPattern.MatchString(trimmed) {
+		return false
+	}
+	return strings.Trim(trimmed, "0123456789") != ""
+}
+
+// translateSubtitles translates the cue text of an SRT file, leaving cue
+// numbers, timings and blank lines exactly where they were
+func translateSubtitles(content string, translate func(lines []string) []batchItem) string {
+	lines := strings.Split(content, "\n")
+	var textLines []int
+	for n, line := range lines {
+		if isSubtitleText(line) {
+			textLines = append(textLines, n)
+		}
+	}
+
+	for start := 0; start < len(textLines); start += subtitleBatchSize {
+		batch := textLines[start:min(start+subtitleBatchSize, len(textLines))]
+		texts := make([]string, len(batch))
+		for n, line := range batch {
+			texts[n] = strings.TrimRight(lines[line], "\r")
+		}
+		for n, item := range translate(texts) {
+			// Keep Windows line endings if the file has them
+			suffix := ""
+			if strings.HasSuffix(lines[batch[n]], "\r") {
+				suffix = "\r"
+			}
+			lines[batch[n]] = item.display() + suffix
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// translatePlainText translates a text file a chunk at a time, keeping the
+// breaks between chunks as they were
+func translatePlainText(content string, translate func(chunk string) (string, error)) (string, error) {
+	chunks, breaks := splitTextKeepingBreaks(content, textFileChunkLength)
+	var out strings.Builder
+	for n, chunk := range chunks {
+		translated, err := translate(chunk)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(translated)
+		if n < len(breaks) {
+			out.WriteString(breaks[n])
+		}
+	}
+	return out.String(), nil
+}
+
+// translateTextFiles translates msg's text attachments and posts them back as
+// files, replying to msg
+func (h *DiscordHandler) translateTextFiles(s *discordgo.Session, r *discordgo.MessageReactionAdd, msg *discordgo.Message, files []*discordgo.MessageAttachment, targetLang string) {
+	if h.blocksLanguage(targetLang) {
+		h.notify(s, r.UserID, noticeUnreliable, unreliableNotice(targetLang))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var translated []*discordgo.File
+	for _, file := range files {
+		data, err := downloadAttachment(file.URL, maxTextFileSize)
+		if err != nil {
+			log.Printf("Error downloading %s: %v", file.Filename, err)
+			continue
+		}
+		if !utf8.Valid(data) {
+			log.Printf("Skipping %s: not UTF-8 text", file.Filename)
+			continue
+		}
+
+		var out string
+		if strings.EqualFold(path.Ext(file.Filename), ".srt") {
+			out = translateSubtitles(string(data), func(lines []string) []batchItem {
+				return h.translateBatch(ctx, r.GuildID, lines, targetLang)
+			})
+		} else {
+			out, err = translatePlainText(string(data), func(chunk string) (string, error) {
+				return h.translate(ctx, r.GuildID, "", chunk, targetLang)
+			})
+		}
+		h.emitTranslation(r.GuildID, targetLang, err)
+		if err != nil {
+			log.Printf("Error translating %s: %v", file.Filename, err)
+			continue
+		}
+		translated = append(translated, &discordgo.File{
+			Name:        translatedFilename(file.Filename, targetLang),
+			ContentType: "text/plain; charset=utf-8",
+			Reader:      bytes.NewReader([]byte(out)),
+		})
+	}
+	if len(translated) == 0 {
+		return
+	}
+
+	send := &discordgo.MessageSend{
+		Content:         h.withQualityWarning(fmt.Sprintf("Translated to %s", targetLang), targetLang),
+		Files:           translated,
+		AllowedMentions: h.allowedMentions(),
+	}
+	if h.guildConfig(r.GuildID).ephemeralOnly() {
+		if err := sendPrivately(s, r.UserID, send); err != nil {
+			log.Printf("Error sending translated files by DM: %v", err)
+		}
+		return
+	}
+	channelID, reference, elsewhere := h.translationDestination(r, msg)
+	send.Reference = reference
+	if elsewhere {
+		send.Content += fmt.Sprintf("\nOriginal message: %s", messageLink(r.GuildID, r.ChannelID, r.MessageID))
+	}
+	sent, err := s.ChannelMessageSendComplex(channelID, send)
+	if err != nil {
+		log.Printf("Error sending translated files: %v", err)
+		return
+	}
+	h.posted.record(channelID, sent.ID)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestIsSubtitleText(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want bool
+	}{
+		{"text", "Hello there", true},
+		{"cue number", "12", false},
+		{"cue number after byte order mark", "\ufeff1", false},
+		{"timing", "00:01:02,500 --> 00:01:04,000", false},
+		{"timing after byte order mark", "\ufeff00:01:02,500 --> 00:01:04,000", false},
+		{"blank", " \r", false},
+		{"text with digits", "Route 66", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSubtitleText(tt.line); got != tt.want {
+				t.Errorf("isSubtitleText(%q) = %v, want %v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateSubtitles(t *testing.T) {
+	content := "\ufeff1\r\n00:00:01,000 --> 00:00:02,000\r\nHello\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\nBye\r\n"
+	want := "\ufeff1\r\n00:00:01,000 --> 00:00:02,000\r\nHELLO\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\nBYE\r\n"
+	got := translateSubtitles(content, func(lines []string) []batchItem {
+		items := make([]batchItem, len(lines))
+		for n, line := range lines {
+			items[n] = batchItem{Text: strings.ToUpper(line)}
+		}
+		return items
+	})
+	if got != want {
+		t.Errorf("translateSubtitles() = %q, want %q", got, want)
+	}
+}
+
+func TestTranslatePlainText(t *testing.T) {
+	paragraph := strings.Repeat("word ", textFileChunkLength/5-1) + "end"
+	tests := []struct {
+		name    string
+		content string
+		chunks  int
+	}{
+		{"one chunk", "hello\nworld", 1},
+		{"paragraph break", paragraph + "\n\n" + paragraph, 2},
+		{"line break", paragraph + "\n" + paragraph, 2},
+		{"trailing newline", paragraph + "\n\n" + paragraph + "\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := 0
+			got, err := translatePlainText(tt.content, func(chunk string) (string, error) {
+				chunks++
+				return chunk, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if chunks != tt.chunks {
+				t.Errorf("translated %d chunks, want %d", chunks, tt.chunks)
+			}
+			if got != tt.content {
+				t.Errorf("translatePlainText() with an identity translation = %q, want %q", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestTextAttachments(t *testing.T) {
+	msg := &discordgo.Message{Attachments: []*discordgo.MessageAttachment{
+		{ID: "notes", Filename: "notes.txt", Size: 100},
+		{ID: "readme", Filename: "README.MD", Size: 100},
+		{ID: "subs", Filename: "episode.srt", Size: maxTextFileSize},
+		{ID: "big", Filename: "book.txt", Size: maxTextFileSize + 1},
+		{ID: "image", Filename: "cat.png", Size: 100},
+		{ID: "bare", Filename: "txt", Size: 100},
+	}}
+	var got []string
+	for _, a := range textAttachments(msg) {
+		got = append(got, a.ID)
+	}
+	if want := []string{"notes", "readme", "subs"}; !slices.Equal(got, want) {
+		t.Errorf("textAttachments() = %q, want %q", got, want)
+	}
+}
+
+func TestTranslatedFilename(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"notes.txt", "notes.Spanish.txt"},
+		{"notes.es.txt", "notes.es.Spanish.txt"},
+		{"episode.srt", "episode.Spanish.srt"},
+	}
+	for _, tt := range tests {
+		if got := translatedFilename(tt.name, "Spanish"); got != tt.want {
+			t.Errorf("translatedFilename(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
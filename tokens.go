@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Discord timestamp markup, e.g. <t:1700000000> or <t:1700000000:R>
+	timestampPattern = regexp.MustCompile(`<t:-?\d+(?::[tTdDfFR])?>`)
+
+	// Discord spoiler markup, e.g. ||secret||
+	spoilerPattern = regexp.MustCompile(`(?s)\|\|(.+?)\|\|`)
+
+	// Amounts, decimals, dates and times such as $1,234.56, 3.14, 50%,
+	// 2024-01-31, 31/01/2024 and 12:30
+	numberPattern = regexp.MustCompile(`(?:[$€£¥₹]\s?)?\d+(?:[.,:/-]\d+)*(?:\s?[%€£¥₹])?`)
+
+	// Markdown links, e.g. [anchor text](https://example.com)
+	linkPattern = regexp.MustCompile(`\[([^\[\]\n]+)\]\((https?://[^\s()]+)\)`)
+
+	// Unicode emoji, including flags, keycaps, skin tones, tag sequences
+	// such as subdivision flags, and ZWJ sequences such as 👨‍👩‍👧
+	emojiPattern = regexp.MustCompile(`(?:` + emojiAtom + `)(?:\x{200D}(?:` + emojiAtom + `))*`)
+
+	placeholderPattern = regexp.MustCompile(`\{\{\d+\}\}`)
+)
+
+// A single emoji with its modifiers: a pair of regional indicators, a keycap,
+// or a pictograph followed by variation selectors, skin tones and tags
+const emojiAtom = `[\x{1F1E6}-\x{1F1FF}]{2}` +
+	`|[#*0-9]\x{FE0F}?\x{20E3}` +
+	`|[\x{1F000}-\x{1FAFF}\x{2300}-\x{23FF}\x{2600}-\x{27BF}\x{2B00}-\x{2BFF}\x{3030}\x{303D}\x{3297}\x{3299}]` +
+	`[\x{FE0F}\x{1F3FB}-\x{1F3FF}\x{E0020}-\x{E007F}]*`
+
+// tokenOptions selects the optional kinds of span protectTokens keeps intact
+type tokenOptions struct {
+	emoji   bool
+	numbers bool
+	// Translate the anchor text of markdown links but keep their URLs
+	links bool
+	// Translate only the comments in fenced code blocks
+	codeComments bool
+	// Forced terminology, source term to the exact term to put in the
+	// translation
+	terms map[string]string
+	// How @everyone and @here are neutralized; see neutralizeMention
+	massMentions string
+}
+
+// tokenPart is a piece of a protected span, translated on its own or kept
+type tokenPart struct {
+	text      string
+	translate bool
+}
+
+// keep and translated build tokenParts
+func keep(text string) tokenPart       { return tokenPart{text: text} }
+func translated(text string) tokenPart { return tokenPart{text: text, translate: true} }
+
+// tokenRule finds one kind of span to protect. Spans with a split function
+// have their translatable parts translated separately; the rest are kept
+// verbatim.
+type tokenRule struct {
+	pattern *regexp.Regexp
+	split   func(submatches []string) []tokenPart
+}
+
+// rules lists the spans to protect. Verbatim rules come first so that their
+// placeholders can end up inside the translated part of later rules.
+func (o tokenOptions) rules() []tokenRule {
+	var rules []tokenRule
+	// Before anything else adds placeholders whose digits a term could match
+	if len(o.terms) > 0 {
+		rules = append(rules, glossaryRule(o.terms))
+	}
+	// Timestamps are always kept, and before numbers so that they don't
+	// take their digits
+	rules = append(rules, tokenRule{pattern: timestampPattern}, massMentionRule(o.massMentions))
+	// Before numbers, so keycaps stay whole
+	if o.emoji {
+		rules = append(rules, tokenRule{pattern: emojiPattern})
+	}
+	if o.numbers {
+		rules = append(rules, tokenRule{pattern: numberPattern})
+	}
+	if o.codeComments {
+		rules = append(rules, tokenRule{pattern: codeFencePattern, split: splitCodeFence})
+	}
+	if o.links {
+		rules = append(rules, tokenRule{
+			pattern: linkPattern,
+			split: func(m []string) []tokenPart {
+				return []tokenPart{keep("["), translated(m[1]), keep("](" + m[2] + ")")}
+			},
+		})
+	}
+	rules = append(rules, tokenRule{
+		pattern: spoilerPattern,
+		split: func(m []string) []tokenPart {
+			return []tokenPart{keep("||"), translated(m[1]), keep("||")}
+		},
+	})
+	return rules
+}
+
+// protectedToken is a span of the source text swapped out for a placeholder
+// so the model can't alter or expose it
+type protectedToken struct {
+	placeholder string
+	// verbatim is put back unchanged
+	verbatim string
+	// parts, when set, are put back instead of verbatim, with the
+	// translatable ones translated on their own
+	parts []tokenPart
+}
+
+func (t protectedToken) restored() string {
+	if t.parts == nil {
+		return t.verbatim
+	}
+	var b strings.Builder
+	for _, p := range t.parts {
+		b.WriteString(p.text)
+	}
+	return b.String()
+}
+
+// protectTokens replaces protected spans in text with numbered placeholders
+func protectTokens(text string, opts tokenOptions) (string, []protectedToken) {
+	var tokens []protectedToken
+	for _, rule := range opts.rules() {
+		repl := func(match string) string {
+			token := protectedToken{
+				placeholder: fmt.Sprintf("{{%d}}", len(tokens)),
+				verbatim:    match,
+			}
+			if rule.split != nil {
+				token.parts = rule.split(rule.pattern.FindStringSubmatch(match))
+			}
+			tokens = append(tokens, token)
+			return token.placeholder
+		}
+
+		// Spans that get translated may wrap earlier placeholders, but
+		// verbatim spans must not match the digits inside one
+		if rule.split != nil {
+			text = rule.pattern.ReplaceAllStringFunc(text, repl)
+		} else {
+			text = replaceOutsidePlaceholders(text, rule.pattern, repl)
+		}
+	}
+	return text, tokens
+}
+
+// replaceOutsidePlaceholders is like ReplaceAllStringFunc but leaves
+// existing placeholders untouched
+func replaceOutsidePlaceholders(text string, re *regexp.Regexp, repl func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringIndex(text, -1) {
+		b.WriteString(re.ReplaceAllStringFunc(text[last:loc[0]], repl))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(re.ReplaceAllStringFunc(text[last:], repl))
+	return b.String()
+}
+
+// restoreTokens puts protected spans back in place of their placeholders,
+// latest first so placeholders nested inside other spans are restored too.
+// Tokens whose placeholder the model dropped are appended so nothing is lost.
+func restoreTokens(text string, tokens []protectedToken) string {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		t := tokens[i]
+		if strings.Contains(text, t.placeholder) {
+			text = strings.Replace(text, t.placeholder, t.restored(), 1)
+		} else {
+			text += " " + t.restored()
+		}
+	}
+	return text
+}
+
+// translateProtected translates text while keeping protected spans intact.
+// Spoiler contents are translated separately and re-wrapped so they stay hidden.
+func translateProtected(ctx context.Context, t Translator, req TranslateRequest, opts tokenOptions) (string, error) {
+	masked, tokens := protectTokens(req.Text, opts)
+
+	for _, token := range tokens {
+		for j, part := range token.parts {
+			if !part.translate || onlyPlaceholders(part.text) {
+				continue
+			}
+			result, err := t.Translate(ctx, req.with(part.text))
+			if err != nil {
+				return "", err
+			}
+			token.parts[j].text = result.Text
+		}
+	}
+
+	// Skip the provider call when nothing but placeholders is left
+	translation := masked
+	if !onlyPlaceholders(masked) {
+		result, err := t.Translate(ctx, req.with(masked))
+		if err != nil {
+			return "", err
+		}
+		translation = result.Text
+	}
+
+	return tokenRestorer(tokens).Process(translation)
+}
+
+// onlyPlaceholders reports whether s has nothing worth sending to a model
+func onlyPlaceholders(s string) bool {
+	return strings.TrimSpace(placeholderPattern.ReplaceAllString(s, "")) == ""
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestProtectTokens(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		opts       tokenOptions
+		wantMasked string
+	}{
+		{
+			name:       "timestamps are always kept",
+			text:       "starts <t:1700000000:R>",
+			wantMasked: "starts {{0}}",
+		},
+		{
+			name:       "numbers only when asked",
+			text:       "costs $12.50",
+			wantMasked: "costs $12.50",
+		},
+		{
+			name:       "numbers",
+			text:       "costs $12.50",
+			opts:       tokenOptions{numbers: true},
+			wantMasked: "costs {{0}}",
+		},
+		{
+			name:       "emoji",
+			text:       "nice 👍🏽",
+			opts:       tokenOptions{emoji: true},
+			wantMasked: "nice {{0}}",
+		},
+		{
+			name:       "spoilers",
+			text:       "the end is ||a twist||",
+			wantMasked: "the end is {{0}}",
+		},
+		{
+			name:       "links keep their URL",
+			text:       "read [the docs](https://example.com/a)",
+			opts:       tokenOptions{links: true},
+			wantMasked: "read {{0}}",
+		},
+		{
+			name:       "mass mentions",
+			text:       "hey @everyone",
+			wantMasked: "hey {{0}}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masked, tokens := protectTokens(tt.text, tt.opts)
+			if masked != tt.wantMasked {
+				t.Errorf("masked = %q, want %q", masked, tt.wantMasked)
+			}
+			for _, token := range tokens {
+				if strings.Contains(masked, token.verbatim) {
+					t.Errorf("%q left in masked text %q", token.verbatim, masked)
+				}
+			}
+		})
+	}
+}
+
+func TestProtectTokensNestsVerbatimInTranslated(t *testing.T) {
+	masked, tokens := protectTokens("||at 5pm||", tokenOptions{numbers: true})
+	if masked != "{{1}}" {
+		t.Fatalf("masked = %q, want {{1}}", masked)
+	}
+	if got := tokens[1].parts[1].text; got != "at {{0}}pm" {
+		t.Errorf("spoiler contents = %q, want the number masked inside", got)
+	}
+}
+
+func TestRestoreTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		reply string
+		opts  tokenOptions
+		want  string
+	}{
+		{
+			name:  "round trip",
+			text:  "meet <t:1700000000:t> for 2 hours",
+			reply: "rendez-vous {{0}} pour {{1}} heures",
+			opts:  tokenOptions{numbers: true},
+			want:  "rendez-vous <t:1700000000:t> pour 2 heures",
+		},
+		{
+			name:  "dropped placeholders are appended",
+			text:  "party 🎉",
+			reply: "fête",
+			opts:  tokenOptions{emoji: true},
+			want:  "fête 🎉",
+		},
+		{
+			name:  "mass mentions come back neutralized",
+			text:  "@here hi",
+			reply: "{{0}} salut",
+			opts:  tokenOptions{massMentions: massMentionsEscape},
+			want:  "@\u200bhere salut",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, tokens := protectTokens(tt.text, tt.opts)
+			if got := restoreTokens(tt.reply, tokens); got != tt.want {
+				t.Errorf("restoreTokens() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumbersSurviveRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		kept  []string
+		reply func(masked string) string
+		want  string
+	}{
+		{name: "currency", text: "it costs $1,234.56 or €20", kept: []string{"$1,234.56", "€20"}},
+		{name: "trailing currency", text: "about 15 € each", kept: []string{"15 €"}},
+		{name: "decimals and percent", text: "pi is 3.14 and 50% of 0,5", kept: []string{"3.14", "50%", "0,5"}},
+		{name: "dates", text: "due 2024-01-31, or 31/01/2024", kept: []string{"2024-01-31", "31/01/2024"}},
+		{name: "times", text: "see you at 12:30", kept: []string{"12:30"}},
+		{
+			name:  "reordered by the model",
+			text:  "on 2024-01-31 pay $5",
+			kept:  []string{"2024-01-31", "$5"},
+			reply: func(string) string { return "paga {{1}} el {{0}}" },
+			want:  "paga $5 el 2024-01-31",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masked, tokens := protectTokens(tt.text, tokenOptions{numbers: true})
+			for _, kept := range tt.kept {
+				if strings.Contains(masked, kept) {
+					t.Errorf("%q left for the model in %q", kept, masked)
+				}
+			}
+			reply, want := masked, tt.text
+			if tt.reply != nil {
+				reply, want = tt.reply(masked), tt.want
+			}
+			if got := restoreTokens(reply, tokens); got != want {
+				t.Errorf("restoreTokens(%q) = %q, want %q", reply, got, want)
+			}
+		})
+	}
+}
+
+func TestTranslateLinkText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		links bool
+		want  string
+	}{
+		{
+			name:  "single link",
+			text:  "see [the docs](https://example.com/Docs) first",
+			links: true,
+			want:  "SEE [THE DOCS](https://example.com/Docs) FIRST",
+		},
+		{
+			name:  "only a link",
+			text:  "[the docs](https://example.com/Docs)",
+			links: true,
+			want:  "[THE DOCS](https://example.com/Docs)",
+		},
+		{
+			name:  "multiple links",
+			text:  "[uno](https://a.example/One) y [dos](https://b.example/x?y=1)",
+			links: true,
+			want:  "[UNO](https://a.example/One) Y [DOS](https://b.example/x?y=1)",
+		},
+		{
+			name:  "not a url",
+			text:  "[uno](not a url)",
+			links: true,
+			want:  "[UNO](NOT A URL)",
+		},
+		{
+			name:  "unclosed",
+			text:  "[uno](https://a.example",
+			links: true,
+			want:  "[UNO](HTTPS://A.EXAMPLE",
+		},
+		{
+			name:  "empty anchor",
+			text:  "[](https://a.example)",
+			links: true,
+			want:  "[](HTTPS://A.EXAMPLE)",
+		},
+		{
+			name: "disabled",
+			text: "see [the docs](https://example.com/Docs)",
+			want: "SEE [THE DOCS](HTTPS://EXAMPLE.COM/DOCS)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := &fakeTranslator{reply: func(req TranslateRequest) (string, error) {
+				return strings.ToUpper(req.Text), nil
+			}}
+			got, err := translateProtected(context.Background(), translator, TranslateRequest{Text: tt.text, TargetLang: "Spanish"}, tokenOptions{links: tt.links})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("translateProtected() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmojiSurviveRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		emoji string
+	}{
+		{"family", "\U0001F468\u200d\U0001F469\u200d\U0001F467"},
+		{"flag", "🇵🇭"},
+		{"skin tone", "👋🏽"},
+		{"keycap", "1\ufe0f\u20e3"},
+		{"rainbow flag", "\U0001F3F3\ufe0f\u200d\U0001F308"},
+		{"subdivision flag", "\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F"},
+		{"variation selector", "❤\ufe0f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := "we did it " + tt.emoji + " see you"
+			masked, tokens := protectTokens(text, tokenOptions{emoji: true})
+			if masked != "we did it {{0}} see you" {
+				t.Fatalf("masked = %q, want the emoji as one placeholder", masked)
+			}
+			if len(tokens) != 1 || tokens[0].verbatim != tt.emoji {
+				t.Fatalf("tokens = %+v, want the whole emoji", tokens)
+			}
+			if got, want := restoreTokens("lo logramos {{0}} nos vemos", tokens), "lo logramos "+tt.emoji+" nos vemos"; got != want {
+				t.Errorf("restoreTokens() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestEmojiNextToEachOther(t *testing.T) {
+	text := "🇵🇭🇯🇵 \U0001F468\u200d\U0001F469\u200d\U0001F467👍"
+	masked, tokens := protectTokens(text, tokenOptions{emoji: true})
+	if masked != "{{0}}{{1}} {{2}}{{3}}" {
+		t.Errorf("masked = %q, want four placeholders", masked)
+	}
+	if got := restoreTokens(masked, tokens); got != text {
+		t.Errorf("restoreTokens() = %q, want %q", got, text)
+	}
+}
+
+func TestTimestampsSurviveRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp string
+		protected bool
+	}{
+		{name: "default", timestamp: "<t:1700000000>", protected: true},
+		{name: "short time", timestamp: "<t:1700000000:t>", protected: true},
+		{name: "long time", timestamp: "<t:1700000000:T>", protected: true},
+		{name: "short date", timestamp: "<t:1700000000:d>", protected: true},
+		{name: "long date", timestamp: "<t:1700000000:D>", protected: true},
+		{name: "short date and time", timestamp: "<t:1700000000:f>", protected: true},
+		{name: "long date and time", timestamp: "<t:1700000000:F>", protected: true},
+		{name: "relative", timestamp: "<t:1700000000:R>", protected: true},
+		{name: "before 1970", timestamp: "<t:-86400:D>", protected: true},
+		{name: "unknown style", timestamp: "<t:1700000000:X>"},
+		{name: "not a number", timestamp: "<t:soon>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Numbers are protected too, to check they don't take the
+			// timestamp's digits
+			text := "the event starts " + tt.timestamp + " sharp"
+			masked, tokens := protectTokens(text, tokenOptions{numbers: true})
+			if !tt.protected {
+				for _, token := range tokens {
+					if token.verbatim == tt.timestamp {
+						t.Errorf("%q protected as a timestamp", tt.timestamp)
+					}
+				}
+				return
+			}
+			if masked != "the event starts {{0}} sharp" || len(tokens) != 1 || tokens[0].verbatim != tt.timestamp {
+				t.Fatalf("masked = %q, tokens = %+v, want the whole timestamp as one placeholder", masked, tokens)
+			}
+			if got, want := restoreTokens("el evento empieza {{0}} en punto", tokens), "el evento empieza "+tt.timestamp+" en punto"; got != want {
+				t.Errorf("restoreTokens() = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Most messages /translate-range translates at once
+const maxRangeMessages = 50
+
+// Message links as copied from the Discord client
+var messageLinkPattern = regexp.MustCompile(`^https://(?:(?:ptb|canary)\.)?discord(?:app)?\.com/channels/(\d+|@me)/(\d+)/(\d+)$`)
+
+// parseMessageLink returns the guild, channel and message IDs from a
+// message link
+func parseMessageLink(link string) (guildID, channelID, messageID string, err error) {
+	m := messageLinkPattern.FindStringSubmatch(link)
+	if m == nil {
+		return "", "", "", fmt.Errorf("%q is not a message link", link)
+	}
+	return m[1], m[2], m[3], nil
+}
+
+// snowflakeLess orders Discord IDs by age, oldest first
+func snowflakeLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// collectRange adds fetched pages of messages to the range from startID to
+// endID, both included, oldest first. It reports whether the range is
+// complete, and fails once it would hold more than limit messages.
+func collectRange(collected, page []*discordgo.Message, startID, endID string, limit int) ([]*discordgo.Message, bool, error) {
+	done := false
+	for _, msg := range page {
+		if snowflakeLess(endID, msg.ID) {
+			done = true
+			continue
+		}
+		if snowflakeLess(msg.ID, startID) {
+			continue
+		}
+		collected = append(collected, msg)
+		if msg.ID == endID {
+			done = true
+		}
+	}
+	slices.SortFunc(collected, func(a, b *discordgo.Message) int {
+		switch {
+		case a.ID == b.ID:
+			return 0
+		case snowflakeLess(a.ID, b.ID):
+			return -1
+		}
+		return 1
+	})
+	collected = slices.CompactFunc(collected, func(a, b *discordgo.Message) bool { return a.ID == b.ID })
+	if len(collected) > limit {
+		return nil, false, fmt.Errorf("that range has more than %d messages", limit)
+	}
+	return collected, done, nil
+}
+
+// fetchRange fetches every message from startID to endID, 100 at a time
+func fetchRange(s *discordgo.Session, channelID, startID, endID string) ([]*discordgo.Message, error) {
+	start, err := s.ChannelMessage(channelID, startID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching first message: %v", err)
+	}
+	collected := []*discordgo.Message{start}
+	if startID == endID {
+		return collected, nil
+	}
+
+	after := startID
+	for {
+		var page []*discordgo.Message
+		err := retryRead("message range fetch", func() error {
+			var err error
+			page, err = s.ChannelMessages(channelID, 100, "", after, "")
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error fetching messages: %v", err)
+		}
+
+		var done bool
+		collected, done, err = collectRange(collected, page, startID, endID, maxRangeMessages)
+		if err != nil {
+			return nil, err
+		}
+		if done || len(page) == 0 {
+			return collected, nil
+		}
+		after = collected[len(collected)-1].ID
+	}
+}
+
+// canReadChannel reports whether channelID belongs to guildID and userID can
+// see it and read its history. Message links are written by the user, so
+// their IDs can't be trusted on their own.
+func canReadChannel(s *discordgo.Session, guildID, userID, channelID string) bool {
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		channel, err = s.Channel(channelID)
+		if err != nil {
+			log.Printf("Error looking up channel %s: %v", channelID, err)
+			return false
+		}
+	}
+	if channel.GuildID != guildID {
+		return false
+	}
+	perms, err := s.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		log.Printf("Error checking permissions: %v", err)
+		return false
+	}
+	var need int64 = discordgo.PermissionViewChannel | discordgo.PermissionReadMessageHistory
+	return perms&need == need
+}
+
+func (h *DiscordHandler) translateRangeCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := commandOptions(i)
+	targetLang := opts["language"].StringValue()
+	if h.blocksLanguage(targetLang) {
+		respondEphemeral(s, i, unreliableNotice(targetLang))
+		return
+	}
+	guildID, channelID, startID, err := parseMessageLink(opts["start"].StringValue())
+	var endGuildID, endChannelID, endID string
+	if err == nil {
+		endGuildID, endChannelID, endID, err = parseMessageLink(opts["end"].StringValue())
+	}
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Sorry, %v.", err))
+		return
+	}
+	// Don't let the bot be used to read other servers' channels
+	if guildID != i.GuildID || endGuildID != i.GuildID {
+		respondEphemeral(s, i, "Both messages must be in this server.")
+		return
+	}
+	if channelID != endChannelID {
+		respondEphemeral(s, i, "Both messages must be in the same channel.")
+		return
+	}
+	user := interactionUser(i)
+	if user == nil || !canReadChannel(s, i.GuildID, user.ID, channelID) {
+		respondEphemeral(s, i, "Both messages must be in a channel of this server that you can read.")
+		return
+	}
+	if snowflakeLess(endID, startID) {
+		startID, endID = endID, startID
+	}
+	if !h.admitCommand(s, i) {
+		return
+	}
+
+	if err := deferResponse(s, i); err != nil {
+		log.Printf("Error deferring range response: %v", err)
+		return
+	}
+
+	messages, err := fetchRange(s, channelID, startID, endID)
+	if err != nil {
+		log.Printf("Error collecting message range: %v", err)
+		editResponseText(s, i, fmt.Sprintf("Sorry, I couldn't collect those messages: %v.", err))
+		return
+	}
+	var texts []string
+	var sources []*discordgo.Message
+	for _, msg := range messages {
+		if text := messageText(msg); text != "" {
+			texts = append(texts, text)
+			sources = append(sources, msg)
+		}
+	}
+	if len(texts) == 0 {
+		editResponseText(s, i, "There's no text to translate in that range.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	items := h.translateBatch(ctx, i.GuildID, texts, targetLang)
+
+	fields := make([]*discordgo.MessageEmbedField, len(items))
+	for n, item := range items {
+		author := "Unknown"
+		if sources[n].Author != nil {
+			author = sources[n].Author.Username
+		}
+		fields[n] = &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%s • %s", author, sources[n].Timestamp.Format("Jan 2 15:04")),
+			Value: item.display(),
+		}
+	}
+
+	// Post the translations in a thread so they don't flood the channel
+	summary := h.withQualityWarning(fmt.Sprintf("Translated %d message(s) to %s: %s", len(items), targetLang, batchSummary(items)), targetLang)
+	editResponseText(s, i, summary)
+	response, err := s.InteractionResponse(i.Interaction)
+	if err != nil {
+		log.Printf("Error getting range response: %v", err)
+		return
+	}
+	thread, err := s.MessageThreadStart(i.ChannelID, response.ID, truncate("Translations to "+targetLang, 100), 60)
+	if err != nil {
+		log.Printf("Error starting range thread: %v", err)
+		return
+	}
+	// One embed per message keeps each within the per-message length limit
+	for _, embed := range packIntoEmbeds(fields) {
+		embed.Title = fmt.Sprintf("Translated to %s", targetLang)
+		if _, err := s.ChannelMessageSendEmbed(thread.ID, embed); err != nil {
+			log.Printf("Error sending range translation: %v", err)
+			return
+		}
+	}
+}
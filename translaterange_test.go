@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// rangeMessages are messages with the given IDs
+func rangeMessages(ids ...int) []*discordgo.Message {
+	var messages []*discordgo.Message
+	for _, id := range ids {
+		messages = append(messages, &discordgo.Message{ID: fmt.Sprint(id), Content: fmt.Sprintf("message %d", id)})
+	}
+	return messages
+}
+
+func messageIDs(messages []*discordgo.Message) []string {
+	var ids []string
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+func TestParseMessageLink(t *testing.T) {
+	tests := []struct {
+		link    string
+		want    []string
+		wantErr bool
+	}{
+		{link: "https://discord.com/channels/1/2/3", want: []string{"1", "2", "3"}},
+		{link: "https://ptb.discord.com/channels/1/2/3", want: []string{"1", "2", "3"}},
+		{link: "https://discordapp.com/channels/@me/2/3", want: []string{"@me", "2", "3"}},
+		{link: "https://discord.com/channels/1/2", wantErr: true},
+		{link: "https://example.com/channels/1/2/3", wantErr: true},
+		{link: "https://discord.com/channels/1/2/3?x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			guildID, channelID, messageID, err := parseMessageLink(tt.link)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseMessageLink() succeeded, want an error")
+				}
+				return
+			}
+			if got := []string{guildID, channelID, messageID}; err != nil || !slices.Equal(got, tt.want) {
+				t.Errorf("parseMessageLink() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnowflakeLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"9", "10", true},
+		{"10", "9", false},
+		{"123", "124", true},
+		{"124", "124", false},
+	}
+	for _, tt := range tests {
+		if got := snowflakeLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("snowflakeLess(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCollectRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		collected []*discordgo.Message
+		page      []*discordgo.Message
+		limit     int
+		want      []string
+		wantDone  bool
+		wantErr   bool
+	}{
+		{
+			name:      "page reaching the end",
+			collected: rangeMessages(100),
+			page:      rangeMessages(103, 102, 101),
+			limit:     10,
+			want:      []string{"100", "101", "102", "103"},
+			wantDone:  true,
+		},
+		{
+			name:      "page short of the end",
+			collected: rangeMessages(100),
+			page:      rangeMessages(102, 101),
+			limit:     10,
+			want:      []string{"100", "101", "102"},
+		},
+		{
+			name:      "past the end left out",
+			collected: rangeMessages(100),
+			page:      rangeMessages(102, 104, 105),
+			limit:     10,
+			want:      []string{"100", "102"},
+			wantDone:  true,
+		},
+		{
+			name:      "before the start left out and repeats dropped",
+			collected: rangeMessages(100, 101),
+			page:      rangeMessages(99, 101, 102),
+			limit:     10,
+			want:      []string{"100", "101", "102"},
+		},
+		{
+			name:      "at the cap",
+			collected: rangeMessages(100),
+			page:      rangeMessages(101, 102),
+			limit:     3,
+			want:      []string{"100", "101", "102"},
+		},
+		{
+			name:      "over the cap",
+			collected: rangeMessages(100),
+			page:      rangeMessages(101, 102, 103),
+			limit:     3,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, done, err := collectRange(tt.collected, tt.page, "100", "103", tt.limit)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("collectRange() = %q, want an error", messageIDs(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids := messageIDs(got); !slices.Equal(ids, tt.want) || done != tt.wantDone {
+				t.Errorf("collectRange() = %q, %v, want %q, %v", ids, done, tt.want, tt.wantDone)
+			}
+		})
+	}
+}
+
+func TestFetchRange(t *testing.T) {
+	s, fake := newTestSession(t)
+	fake.replies["/channels/c1/messages/100"] = `{"id":"100","content":"first"}`
+	fake.replies["/channels/c1/messages"] = `[{"id":"102","content":"c"},{"id":"101","content":"b"}]`
+	messages, err := fetchRange(s, "c1", "100", "102")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := messageIDs(messages); !slices.Equal(ids, []string{"100", "101", "102"}) {
+		t.Errorf("fetchRange() = %q, want 100 to 102", ids)
+	}
+}
+
+func TestFetchRangeCap(t *testing.T) {
+	s, fake := newTestSession(t)
+	var page []string
+	for id := maxRangeMessages + 101; id > 100; id-- {
+		page = append(page, fmt.Sprintf(`{"id":"%d"}`, id))
+	}
+	fake.replies["/channels/c1/messages/100"] = `{"id":"100"}`
+	fake.replies["/channels/c1/messages"] = "[" + strings.Join(page, ",") + "]"
+	if _, err := fetchRange(s, "c1", "100", "999"); err == nil {
+		t.Errorf("fetched more than %d messages, want an error", maxRangeMessages)
+	}
+	if got := len(fake.sent("/channels/c1/messages")); got != 1 {
+		t.Errorf("fetched %d pages, want to stop at the cap", got)
+	}
+}
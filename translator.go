@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Translator is a translation backend the bot can send text to
+type Translator interface {
+	// Name identifies the backend in logs and command output
+	Name() string
+	Translate(ctx context.Context, req TranslateRequest) (TranslateResult, error)
+}
+
+// TranslateRequest describes one piece of text to translate. Only Text and
+// TargetLang are required; providers ignore hints they can't use.
+type TranslateRequest struct {
+	Text       string
+	TargetLang string
+	// SourceLang is the language of the text, if known
+	SourceLang string
+	Formality  Formality
+	// History holds recent exchanges from the same conversation, oldest
+	// first, for consistency
+	History []Exchange
+	// Context is background from the requester to disambiguate the text
+	Context string
+	// Instructions are configured for this language pair
+	Instructions string
+}
+
+// with returns a copy of the request for different text
+func (r TranslateRequest) with(text string) TranslateRequest {
+	r.Text = text
+	return r
+}
+
+// TranslateResult is a provider's translation and what it cost
+type TranslateResult struct {
+	Text string
+	// Provider is the Name of the translator that produced the result
+	Provider string
+	Usage    Usage
+}
+
+// Usage counts the tokens a provider billed for a request, where reported
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Formality is the register a translation should use
+type Formality string
+
+const (
+	FormalityDefault  Formality = ""
+	FormalityFormal   Formality = "formal"
+	FormalityInformal Formality = "informal"
+)
+
+// parseFormality reads a formality setting, accepting polite and casual as
+// well and treating anything unknown as the default
+func parseFormality(s string) Formality {
+	switch f := Formality(strings.ToLower(strings.TrimSpace(s))); f {
+	case FormalityFormal, FormalityInformal:
+		return f
+	case "polite":
+		return FormalityFormal
+	case "casual":
+		return FormalityInformal
+	}
+	return FormalityDefault
+}
+
+// Completer is implemented by translators backed by a general language model,
+// which can answer prompts other than plain translation
+type Completer interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+	// CompleteJSON returns a reply that is a single JSON object
+	CompleteJSON(ctx context.Context, prompt string) (string, error)
+}
+
+// translate runs text through the translator for targetLang with the
+// configured formatting options and the guild's settings
+func (h *DiscordHandler) translate(ctx context.Context, guildID, channelID, text, targetLang string) (string, error) {
+	result, err := h.translateDetailed(ctx, guildID, channelID, text, targetLang, "")
+	return result.Text, err
+}
+
+// translationResult is a finished translation and what is known about it
+type translationResult struct {
+	Text string
+	// SourceLang is the detected language of the original, if detected
+	SourceLang string
+}
+
+// translateDetailed is like translate but passes background from the
+// requester on to the prompt and reports the detected source language.
+// Translations with background bypass the cache since it can change their
+// meaning.
+func (h *DiscordHandler) translateDetailed(ctx context.Context, guildID, channelID, text, targetLang, requestContext string) (translationResult, error) {
+	if h.blocksLanguage(targetLang) {
+		return translationResult{}, errUnreliableLanguage
+	}
+	ctx = h.guildContext(ctx, guildID)
+	guild := h.guildConfig(guildID)
+	tokens := h.tokenOptions()
+	tokens.terms = guild.glossaryFor(targetLang)
+	req := TranslateRequest{
+		Text:       text,
+		TargetLang: targetLang,
+		Formality:  h.formalityFor(guildID, targetLang),
+		Context:    requestContext,
+	}
+	useCache := h.cache != nil && requestContext == ""
+
+	cacheLang := cacheLanguage(targetLang, req.Formality, tokens.terms)
+	if useCache {
+		if result, hit := h.cachedTranslation(guildID, channelID, text, targetLang, cacheLang); hit {
+			return result, nil
+		}
+	}
+
+	// Name the detected source language in the prompt to help with mixed
+	// or ambiguous messages
+	if h.detectsSource() || needsSourceFor(h.config.PairPrompts, targetLang) {
+		source, err := detectLanguage(ctx, h.completer, text)
+		if err != nil {
+			log.Printf("Continuing without source hint: %v", err)
+		} else {
+			req.SourceLang = source
+		}
+	}
+	req.Instructions = pairPrompt(h.config.PairPrompts, req.SourceLang, targetLang)
+
+	var conversation string
+	if h.conversations != nil && channelID != "" {
+		conversation = conversationKey(channelID, req.SourceLang, targetLang)
+		req.History = h.conversations.recent(conversation, time.Now())
+	}
+
+	// Line by line translation would break code blocks apart
+	byLine := h.config.PreserveLines && strings.Contains(text, "\n")
+	if tokens.codeComments && codeFencePattern.MatchString(text) {
+		byLine = false
+	}
+	run := func(t Translator) (string, error) {
+		var translation string
+		var err error
+		if byLine {
+			translation, err = translateLines(ctx, t, req, tokens)
+		} else {
+			translation, err = translateProtected(ctx, t, req, tokens)
+		}
+		if err != nil {
+			return "", err
+		}
+		return h.finishTranslation(text, translation)
+	}
+
+	started := time.Now()
+	translation, err := run(h.routeFor(targetLang))
+	if h.latencies != nil && err == nil {
+		h.latencies.record(time.Since(started), time.Now())
+	}
+	// A translation in the wrong script is often the source sent back
+	// unchanged; ask once more, naming the script
+	if err == nil && h.config.VerifyScript && scriptMismatch(translation, targetLang) {
+		log.Printf("Translation to %s came back in %s script, retrying", targetLang, detectScript(translation))
+		req.Instructions = strings.TrimSpace(req.Instructions + " " + scriptInstruction(targetLang))
+		translation, err = run(h.routeFor(targetLang))
+	}
+	if err == nil && h.escalation != nil && slices.Contains(h.config.QualityChannels, channelID) {
+		translation = h.checkQuality(ctx, text, translation, targetLang, run)
+	}
+	h.metrics.recordTranslation(targetLang, err)
+	if errors.Is(err, errBusy) {
+		h.recordDropped(DroppedBusy, guildID, "")
+	}
+	if err != nil {
+		return translationResult{}, err
+	}
+	if conversation != "" {
+		h.conversations.record(conversation, Exchange{Original: text, Translation: translation}, time.Now())
+	}
+	// Translations shaped by a conversation don't stand on their own
+	h.recordFresh(guildID, channelID, text, targetLang, cacheLang, translationResult{Text: translation, SourceLang: req.SourceLang}, useCache && len(req.History) == 0)
+	return translationResult{Text: translation, SourceLang: req.SourceLang}, nil
+}
+
+// cachedTranslation looks text up in the cache, counting a hit as a
+// translation
+func (h *DiscordHandler) cachedTranslation(guildID, channelID, text, targetLang, cacheLang string) (translationResult, bool) {
+	translation, source, hit := h.cache.get(text, cacheLang, time.Now())
+	h.metrics.recordCacheLookup(hit)
+	if !hit {
+		return translationResult{}, false
+	}
+	h.metrics.recordTranslation(targetLang, nil)
+	h.history.record(guildID, channelID, source, targetLang, text, translation, true, time.Now())
+	return translationResult{Text: translation, SourceLang: source}, true
+}
+
+// recordFresh keeps a new translation in the history and glossary
+// suggestions, and in the cache when cache is set
+func (h *DiscordHandler) recordFresh(guildID, channelID, text, targetLang, cacheLang string, result translationResult, cache bool) {
+	h.history.record(guildID, channelID, result.SourceLang, targetLang, text, result.Text, false, time.Now())
+	if h.terms != nil && guildID != "" {
+		h.terms.observe(guildID, text, result.Text)
+	}
+	if cache {
+		h.cache.put(text, cacheLang, result.Text, result.SourceLang, time.Now())
+	}
+}
+
+// finishTranslation tidies the model's translation of original into what
+// is shown to users
+func (h *DiscordHandler) finishTranslation(original, translation string) (string, error) {
+	// A message that is itself a quotation keeps its quotes
+	if h.config.StripWrappers && !quotedWhole(original) {
+		translation = cleanModelOutput(translation)
+	}
+	translation, err := h.postProcess.Process(translation)
+	if err != nil {
+		return "", err
+	}
+	return neutralizeMassMentions(translation, h.config.MassMentions), nil
+}
+
+// detectsSource reports whether every translation starts by detecting the
+// source language
+func (h *DiscordHandler) detectsSource() bool {
+	return h.config.IncludeSourceHint || h.config.DetectSource || h.config.ShowSourceLang
+}
+
+// cacheLanguage is the language a translation is cached under. Translations
+// in different registers, or with different forced terms, are cached
+// separately.
+func cacheLanguage(targetLang string, formality Formality, terms map[string]string) string {
+	lang := targetLang
+	if formality != FormalityDefault {
+		lang += "/" + string(formality)
+	}
+	if len(terms) > 0 {
+		lang += "/" + glossaryKey(terms)
+	}
+	return lang
+}
+
+// tokenOptions returns the configured kinds of span to protect
+func (h *DiscordHandler) tokenOptions() tokenOptions {
+	return tokenOptions{
+		emoji:        h.config.PreserveEmoji,
+		numbers:      h.config.PreserveNumbers,
+		links:        h.config.TranslateLinkText,
+		codeComments: h.config.TranslateCodeComments,
+		massMentions: h.config.MassMentions,
+	}
+}
+
+// routeFor picks the translator for a target language, falling back to the
+// default translator when no route is configured
+func (h *DiscordHandler) routeFor(targetLang string) Translator {
+	if t, ok := h.routes[strings.ToLower(targetLang)]; ok {
+		return t
+	}
+	return h.translators[0]
+}
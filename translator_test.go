@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseFormality(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Formality
+	}{
+		{"", FormalityDefault},
+		{"formal", FormalityFormal},
+		{"informal", FormalityInformal},
+		{" Formal ", FormalityFormal},
+		{"INFORMAL", FormalityInformal},
+		{"polite", FormalityFormal},
+		{"casual", FormalityInformal},
+		{"default", FormalityDefault},
+		{"rude", FormalityDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := parseFormality(tt.s); got != tt.want {
+				t.Errorf("parseFormality(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteFor(t *testing.T) {
+	fallback := &fakeTranslator{}
+	french := &delayedTranslator{name: "french"}
+	h := newTestHandler(t, Config{}, fallback)
+	h.routes["french"] = french
+	tests := []struct {
+		lang string
+		want Translator
+	}{
+		{"French", french},
+		{"french", french},
+		{"Spanish", fallback},
+	}
+	for _, tt := range tests {
+		if got := h.routeFor(tt.lang); got != tt.want {
+			t.Errorf("routeFor(%q) = %s, want %s", tt.lang, got.Name(), tt.want.Name())
+		}
+	}
+}
+
+func TestTranslationsFollowRoutes(t *testing.T) {
+	fallback := &fakeTranslator{}
+	routed := &fakeTranslator{reply: func(req TranslateRequest) (string, error) { return "bonjour", nil }}
+	h := newTestHandler(t, Config{}, fallback)
+	h.routes["french"] = routed
+
+	french, err := h.translateDetailed(context.Background(), "g1", "c1", "hello", "French", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spanish, err := h.translateDetailed(context.Background(), "g1", "c1", "hello", "Spanish", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if french.Text != "bonjour" || spanish.Text != "translated: hello" {
+		t.Errorf("translations = %q, %q, want French from its route and Spanish from the default", french.Text, spanish.Text)
+	}
+	if routed.count() != 1 || fallback.count() != 1 {
+		t.Errorf("routed got %d calls and the default %d, want 1 each", routed.count(), fallback.count())
+	}
+}
+
+func TestSourceHint(t *testing.T) {
+	tests := []struct {
+		name        string
+		hint        bool
+		detected    string
+		wantSource  string
+		wantDetects int
+	}{
+		{name: "off", detected: "Spanish"},
+		{name: "on", hint: true, detected: "Spanish.", wantSource: "Spanish", wantDetects: 1},
+		{name: "detection failing", hint: true, detected: "", wantDetects: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := &fakeTranslator{}
+			h := newTestHandler(t, Config{IncludeSourceHint: tt.hint}, translator)
+			completer := &fakeCompleter{reply: tt.detected}
+			h.completer = completer
+
+			result, err := h.translateDetailed(context.Background(), "g1", "c1", "hola amigo, how are you?", "French", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if completer.count() != tt.wantDetects {
+				t.Errorf("detected %d times, want %d", completer.count(), tt.wantDetects)
+			}
+			if got := translator.calls[0].SourceLang; got != tt.wantSource {
+				t.Errorf("request source = %q, want %q", got, tt.wantSource)
+			}
+			if result.SourceLang != tt.wantSource {
+				t.Errorf("result source = %q, want %q", result.SourceLang, tt.wantSource)
+			}
+		})
+	}
+}
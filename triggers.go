@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// LanguageTrigger resolves a reaction emoji to the language it asks for
+type LanguageTrigger interface {
+	Language(emoji string) (string, bool)
+}
+
+// emojiTrigger is a LanguageTrigger backed by a fixed emoji to language map
+type emojiTrigger map[string]string
+
+func (m emojiTrigger) Language(emoji string) (string, bool) {
+	lang, ok := m[emoji]
+	return lang, ok
+}
+
+// keycapEmoji returns the keycap emoji for a single digit, e.g. 1️⃣
+func keycapEmoji(digit string) string {
+	return digit + "\uFE0F\u20E3"
+}
+
+// numberTrigger builds a trigger mapping keycap emoji 1️⃣–9️⃣ to languages,
+// from a map of digit to language name
+func numberTrigger(numberLangs map[string]string) (emojiTrigger, error) {
+	trigger := emojiTrigger{}
+	for digit, lang := range numberLangs {
+		if len(digit) != 1 || digit < "1" || digit > "9" {
+			return nil, fmt.Errorf("invalid menu number %q, must be 1-9", digit)
+		}
+		trigger[keycapEmoji(digit)] = lang
+	}
+	return trigger, nil
+}
+
+// numberLegend lists the number menu in order, one emoji and language per line
+func numberLegend(numberLangs map[string]string) string {
+	digits := make([]string, 0, len(numberLangs))
+	for digit := range numberLangs {
+		digits = append(digits, digit)
+	}
+	sort.Strings(digits)
+
+	var b strings.Builder
+	for _, digit := range digits {
+		fmt.Fprintf(&b, "%s %s\n", keycapEmoji(digit), numberLangs[digit])
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// resolveLanguage checks each configured trigger scheme in turn
+func (h *DiscordHandler) resolveLanguage(emoji string) (string, bool) {
+	for _, t := range h.triggers {
+		if lang, ok := t.Language(emoji); ok {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+func (h *DiscordHandler) langMenuCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	content := "No number menu is configured."
+	if len(h.config.NumberLanguages) > 0 {
+		content = "React with a number to translate a message:\n" + numberLegend(h.config.NumberLanguages)
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: content},
+	})
+	if err != nil {
+		log.Printf("Error sending language menu: %v", err)
+	}
+}
+
+// flagLanguages lists every language reachable by a flag reaction, sorted
+func flagLanguages() []string {
+	seen := make(map[string]bool)
+	var langs []string
+	for _, lang := range flagToLang {
+		if !seen[lang] {
+			seen[lang] = true
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// isFlagEmoji reports whether emoji is a flag: a pair of regional indicators
+// such as 🇵🇭, a subdivision flag such as 🏴󠁧󠁢󠁳󠁣󠁴󠁿, or another flag sequence
+// such as 🏴‍☠️ or 🏳️‍🌈
+func isFlagEmoji(emoji string) bool {
+	runes := []rune(emoji)
+	if len(runes) == 0 {
+		return false
+	}
+	if isRegionalIndicator(runes[0]) {
+		return len(runes) == 2 && isRegionalIndicator(runes[1])
+	}
+	switch runes[0] {
+	case '\U0001F3F4', '\U0001F3F3', '\U0001F6A9', '\U0001F3C1': // black, white, triangular and chequered flags
+		return true
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= '\U0001F1E6' && r <= '\U0001F1FF'
+}
+
+// unmappedFlagNotice lists the flags that do translate
+func unmappedFlagNotice() string {
+	flags := make([]string, 0, len(flagToLang))
+	for flag := range flagToLang {
+		flags = append(flags, flag)
+	}
+	sort.Strings(flags)
+
+	var b strings.Builder
+	b.WriteString("That flag isn't mapped to a language. Supported flags are:")
+	for _, flag := range flags {
+		fmt.Fprintf(&b, " %s %s", flag, flagToLang[flag])
+	}
+	return b.String()
+}
+
+// Flags shown for languages reachable by more than one flag
+var preferredFlags = map[string]string{
+	"english": "🇺🇸",
+	"arabic":  "🇸🇦",
+}
+
+// langToFlag maps lowercased language names back to a representative flag
+var langToFlag = reverseFlags(flagToLang, preferredFlags)
+
+// reverseFlags inverts a flag map, taking the preferred flag for languages
+// with several and otherwise the first in sorted order
+func reverseFlags(flags map[string]string, preferred map[string]string) map[string]string {
+	sorted := make([]string, 0, len(flags))
+	for flag := range flags {
+		sorted = append(sorted, flag)
+	}
+	sort.Strings(sorted)
+
+	langs := make(map[string]string)
+	for _, flag := range sorted {
+		lang := strings.ToLower(flags[flag])
+		if _, ok := langs[lang]; !ok {
+			langs[lang] = flag
+		}
+	}
+	for lang, flag := range preferred {
+		langs[lang] = flag
+	}
+	return langs
+}
+
+// addLanguageFlag prefixes the footer of a translation with the target
+// language's flag, if it has one
+func addLanguageFlag(embed *discordgo.MessageEmbed, targetLang string) {
+	flag, ok := langToFlag[strings.ToLower(targetLang)]
+	if !ok || embed.Footer == nil {
+		return
+	}
+	embed.Footer.Text = flag + " " + embed.Footer.Text
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestNumberTrigger(t *testing.T) {
+	tests := []struct {
+		name    string
+		langs   map[string]string
+		want    emojiTrigger
+		wantErr bool
+	}{
+		{name: "maps keycaps", langs: map[string]string{"1": "Spanish", "9": "Tagalog"}, want: emojiTrigger{"1\uFE0F\u20E3": "Spanish", "9\uFE0F\u20E3": "Tagalog"}},
+		{name: "empty", langs: map[string]string{}, want: emojiTrigger{}},
+		{name: "zero", langs: map[string]string{"0": "Spanish"}, wantErr: true},
+		{name: "two digits", langs: map[string]string{"10": "Spanish"}, wantErr: true},
+		{name: "not a digit", langs: map[string]string{"a": "Spanish"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := numberTrigger(tt.langs)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("numberTrigger(%v) = %v, want an error", tt.langs, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("numberTrigger(%v) = %q, want %q", tt.langs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumberLegend(t *testing.T) {
+	got := numberLegend(map[string]string{"3": "Korean", "1": "Spanish", "2": "French"})
+	want := "1\uFE0F\u20E3 Spanish\n2\uFE0F\u20E3 French\n3\uFE0F\u20E3 Korean"
+	if got != want {
+		t.Errorf("numberLegend() = %q, want %q", got, want)
+	}
+}
+
+func TestResolveLanguage(t *testing.T) {
+	numbers, err := numberTrigger(map[string]string{"1": "Spanish"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newTestHandler(t, Config{}, &fakeTranslator{})
+	h.triggers = []LanguageTrigger{emojiTrigger(flagToLang), numbers}
+	tests := []struct {
+		name  string
+		emoji string
+		want  string
+		ok    bool
+	}{
+		{"flag", "🇫🇷", "French", true},
+		{"number", keycapEmoji("1"), "Spanish", true},
+		{"unmapped number", keycapEmoji("2"), "", false},
+		{"other emoji", "👍", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := h.resolveLanguage(tt.emoji)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("resolveLanguage(%q) = %q, %v, want %q, %v", tt.emoji, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLangMenuCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		langs map[string]string
+		want  string
+	}{
+		{"legend", map[string]string{"1": "Spanish"}, "React with a number to translate a message:\n1\uFE0F\u20E3 Spanish"},
+		{"no menu", nil, "No number menu is configured."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			h := newTestHandler(t, Config{NumberLanguages: tt.langs}, &fakeTranslator{})
+			h.langMenuCommand(s, commandInteraction("langmenu", "u1", nil))
+			var response discordgo.InteractionResponse
+			callbacks := fake.sent("/callback")
+			if len(callbacks) != 1 {
+				t.Fatalf("got %d responses, want 1", len(callbacks))
+			}
+			if err := json.Unmarshal([]byte(callbacks[0].Body), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Data.Content != tt.want {
+				t.Errorf("menu = %q, want %q", response.Data.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsFlagEmoji(t *testing.T) {
+	tests := []struct {
+		name  string
+		emoji string
+		want  bool
+	}{
+		{"country flag", "🇵🇭", true},
+		{"unmapped country flag", "🇦🇶", true},
+		{"subdivision flag", "\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F", true},
+		{"pirate flag", "\U0001F3F4\u200d☠\ufe0f", true},
+		{"rainbow flag", "\U0001F3F3\ufe0f\u200d\U0001F308", true},
+		{"chequered flag", "\U0001F3C1", true},
+		{"lone regional indicator", "\U0001F1F5", false},
+		{"three regional indicators", "🇵🇭\U0001F1F5", false},
+		{"thumbs up", "👍", false},
+		{"custom emoji name", "flag_ph", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isFlagEmoji(tt.emoji); got != tt.want {
+				t.Errorf("isFlagEmoji(%q) = %v, want %v", tt.emoji, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmappedFlagHint(t *testing.T) {
+	scotland := "\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F"
+	tests := []struct {
+		name      string
+		notify    bool
+		emoji     []string
+		wantHints int
+	}{
+		{name: "unmapped flag hinted", notify: true, emoji: []string{scotland}, wantHints: 1},
+		{name: "hinted once", notify: true, emoji: []string{scotland, "🇦🇶", scotland}, wantHints: 1},
+		{name: "other emoji quiet", notify: true, emoji: []string{"👍", "🎉"}},
+		{name: "mapped flag translates instead", notify: true, emoji: []string{"🇫🇷"}},
+		{name: "off by default", emoji: []string{scotland}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"author"}}`
+			h := newTestHandler(t, Config{NotifyUnmappedFlags: tt.notify}, &fakeTranslator{})
+			h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+
+			for _, emoji := range tt.emoji {
+				h.reactionAdd(s, testReaction("user", emoji))
+			}
+
+			var hints []discordgo.MessageSend
+			for _, msg := range sentMessages(t, fake, "dm") {
+				if msg.Content == unmappedFlagNotice() {
+					hints = append(hints, msg)
+				}
+			}
+			if len(hints) != tt.wantHints {
+				t.Errorf("sent %d hints, want %d", len(hints), tt.wantHints)
+			}
+		})
+	}
+}
+
+func TestUnmappedFlagNotice(t *testing.T) {
+	notice := unmappedFlagNotice()
+	for _, want := range []string{"isn't mapped", "🇵🇭 Filipino", "🇫🇷 French"} {
+		if !strings.Contains(notice, want) {
+			t.Errorf("unmappedFlagNotice() = %q, want it to contain %q", notice, want)
+		}
+	}
+}
+
+func TestReverseFlags(t *testing.T) {
+	flags := map[string]string{"🇬🇧": "English", "🇺🇸": "English", "🇫🇷": "French", "🇧🇪": "French"}
+	tests := []struct {
+		name      string
+		preferred map[string]string
+		want      map[string]string
+	}{
+		{
+			name: "first in sorted order",
+			want: map[string]string{"english": "🇬🇧", "french": "🇧🇪"},
+		},
+		{
+			name:      "preferred flag wins",
+			preferred: map[string]string{"english": "🇺🇸"},
+			want:      map[string]string{"english": "🇺🇸", "french": "🇧🇪"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reverseFlags(flags, tt.preferred); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reverseFlags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLangToFlag(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"english", "🇺🇸"},
+		{"arabic", "🇸🇦"},
+		{"filipino", "🇵🇭"},
+		{"french", "🇫🇷"},
+	}
+	for _, tt := range tests {
+		if got := langToFlag[tt.lang]; got != tt.want {
+			t.Errorf("langToFlag[%q] = %s, want %s", tt.lang, got, tt.want)
+		}
+	}
+	// Every flag found leads back to its own language
+	for lang, flag := range langToFlag {
+		if !strings.EqualFold(flagToLang[flag], lang) {
+			t.Errorf("langToFlag[%q] = %s, which is %s", lang, flag, flagToLang[flag])
+		}
+	}
+}
+
+func TestAddLanguageFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		want string
+	}{
+		{name: "known language", lang: "Japanese", want: "🇯🇵 Translated to Japanese"},
+		{name: "any case", lang: "JAPANESE", want: "🇯🇵 Translated to JAPANESE"},
+		{name: "no flag", lang: "Klingon", want: "Translated to Klingon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embed := translationEmbed(testMessage("hello"), "translation", tt.lang)
+			addLanguageFlag(embed, tt.lang)
+			if got := strings.TrimSuffix(embed.Footer.Text, translationMarker); got != tt.want {
+				t.Errorf("footer = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShowFlag(t *testing.T) {
+	for _, show := range []bool{false, true} {
+		s, fake := newTestSession(t)
+		fake.replies["/channels/c1/messages/m1"] = `{"id":"m1","channel_id":"c1","content":"hello","author":{"id":"author"}}`
+		h := newTestHandler(t, Config{ShowFlag: show}, &fakeTranslator{})
+		h.triggers = []LanguageTrigger{emojiTrigger(flagToLang)}
+
+		h.reactionAdd(s, testReaction("user", "🇯🇵"))
+
+		messages := sentMessages(t, fake, "c1")
+		if len(messages) != 1 || len(messages[0].Embeds) != 1 {
+			t.Fatalf("sent %v, want one translation embed", messages)
+		}
+		footer := messages[0].Embeds[0].Footer.Text
+		if flagged := strings.HasPrefix(footer, "🇯🇵 "); flagged != show {
+			t.Errorf("SHOW_FLAG=%v: footer = %q", show, footer)
+		}
+	}
+}
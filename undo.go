@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// How many recent translations are remembered per channel
+	undoCapacity = 50
+
+	// Discord only bulk-deletes messages younger than two weeks
+	bulkDeleteMaxAge = 14 * 24 * time.Hour
+)
+
+// postedRing remembers the most recent translation message IDs in a channel,
+// overwriting the oldest once full
+type postedRing struct {
+	ids   [undoCapacity]string
+	start int
+	size  int
+}
+
+func (r *postedRing) push(id string) {
+	r.ids[(r.start+r.size)%undoCapacity] = id
+	if r.size < undoCapacity {
+		r.size++
+	} else {
+		r.start = (r.start + 1) % undoCapacity
+	}
+}
+
+// pop removes and returns up to n IDs, newest first
+func (r *postedRing) pop(n int) []string {
+	n = min(n, r.size)
+	ids := make([]string, 0, n)
+	for range n {
+		r.size--
+		ids = append(ids, r.ids[(r.start+r.size)%undoCapacity])
+	}
+	return ids
+}
+
+// postedTranslations tracks translations the bot posted, per channel
+type postedTranslations struct {
+	mu       sync.Mutex
+	channels map[string]*postedRing
+}
+
+func newPostedTranslations() *postedTranslations {
+	return &postedTranslations{channels: make(map[string]*postedRing)}
+}
+
+func (p *postedTranslations) record(channelID, messageID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ring, ok := p.channels[channelID]
+	if !ok {
+		ring = &postedRing{}
+		p.channels[channelID] = ring
+	}
+	ring.push(messageID)
+}
+
+func (p *postedTranslations) take(channelID string, n int) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ring, ok := p.channels[channelID]
+	if !ok {
+		return nil
+	}
+	return ring.pop(n)
+}
+
+// deleteMessages removes messages, bulk-deleting the ones Discord allows
+// and deleting older ones one at a time. It returns how many were deleted.
+func deleteMessages(s *discordgo.Session, channelID string, ids []string, now time.Time) int {
+	var recent, old []string
+	for _, id := range ids {
+		created, err := discordgo.SnowflakeTimestamp(id)
+		if err == nil && now.Sub(created) < bulkDeleteMaxAge {
+			recent = append(recent, id)
+		} else {
+			old = append(old, id)
+		}
+	}
+
+	deleted := 0
+	if len(recent) >= 2 {
+		if err := s.ChannelMessagesBulkDelete(channelID, recent); err != nil {
+			log.Printf("Error bulk deleting translations: %v", err)
+		} else {
+			deleted += len(recent)
+		}
+	} else {
+		old = append(old, recent...)
+	}
+
+	for _, id := range old {
+		if err := s.ChannelMessageDelete(channelID, id); err != nil {
+			log.Printf("Error deleting translation: %v", err)
+			continue
+		}
+		deleted++
+	}
+	return deleted
+}
+
+func (h *DiscordHandler) undoCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	count := 1
+	if opt, ok := commandOptions(i)["count"]; ok {
+		count = int(opt.IntValue())
+	}
+
+	ids := h.posted.take(i.ChannelID, count)
+	if len(ids) == 0 {
+		respondEphemeral(s, i, "There are no recent translations to remove in this channel.")
+		return
+	}
+
+	if err := deferEphemeral(s, i); err != nil {
+		log.Printf("Error deferring undo response: %v", err)
+		return
+	}
+	deleted := deleteMessages(s, i.ChannelID, ids, time.Now())
+	editResponseText(s, i, fmt.Sprintf("Removed %d translation(s).", deleted))
+}
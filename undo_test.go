@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// countdown returns the IDs from down to to, newest first
+func countdown(from, to int) []string {
+	out := []string{}
+	for n := from; n >= to; n-- {
+		out = append(out, fmt.Sprint(n))
+	}
+	return out
+}
+
+func TestPostedRing(t *testing.T) {
+	tests := []struct {
+		name   string
+		pushed int
+		pops   []int
+		want   [][]string
+	}{
+		{"newest first", 3, []int{2}, [][]string{{"3", "2"}}},
+		{"more than held", 2, []int{5}, [][]string{{"2", "1"}}},
+		{"empty", 0, []int{1}, [][]string{{}}},
+		{"pops continue", 3, []int{1, 1, 1, 1}, [][]string{{"3"}, {"2"}, {"1"}, {}}},
+		{"overwrites the oldest", undoCapacity + 5, []int{undoCapacity + 5}, [][]string{countdown(undoCapacity+5, 6)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r postedRing
+			for n := 1; n <= tt.pushed; n++ {
+				r.push(fmt.Sprint(n))
+			}
+			for n, pop := range tt.pops {
+				if got := r.pop(pop); !slices.Equal(got, tt.want[n]) {
+					t.Errorf("pop %d of %d = %v, want %v", n+1, pop, got, tt.want[n])
+				}
+			}
+		})
+	}
+}
+
+func TestPostedTranslationsPerChannel(t *testing.T) {
+	p := newPostedTranslations()
+	p.record("c1", "a")
+	p.record("c2", "b")
+	p.record("c1", "c")
+
+	if got := p.take("c1", 5); !slices.Equal(got, []string{"c", "a"}) {
+		t.Errorf("take(c1) = %v, want [c a]", got)
+	}
+	if got := p.take("c2", 5); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("take(c2) = %v, want [b]", got)
+	}
+	if got := p.take("c3", 5); len(got) != 0 {
+		t.Errorf("take(c3) = %v, want nothing", got)
+	}
+}
+
+func TestDeleteMessages(t *testing.T) {
+	now := time.Now()
+	recent1 := snowflakeAt(now.Add(-time.Hour))
+	recent2 := snowflakeAt(now.Add(-24 * time.Hour))
+	old := snowflakeAt(now.Add(-bulkDeleteMaxAge - time.Hour))
+	tests := []struct {
+		name       string
+		ids        []string
+		wantBulk   bool
+		wantSingle []string
+	}{
+		{"recent in bulk", []string{recent1, recent2}, true, nil},
+		{"old one at a time", []string{recent1, recent2, old}, true, []string{old}},
+		{"a single recent one", []string{recent1}, false, []string{recent1}},
+		{"one recent with an old one", []string{recent1, old}, false, []string{old, recent1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			if got := deleteMessages(s, "c1", tt.ids, now); got != len(tt.ids) {
+				t.Errorf("deleteMessages() = %d, want %d", got, len(tt.ids))
+			}
+			if bulk := len(fake.sent("/messages/bulk-delete")) == 1; bulk != tt.wantBulk {
+				t.Errorf("bulk deleted = %v, want %v", bulk, tt.wantBulk)
+			}
+			var single []string
+			for _, r := range fake.requests {
+				if r.Method == "DELETE" {
+					single = append(single, r.Path[len("/api/v9/channels/c1/messages/"):])
+				}
+			}
+			if !slices.Equal(single, tt.wantSingle) {
+				t.Errorf("deleted one at a time %v, want %v", single, tt.wantSingle)
+			}
+		})
+	}
+}
+
+func TestUndoCommandCapsCount(t *testing.T) {
+	s, fake := newTestSession(t)
+	h := newTestHandler(t, Config{}, &fakeTranslator{})
+	for n := range 3 {
+		h.posted.record("c1", snowflakeAt(time.Now().Add(-time.Duration(n+1)*time.Hour)))
+	}
+	i := commandInteraction("undo", "u1", nil)
+	h.undoCommand(s, i)
+
+	if got := len(fake.sent("/messages/bulk-delete")) + countDeletes(fake); got != 1 {
+		t.Errorf("made %d delete calls, want 1 for the default count of 1", got)
+	}
+	if left := h.posted.take("c1", undoCapacity); len(left) != 2 {
+		t.Errorf("%d translations left to undo, want 2", len(left))
+	}
+}
+
+// countDeletes counts single message deletions
+func countDeletes(fake *fakeDiscord) int {
+	n := 0
+	for _, r := range fake.requests {
+		if r.Method == "DELETE" {
+			n++
+		}
+	}
+	return n
+}
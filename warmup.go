@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// warmup checks the whole provider path end to end with a trivial
+// translation, catching bad tokens, models or endpoints before users do
+func warmup(ctx context.Context, t Translator) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result, err := t.Translate(ctx, TranslateRequest{Text: "hello", TargetLang: "Spanish"})
+	if err != nil {
+		return fmt.Errorf("error translating with %s: %v", t.Name(), err)
+	}
+	if strings.TrimSpace(result.Text) == "" {
+		return fmt.Errorf("%s returned an empty translation", t.Name())
+	}
+	return nil
+}
+
+// selfTest runs warmup at startup. A failure is only returned when strict
+// is set; otherwise it is logged and the bot starts anyway.
+func selfTest(ctx context.Context, t Translator, strict bool) error {
+	err := warmup(ctx, t)
+	switch {
+	case err == nil:
+		log.Printf("Startup self-test passed")
+	case strict:
+		return err
+	default:
+		log.Printf("Startup self-test failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	errDown := errors.New("invalid API key")
+	tests := []struct {
+		name    string
+		reply   func(TranslateRequest) (string, error)
+		strict  bool
+		wantErr bool
+	}{
+		{name: "passes", strict: true},
+		{name: "failure is tolerated", reply: func(TranslateRequest) (string, error) { return "", errDown }},
+		{name: "strict failure", reply: func(TranslateRequest) (string, error) { return "", errDown }, strict: true, wantErr: true},
+		{name: "strict empty translation", reply: func(TranslateRequest) (string, error) { return " \n", nil }, strict: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := &fakeTranslator{reply: tt.reply}
+			err := selfTest(context.Background(), translator, tt.strict)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("selfTest() error = %v, want error %v", err, tt.wantErr)
+			}
+			if translator.count() != 1 {
+				t.Errorf("translator called %d times, want 1", translator.count())
+			}
+		})
+	}
+}
+
+func TestWarmupNamesTheProvider(t *testing.T) {
+	translator := &fakeTranslator{reply: func(TranslateRequest) (string, error) { return "", nil }}
+	err := warmup(context.Background(), translator)
+	if err == nil || err.Error() != "fake returned an empty translation" {
+		t.Errorf("warmup() error = %v, want the provider named", err)
+	}
+}
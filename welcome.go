@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// welcomeScreen is a guild's welcome screen. discordgo has no type for it.
+type welcomeScreen struct {
+	Description     string           `json:"description"`
+	WelcomeChannels []welcomeChannel `json:"welcome_channels"`
+}
+
+type welcomeChannel struct {
+	ChannelID   string `json:"channel_id"`
+	Description string `json:"description"`
+	EmojiName   string `json:"emoji_name"`
+}
+
+func (w *welcomeScreen) empty() bool {
+	return w == nil || (w.Description == "" && len(w.WelcomeChannels) == 0)
+}
+
+// fetchWelcomeScreen returns the guild's welcome screen, or nil if it has
+// none
+func fetchWelcomeScreen(s *discordgo.Session, guildID string) (*welcomeScreen, error) {
+	endpoint := discordgo.EndpointGuild(guildID) + "/welcome-screen"
+	body, err := s.RequestWithBucketID("GET", endpoint, nil, endpoint)
+	if restStatus(err) == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var screen welcomeScreen
+	if err := json.Unmarshal(body, &screen); err != nil {
+		return nil, fmt.Errorf("error decoding welcome screen: %v", err)
+	}
+	return &screen, nil
+}
+
+// welcomeParts lists the texts of a welcome screen that need translating
+func welcomeParts(screen *welcomeScreen) []string {
+	var parts []string
+	if screen.Description != "" {
+		parts = append(parts, screen.Description)
+	}
+	for _, c := range screen.WelcomeChannels {
+		if c.Description != "" {
+			parts = append(parts, c.Description)
+		}
+	}
+	return parts
+}
+
+// welcomeEmbed lays out a translated welcome screen for admins to copy.
+// translated maps each original text to its translation.
+func welcomeEmbed(screen *welcomeScreen, translated map[string]string, targetLang string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       "Welcome screen",
+		Description: "*No description*",
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Welcome screen translated to %s", targetLang),
+		},
+		Color: 0x00BFFF, // Light blue color
+	}
+	if screen.Description != "" {
+		embed.Description = truncate(translated[screen.Description], maxDescriptionLength)
+	}
+	for _, c := range screen.WelcomeChannels {
+		if c.Description == "" || len(embed.Fields) == maxEmbedFields {
+			continue
+		}
+		name := c.EmojiName + " Channel"
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  truncate(name, maxFieldNameLength),
+			Value: truncate(fmt.Sprintf("<#%s>\n%s", c.ChannelID, translated[c.Description]), maxFieldValueLength),
+		})
+	}
+	return embed
+}
+
+func (h *DiscordHandler) translateWelcomeCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	targetLang := commandOptions(i)["language"].StringValue()
+	if h.blocksLanguage(targetLang) {
+		respondEphemeral(s, i, unreliableNotice(targetLang))
+		return
+	}
+	if !h.admitCommand(s, i) {
+		return
+	}
+
+	if err := deferEphemeral(s, i); err != nil {
+		log.Printf("Error deferring welcome screen response: %v", err)
+		return
+	}
+
+	screen, err := fetchWelcomeScreen(s, i.GuildID)
+	if err != nil {
+		log.Printf("Error fetching welcome screen: %v", err)
+		editResponseText(s, i, "Sorry, I couldn't read this server's welcome screen.")
+		return
+	}
+	if screen.empty() {
+		editResponseText(s, i, "This server doesn't have a welcome screen to translate.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	parts := welcomeParts(screen)
+	items := h.translateBatch(ctx, i.GuildID, parts, targetLang)
+	translated := make(map[string]string, len(parts))
+	for n, part := range parts {
+		translated[part] = items[n].display()
+	}
+
+	embed := welcomeEmbed(screen, translated, targetLang)
+	addDisclaimer(embed, h.qualityWarning(targetLang))
+	embeds := []*discordgo.MessageEmbed{embed}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Embeds: &embeds})
+	if err != nil {
+		log.Printf("Error sending welcome screen translation: %v", err)
+	}
+}
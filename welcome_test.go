@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWelcomeEmbed(t *testing.T) {
+	translated := map[string]string{
+		"Bienvenidos":    "Welcome",
+		"Lee las reglas": "Read the rules",
+		"Preséntate":     "Introduce yourself",
+	}
+	tests := []struct {
+		name       string
+		screen     welcomeScreen
+		wantParts  []string
+		wantDesc   string
+		wantFields []string
+	}{
+		{
+			name: "description and channels",
+			screen: welcomeScreen{
+				Description: "Bienvenidos",
+				WelcomeChannels: []welcomeChannel{
+					{ChannelID: "c1", Description: "Lee las reglas", EmojiName: "📜"},
+					{ChannelID: "c2", Description: "Preséntate"},
+				},
+			},
+			wantParts:  []string{"Bienvenidos", "Lee las reglas", "Preséntate"},
+			wantDesc:   "Welcome",
+			wantFields: []string{"📜 Channel: <#c1>\nRead the rules", " Channel: <#c2>\nIntroduce yourself"},
+		},
+		{
+			name: "no description",
+			screen: welcomeScreen{WelcomeChannels: []welcomeChannel{
+				{ChannelID: "c1", Description: "Lee las reglas"},
+			}},
+			wantParts:  []string{"Lee las reglas"},
+			wantDesc:   "*No description*",
+			wantFields: []string{" Channel: <#c1>\nRead the rules"},
+		},
+		{
+			name: "channels without descriptions left out",
+			screen: welcomeScreen{
+				Description:     "Bienvenidos",
+				WelcomeChannels: []welcomeChannel{{ChannelID: "c1"}},
+			},
+			wantParts: []string{"Bienvenidos"},
+			wantDesc:  "Welcome",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := welcomeParts(&tt.screen); !slices.Equal(got, tt.wantParts) {
+				t.Errorf("welcomeParts() = %q, want %q", got, tt.wantParts)
+			}
+			embed := welcomeEmbed(&tt.screen, translated, "English")
+			if embed.Description != tt.wantDesc {
+				t.Errorf("description = %q, want %q", embed.Description, tt.wantDesc)
+			}
+			var fields []string
+			for _, f := range embed.Fields {
+				fields = append(fields, f.Name+": "+f.Value)
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("fields = %q, want %q", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestWelcomeScreenEmpty(t *testing.T) {
+	var missing *welcomeScreen
+	if !missing.empty() || !(&welcomeScreen{}).empty() {
+		t.Error("no welcome screen isn't empty")
+	}
+	if (&welcomeScreen{Description: "hi"}).empty() {
+		t.Error("welcome screen with a description is empty")
+	}
+}
+
+func TestTranslateWelcomeCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    string
+		status   int
+		wantText string
+		wantDesc string
+	}{
+		{name: "translated", reply: `{"description":"Bienvenidos","welcome_channels":[{"channel_id":"c1","description":"Lee las reglas"}]}`, wantDesc: "en:Bienvenidos"},
+		{name: "no welcome screen", status: http.StatusNotFound, wantText: "doesn't have a welcome screen"},
+		{name: "empty welcome screen", reply: `{"description":"","welcome_channels":[]}`, wantText: "doesn't have a welcome screen"},
+		{name: "fetch failing", status: http.StatusForbidden, wantText: "couldn't read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSession(t)
+			if tt.reply != "" {
+				fake.replies["/guilds/g1/welcome-screen"] = tt.reply
+			}
+			if tt.status != 0 {
+				fake.statuses = map[string]int{"/guilds/g1/welcome-screen": tt.status}
+			}
+			translator := &fakeTranslator{reply: func(req TranslateRequest) (string, error) {
+				return strings.ReplaceAll(req.Text, "]] ", "]] en:"), nil
+			}}
+			h := newTestHandler(t, Config{}, translator)
+
+			h.translateWelcomeCommand(s, commandInteraction("translate-welcome", "user", map[string]string{"language": "English"}))
+
+			edits := responseEdits(t, fake)
+			if len(edits) != 1 {
+				t.Fatalf("got %d response edits, want 1", len(edits))
+			}
+			if tt.wantText != "" {
+				if edits[0].Content == nil || !strings.Contains(*edits[0].Content, tt.wantText) {
+					t.Errorf("response = %v, want it to contain %q", edits[0].Content, tt.wantText)
+				}
+				if translator.count() != 0 {
+					t.Errorf("translated %d times, want none", translator.count())
+				}
+				return
+			}
+			if edits[0].Embeds == nil || len(*edits[0].Embeds) != 1 {
+				t.Fatalf("response embeds = %v, want 1", edits[0].Embeds)
+			}
+			embed := (*edits[0].Embeds)[0]
+			if embed.Description != tt.wantDesc || len(embed.Fields) != 1 || !strings.HasSuffix(embed.Fields[0].Value, "en:Lee las reglas") {
+				t.Errorf("embed = %q, %+v, want the translated screen", embed.Description, embed.Fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// A label the model put before its answer, e.g. "Translation:" or
+// "Here is the translation:". Only matched at the very start.
+var wrapperLabelPattern = regexp.MustCompile(`(?i)^\s*(translation|translated text|here is the translation[^:\n]{0,40}|here's the translation[^:\n]{0,40})\s*:\s*`)
+
+// A closing remark the model added after a blank line, e.g. "Note: ..."
+var wrapperNotePattern = regexp.MustCompile(`(?is)\n\s*\n\s*\(?(note|translator's note|n\.b\.)\s*:[^\n]*\)?\s*$`)
+
+// Quote pairs a model may wrap its whole answer in
+var wrapperQuotes = map[rune]rune{
+	'"':  '"',
+	'\'': '\'',
+	'“':  '”',
+	'«':  '»',
+	'「':  '」',
+}
+
+// quotedWhole reports whether s is a single quotation: it opens and closes
+// with a matching pair and has no other quote of that kind inside
+func quotedWhole(s string) bool {
+	s = strings.TrimSpace(s)
+	open, size := utf8.DecodeRuneInString(s)
+	close, ok := wrapperQuotes[open]
+	if !ok || len(s) <= size {
+		return false
+	}
+	last, lastSize := utf8.DecodeLastRuneInString(s)
+	if last != close {
+		return false
+	}
+	inner := s[size : len(s)-lastSize]
+	return !strings.ContainsRune(inner, open) && !strings.ContainsRune(inner, close)
+}
+
+// cleanModelOutput strips conversational wrappers a model sometimes adds
+// despite being told not to: a leading label, quotes around the whole
+// answer and a trailing note. Quotes are only removed when nothing else in
+// the text uses them, so quoted phrases inside a translation survive.
+func cleanModelOutput(s string) string {
+	s = wrapperNotePattern.ReplaceAllString(s, "")
+	s = wrapperLabelPattern.ReplaceAllString(s, "")
+	s = strings.TrimSpace(s)
+	if quotedWhole(s) {
+		_, size := utf8.DecodeRuneInString(s)
+		_, lastSize := utf8.DecodeLastRuneInString(s)
+		s = strings.TrimSpace(s[size : len(s)-lastSize])
+	}
+	return s
+}
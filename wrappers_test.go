@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestCleanModelOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"plain", "Bonjour tout le monde", "Bonjour tout le monde"},
+		{"label", "Translation: Bonjour", "Bonjour"},
+		{"long label", "Here is the translation into French: Bonjour", "Bonjour"},
+		{"label only at start", "Il a dit translation: rien", "Il a dit translation: rien"},
+		{"whole quoted", `"Bonjour"`, "Bonjour"},
+		{"guillemets", "«Bonjour»", "Bonjour"},
+		{"corner brackets", "「こんにちは」", "こんにちは"},
+		{"quotes inside kept", `"Oui" et "non"`, `"Oui" et "non"`},
+		{"quoted phrase kept", `Il a dit "bonjour"`, `Il a dit "bonjour"`},
+		{"trailing note", "Bonjour\n\nNote: this is informal.", "Bonjour"},
+		{"parenthesized note", "Bonjour\n\n(Translator's note: informal)", "Bonjour"},
+		{"all together", "Translation: \"Bonjour\"\n\nNote: informal", "Bonjour"},
+		{"lone quote", `"`, `"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanModelOutput(tt.s); got != tt.want {
+				t.Errorf("cleanModelOutput(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}